@@ -0,0 +1,102 @@
+package stacktrace
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+MaxFormatSize, if positive, is the budget in bytes of the full format, for
+errors stored in bounded fields such as gRPC status messages or database
+columns. When the full format of an error is larger, fewer frames of each stack
+are shown, keeping the first and last ones, and then fewer levels of the chain,
+keeping the outermost and innermost ones. What is left out is replaced by a
+marker rather than cut mid-line:
+
+	Failed to sync inventory
+	 --- at github.com/palantir/shield/sync.go:88 (Sync) ---
+	     at github.com/palantir/shield/sync.go:88 (Sync)
+	     (+13 frames omitted)
+	(+5 causes omitted)
+	Caused by: connection refused
+	 --- at github.com/palantir/shield/client.go:40 (Dial) ---
+	     at github.com/palantir/shield/client.go:40 (Dial)
+	     ... 1 more
+
+If the output is still too large, it ends with "... truncated" after the last
+line that fits. The brief format is not limited.
+*/
+var MaxFormatSize = 0
+
+// omittedMarker returns "(+n nouns omitted)".
+func omittedMarker(n int, noun string) string {
+	if n != 1 {
+		noun += "s"
+	}
+	return "(+" + strconv.Itoa(n) + " " + noun + " omitted)"
+}
+
+// renderWithin renders the full format of st in at most opts.size bytes, see
+// MaxFormatSize.
+func renderWithin(st *Stacktrace, opts renderOptions) string {
+	size := opts.size
+	opts.size = 0
+	text := renderFull(st, opts)
+	if len(text) <= size {
+		return text
+	}
+
+	levels, _ := chain(st)
+	frames := 0
+	for _, level := range levels {
+		if len(level.Stack) > frames {
+			frames = len(level.Stack)
+		}
+	}
+	if fit, ok := largestWithin(frames-1, size, func(n int) string {
+		opts.maxFrames = n
+		return renderFull(st, opts)
+	}); ok {
+		return fit
+	}
+	opts.maxFrames = 1
+	if fit, ok := largestWithin((len(levels)-1)/2, size, func(n int) string {
+		opts.keepLevels = n
+		return renderFull(st, opts)
+	}); ok {
+		return fit
+	}
+	opts.keepLevels = min(1, (len(levels)-1)/2)
+	text = renderFull(st, opts)
+
+	// Keep the lines that fit
+	const marker = "\n" + truncatedMarker
+	if size <= len(marker) {
+		return truncatedMarker[:min(size, len(truncatedMarker))]
+	}
+	cut := strings.LastIndexByte(text[:size-len(marker)+1], '\n')
+	if cut < 0 {
+		cut = size - len(marker)
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+	}
+	return text[:cut] + marker
+}
+
+// largestWithin returns the output of render for the largest n from 1 to limit
+// whose output fits in size bytes, if any. The output grows with n, so n is
+// found with a binary search rather than by rendering every candidate.
+func largestWithin(limit, size int, render func(n int) string) (text string, ok bool) {
+	for lo, hi := 1, limit; lo <= hi; {
+		n := lo + (hi-lo)/2
+		if t := render(n); len(t) <= size {
+			text, ok = t, true
+			lo = n + 1
+		} else {
+			hi = n - 1
+		}
+	}
+	return text, ok
+}
@@ -0,0 +1,94 @@
+package stacktrace_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+)
+
+// deepError returns a chain of levels messages, each with a stack of frames
+// callers.
+func deepError(levels, frames int) *stacktrace.Stacktrace {
+	var err *stacktrace.Stacktrace
+	for i := levels; i > 0; i-- {
+		st := &stacktrace.Stacktrace{
+			Message:  fmt.Sprintf("level %d", i),
+			File:     fmt.Sprintf("level%d.go", i),
+			Line:     i,
+			Function: fmt.Sprintf("Level%d", i),
+		}
+		for j := 1; j <= frames; j++ {
+			st.Stack = append(st.Stack, stacktrace.Frame{File: fmt.Sprintf("level%d.go", i), Line: 100 + j, Function: fmt.Sprintf("caller%d", j)})
+		}
+		if err != nil {
+			st.Cause = err
+		}
+		err = st
+	}
+	return err
+}
+
+func TestMaxFormatSize(t *testing.T) {
+	defer func(size int) { stacktrace.MaxFormatSize = size }(stacktrace.MaxFormatSize)
+
+	err := deepError(5, 6)
+	full := fmt.Sprintf("%+s", err)
+
+	// frames are dropped first
+	stacktrace.MaxFormatSize = len(full) - 1
+	text := fmt.Sprintf("%+s", err)
+	assert.LessOrEqual(t, len(text), stacktrace.MaxFormatSize)
+	assert.Equal(t, strings.Join([]string{
+		"level 1",
+		" --- at level1.go:1 (Level1) ---",
+		"     at level1.go:101 (caller1)",
+		"     at level1.go:102 (caller2)",
+		"     at level1.go:103 (caller3)",
+		"     (+1 frame omitted)",
+		"     at level1.go:105 (caller5)",
+		"     at level1.go:106 (caller6)",
+	}, "\n"), strings.Join(strings.Split(text, "\n")[:8], "\n"))
+
+	// then the levels in the middle
+	stacktrace.MaxFormatSize = 230
+	text = fmt.Sprintf("%+s", err)
+	assert.LessOrEqual(t, len(text), stacktrace.MaxFormatSize)
+	assert.Equal(t, strings.Join([]string{
+		"level 1",
+		" --- at level1.go:1 (Level1) ---",
+		"     at level1.go:101 (caller1)",
+		"     (+5 frames omitted)",
+		"(+3 causes omitted)",
+		"Caused by: level 5",
+		" --- at level5.go:5 (Level5) ---",
+		"     at level5.go:101 (caller1)",
+		"     (+5 frames omitted)",
+	}, "\n"), text)
+
+	parsed, perr := stacktrace.Parse(text)
+	require.NoError(t, perr)
+	assert.Equal(t, "level 1: level 5", fmt.Sprintf("%#s", parsed))
+
+	// and then the lines that do not fit
+	stacktrace.MaxFormatSize = 60
+	assert.Equal(t, "level 1\n --- at level1.go:1 (Level1) ---\n... truncated", fmt.Sprintf("%+s", err))
+
+	stacktrace.MaxFormatSize = 0
+	assert.Equal(t, full, fmt.Sprintf("%+s", err))
+	assert.Equal(t, text, stacktrace.Sprint(err, stacktrace.PrintFull(), stacktrace.PrintMaxSize(230)))
+}
+
+func TestMaxFormatSizeUTF8(t *testing.T) {
+	defer func(size int) { stacktrace.MaxFormatSize = size }(stacktrace.MaxFormatSize)
+	stacktrace.MaxFormatSize = 21
+
+	text := fmt.Sprintf("%+s", &stacktrace.Stacktrace{Message: strings.Repeat("é", 30)})
+	assert.True(t, utf8.ValidString(text))
+	assert.Equal(t, "ééé\n... truncated", text)
+}
@@ -0,0 +1,72 @@
+package stacktrace
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+/*
+CaptureBuildInfo controls whether errors record the version and VCS revision of
+the binary that created them, so that their File and Line numbers can be matched
+with the exact source during incident review. The full format ends with a line
+like
+
+	Build: github.com/palantir/shield v1.4.0 (revision 1f2e3d4c)
+
+and MarshalYAML includes the information.
+*/
+var CaptureBuildInfo = false
+
+// BuildInfo identifies the binary an error was created in. See
+// CaptureBuildInfo.
+type BuildInfo struct {
+	// Path is the path of the main module.
+	Path string `yaml:"path,omitempty"`
+	// Version is the version of the main module, "(devel)" if unknown.
+	Version string `yaml:"version,omitempty"`
+	// Revision is the VCS revision the binary was built from, with a
+	// "+dirty" suffix if there were local modifications.
+	Revision string `yaml:"revision,omitempty"`
+}
+
+func (info *BuildInfo) String() string {
+	s := info.Path
+	if info.Version != "" {
+		s += " " + info.Version
+	}
+	if info.Revision != "" {
+		s += " (revision " + info.Revision + ")"
+	}
+	return s
+}
+
+var (
+	buildOnce sync.Once
+	build     *BuildInfo
+)
+
+// buildInfo returns the BuildInfo of a new error, or nil if CaptureBuildInfo is
+// disabled or the binary has no build information.
+func buildInfo() *BuildInfo {
+	if !CaptureBuildInfo {
+		return nil
+	}
+	buildOnce.Do(func() {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		build = &BuildInfo{Path: info.Main.Path, Version: info.Main.Version}
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				build.Revision = setting.Value + build.Revision
+			case "vcs.modified":
+				if setting.Value == "true" {
+					build.Revision += "+dirty"
+				}
+			}
+		}
+	})
+	return build
+}
@@ -0,0 +1,31 @@
+package stacktrace_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestBuildInfo(t *testing.T) {
+	assert.Nil(t, stacktrace.NewError("msg").(*stacktrace.Stacktrace).Build)
+
+	defer func(capture bool) { stacktrace.CaptureBuildInfo = capture }(stacktrace.CaptureBuildInfo)
+	stacktrace.CaptureBuildInfo = true
+
+	err := stacktrace.NewError("msg").(*stacktrace.Stacktrace)
+	if assert.NotNil(t, err.Build) {
+		assert.Equal(t, "github.com/palantir/stacktrace", err.Build.Path)
+	}
+
+	err = stacktrace.Propagate(err, "outer").(*stacktrace.Stacktrace)
+	err.Build = &stacktrace.BuildInfo{Path: "github.com/palantir/shield", Version: "v1.4.0", Revision: "1f2e3d4c+dirty"}
+	assert.Equal(t, `outer
+ --- at github.com/palantir/Stacktrace/buildinfo_test.go:# (TestBuildInfo) ---
+Caused by: msg
+ --- at github.com/palantir/Stacktrace/buildinfo_test.go:# (TestBuildInfo) ---
+Build: github.com/palantir/shield v1.4.0 (revision 1f2e3d4c+dirty)`, normalizeLines(fmt.Sprintf("%+s", err)))
+	assert.Equal(t, "outer: msg", fmt.Sprintf("%#s", err))
+}
@@ -0,0 +1,92 @@
+package stacktrace
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Categories for use with RegisterCategory, checked by IsClientError and
+// IsServerError. Any other name can be registered as well.
+const (
+	CategoryClient = "client"
+	CategoryServer = "server"
+)
+
+type codeRange struct {
+	first, last ErrorCode
+	category    string
+}
+
+var (
+	categoriesMu sync.RWMutex
+	categories   []codeRange
+)
+
+/*
+RegisterCategory assigns the error codes from first to last inclusive to a
+category, so that middleware and retry loops can branch on the class of an
+error rather than enumerating every Code:
+
+	func init() {
+		Stacktrace.RegisterCategory(Stacktrace.CategoryClient, 1000, 1999)
+		Stacktrace.RegisterCategory(Stacktrace.CategoryServer, 2000, 2999)
+		Stacktrace.RegisterCategory("retryable", EcodeTimeout, EcodeTimeout)
+	}
+
+RegisterCategory returns an error if the range is empty, includes NoCode or
+overlaps a range registered before.
+*/
+func RegisterCategory(category string, first, last ErrorCode) error {
+	if first > last {
+		return fmt.Errorf("stacktrace: empty code range %d-%d for category %q", first, last, category)
+	}
+	if last == NoCode {
+		return fmt.Errorf("stacktrace: code range %d-%d for category %q includes NoCode", first, last, category)
+	}
+
+	categoriesMu.Lock()
+	defer categoriesMu.Unlock()
+	for _, r := range categories {
+		if first <= r.last && r.first <= last {
+			return fmt.Errorf("stacktrace: code range %d-%d for category %q overlaps %d-%d of category %q",
+				first, last, category, r.first, r.last, r.category)
+		}
+	}
+	categories = append(categories, codeRange{first: first, last: last, category: category})
+	return nil
+}
+
+// CodeCategory returns the category that code was assigned to with
+// RegisterCategory, or "" if it has none.
+func CodeCategory(code ErrorCode) string {
+	r, _ := categoryRange(code)
+	return r.category
+}
+
+// categoryRange returns the registered range that includes code, if any.
+func categoryRange(code ErrorCode) (codeRange, bool) {
+	categoriesMu.RLock()
+	defer categoriesMu.RUnlock()
+	for _, r := range categories {
+		if r.first <= code && code <= r.last {
+			return r, true
+		}
+	}
+	return codeRange{}, false
+}
+
+// Category returns the category of the error Code of err, as returned by
+// GetCode, or "" if it has none.
+func Category(err error) string {
+	return CodeCategory(GetCode(err))
+}
+
+// IsClientError reports whether the error Code of err is in CategoryClient.
+func IsClientError(err error) bool {
+	return Category(err) == CategoryClient
+}
+
+// IsServerError reports whether the error Code of err is in CategoryServer.
+func IsServerError(err error) bool {
+	return Category(err) == CategoryServer
+}
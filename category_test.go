@@ -0,0 +1,40 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestCategory(t *testing.T) {
+	assert.NoError(t, stacktrace.RegisterCategory(stacktrace.CategoryClient, 2000, 2999))
+	assert.NoError(t, stacktrace.RegisterCategory(stacktrace.CategoryServer, 3000, 3999))
+	assert.NoError(t, stacktrace.RegisterCategory("retryable", 4000, 4000))
+
+	assert.Error(t, stacktrace.RegisterCategory("overlapping", 2999, 3000))
+	assert.Error(t, stacktrace.RegisterCategory("overlapping", 1500, 2500))
+	assert.Error(t, stacktrace.RegisterCategory("empty", 5001, 5000))
+	assert.Error(t, stacktrace.RegisterCategory("nocode", 5000, stacktrace.NoCode))
+
+	for _, test := range []struct {
+		err      error
+		category string
+		client   bool
+		server   bool
+	}{
+		{err: stacktrace.NewErrorWithCode(2000, "bad input"), category: stacktrace.CategoryClient, client: true},
+		{err: stacktrace.Propagate(stacktrace.NewErrorWithCode(3500, "down"), ""), category: stacktrace.CategoryServer, server: true},
+		{err: stacktrace.NewErrorWithCode(4000, "timeout"), category: "retryable"},
+		{err: stacktrace.NewErrorWithCode(4001, "uncategorized")},
+		{err: stacktrace.NewError("no code")},
+		{err: errors.New("plain")},
+		{err: nil},
+	} {
+		assert.Equal(t, test.category, stacktrace.Category(test.err))
+		assert.Equal(t, test.client, stacktrace.IsClientError(test.err))
+		assert.Equal(t, test.server, stacktrace.IsServerError(test.err))
+	}
+}
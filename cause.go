@@ -4,6 +4,19 @@ import (
 	"errors"
 )
 
+/*
+MaxChainDepth limits how many levels of a Stacktrace cause chain are followed
+when formatting an error or looking for its root cause. Formatted output of a
+longer chain ends with a "... truncated" marker after the last level shown. A
+value of zero or less means no limit.
+
+Cycles in the cause chain are always detected and cut short the same way,
+regardless of MaxChainDepth.
+*/
+var MaxChainDepth = 1000
+
+const truncatedMarker = "... truncated"
+
 /*
 RootCause unwraps the original error that caused the current one.
 
@@ -11,16 +24,209 @@ RootCause unwraps the original error that caused the current one.
 	if perr, ok := Stacktrace.RootCause(err).(*ParsingError); ok {
 		showError(perr.Line, perr.Column, perr.Text)
 	}
+
+RootCause also unwraps errors that are not Stacktrace errors, such as the ones
+from fmt.Errorf with %w or an *os.PathError, so the root of
+
+	Stacktrace.Propagate(fmt.Errorf("reading config: %w", &os.PathError{Err: os.ErrNotExist}), "")
+
+is os.ErrNotExist. Use StacktraceRootCause to stop at the first error that is not
+a Stacktrace instead.
+
+If the chain is cyclic or deeper than MaxChainDepth, the last level reached is
+treated as the root. If an error in the chain has several causes, such as an
+error from errors.Join, PropagateAll or one of the popular multi-error packages,
+RootCause follows the first one.
+
+If the root is a Stacktrace error, RootCause returns a new error with just its
+Message. Use RootCausePreserve to get the Stacktrace error itself.
 */
 func RootCause(err error) error {
-	for {
+	return messageOnly(rootCause(err, true))
+}
+
+/*
+RootCausePreserve is like RootCause, but returns the root as is if it is a
+Stacktrace error, so that it can be compared with == or errors.Is:
+
+	var errNotReady = Stacktrace.NewError("not ready")
+
+	if Stacktrace.RootCausePreserve(err) == errNotReady {
+		retry()
+	}
+*/
+func RootCausePreserve(err error) error {
+	return rootCause(err, true)
+}
+
+/*
+StacktraceRootCause is like RootCause, but only unwraps Stacktrace errors and
+errors with several causes. It returns the first other error in the chain as is,
+even if that error wraps another one:
+
+	err := Stacktrace.Propagate(fmt.Errorf("reading config: %w", os.ErrNotExist), "")
+	Stacktrace.StacktraceRootCause(err) // reading config: file does not exist
+*/
+func StacktraceRootCause(err error) error {
+	return messageOnly(rootCause(err, false))
+}
+
+// messageOnly replaces a *Stacktrace by an error with just its Message.
+func messageOnly(err error) error {
+	if st, ok := err.(*Stacktrace); ok && st != nil {
+		return errors.New(st.message())
+	}
+	return err
+}
+
+// rootCause returns the root of the cause chain of err, which is the last
+// *Stacktrace reached if the chain ends with one or is cut short. A nil
+// *Stacktrace ends the chain like a nil error.
+func rootCause(err error, unwrapOthers bool) error {
+	var root error
+	seen := make(map[*Stacktrace]bool)
+	for unwrapped := 0; ; {
 		st, ok := err.(*Stacktrace)
+		if ok && st == nil {
+			return root
+		}
 		if !ok {
-			return err
+			root = err
+			if MaxChainDepth > 0 && unwrapped >= MaxChainDepth {
+				return err
+			}
+			if causes, ok := branchesOf(err); ok && len(causes) > 0 {
+				err = causes[0]
+				unwrapped++
+				continue
+			}
+			cause := errors.Unwrap(err)
+			if !unwrapOthers || cause == nil {
+				return err
+			}
+			err = cause
+			unwrapped++
+			continue
+		}
+		levels, truncated := chainFrom(st, seen)
+		if len(levels) == 0 {
+			return st
+		}
+		last := levels[len(levels)-1]
+		if truncated || last.Cause == nil {
+			return last
+		}
+		root, err = last, last.Cause
+	}
+}
+
+/*
+DeepestTrace returns the innermost Stacktrace error in the cause chain of err,
+which holds the first call site where the failure was wrapped, as opposed to the
+outermost annotation:
+
+	if st := Stacktrace.DeepestTrace(err); st != nil {
+		dashboard.Record(st.File, st.Line, st.Function)
+	}
+
+Like RootCause, DeepestTrace looks through other wrappers and follows the first
+of several causes. It returns nil if there is no Stacktrace error in the chain.
+*/
+func DeepestTrace(err error) *Stacktrace {
+	return deepest(err, func(*Stacktrace) bool { return true })
+}
+
+/*
+Location returns the location of the innermost Stacktrace error in the cause
+chain of err that has one, found like DeepestTrace:
+
+	if loc, ok := Stacktrace.Location(err); ok {
+		span.SetAttributes(attribute.String("code.filepath", loc.File), attribute.Int("code.lineno", loc.Line))
+	}
+
+The second result is false if no error in the chain has a location.
+*/
+func Location(err error) (Frame, bool) {
+	st := deepest(err, func(st *Stacktrace) bool { return st.File != "" })
+	if st == nil {
+		return Frame{}, false
+	}
+	return Frame{File: st.File, Function: st.Function, Line: st.Line}, true
+}
+
+// deepest returns the innermost *Stacktrace in the chain of err for which
+// match returns true, following the first of several causes.
+func deepest(err error, match func(*Stacktrace) bool) *Stacktrace {
+	var deepest *Stacktrace
+	seen := make(map[*Stacktrace]bool)
+	for unwrapped := 0; err != nil; {
+		if st, ok := err.(*Stacktrace); ok {
+			if st == nil || seen[st] {
+				break
+			}
+			seen[st] = true
+			if match(st) {
+				deepest = st
+			}
+			err = st.Cause
+			continue
+		}
+		if MaxChainDepth > 0 && unwrapped >= MaxChainDepth {
+			break
 		}
-		if st.Cause == nil {
-			return errors.New(st.Message)
+		if causes, ok := branchesOf(err); ok && len(causes) > 0 {
+			err = causes[0]
+		} else {
+			err = errors.Unwrap(err)
+		}
+		unwrapped++
+	}
+	return deepest
+}
+
+/*
+HasStacktrace reports whether any error in the cause chain of err carries a
+captured location, so that logging middleware can decide whether to dump the
+goroutine stack itself:
+
+	if !Stacktrace.HasStacktrace(err) {
+		buf := make([]byte, 64<<10)
+		log.Printf("%v\n%s", err, buf[:runtime.Stack(buf, false)])
+	}
+
+Errors from NewMessageWithCode have no location. See Walk for which errors are
+part of the chain.
+*/
+func HasStacktrace(err error) bool {
+	found := false
+	Walk(err, func(err error) bool {
+		st, ok := err.(*Stacktrace)
+		found = ok && st.File != ""
+		return !found
+	})
+	return found
+}
+
+// chain returns the consecutive *Stacktrace levels of the cause chain starting
+// at st, outermost first. The last level's Cause is either nil or not a
+// *Stacktrace, unless truncated is true, in which case the walk was cut short
+// by a cycle or by MaxChainDepth.
+func chain(st *Stacktrace) (levels []*Stacktrace, truncated bool) {
+	return chainFrom(st, make(map[*Stacktrace]bool))
+}
+
+// chainFrom is like chain, but also stops at levels already in seen, which
+// holds the levels on the path from the error being rendered down to st, so
+// that only cycles are cut: a cause shared by several branches of an error
+// with several causes is shown in each of them, since every branch walks with
+// its own copy of seen. If st itself was seen before, levels is empty.
+func chainFrom(st *Stacktrace, seen map[*Stacktrace]bool) (levels []*Stacktrace, truncated bool) {
+	for curr, ok := st, st != nil; ok && curr != nil; curr, ok = curr.Cause.(*Stacktrace) {
+		if seen[curr] || (MaxChainDepth > 0 && len(levels) >= MaxChainDepth) {
+			return levels, true
 		}
-		err = st.Cause
+		seen[curr] = true
+		levels = append(levels, curr)
 	}
+	return levels, false
 }
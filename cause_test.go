@@ -2,6 +2,8 @@ package stacktrace_test
 
 import (
 	"errors"
+	"fmt"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -42,7 +44,117 @@ func TestRootCause(t *testing.T) {
 			err:       stacktrace.Propagate(customError("msg1"), "msg2"),
 			rootCause: customError("msg1"),
 		},
+		{
+			err:       stacktrace.Propagate(fmt.Errorf("msg1: %w", customError("msg2")), "msg3"),
+			rootCause: customError("msg2"),
+		},
+		{
+			err:       &os.PathError{Op: "open", Path: "/etc/app.yaml", Err: stacktrace.NewError("msg")},
+			rootCause: errors.New("msg"),
+		},
 	} {
 		assert.Equal(t, test.rootCause, stacktrace.RootCause(test.err))
 	}
 }
+
+func TestRootCausePreserve(t *testing.T) {
+	root := stacktrace.NewError("msg1")
+	err := fmt.Errorf("msg2: %w", stacktrace.Propagate(root, "msg3"))
+	assert.True(t, root == stacktrace.RootCausePreserve(err))
+	assert.True(t, errors.Is(stacktrace.RootCausePreserve(err), root))
+
+	plain := errors.New("msg")
+	assert.True(t, plain == stacktrace.RootCausePreserve(stacktrace.Propagate(plain, "")))
+	assert.Nil(t, stacktrace.RootCausePreserve(nil))
+}
+
+func TestDeepestTrace(t *testing.T) {
+	inner := stacktrace.NewError("msg1")
+	err := stacktrace.Propagate(fmt.Errorf("msg2: %w", stacktrace.Propagate(inner, "")), "msg3")
+	assert.True(t, inner == stacktrace.DeepestTrace(err))
+
+	joined := stacktrace.PropagateAll([]error{inner, stacktrace.NewError("other")}, "")
+	assert.True(t, inner == stacktrace.DeepestTrace(joined))
+
+	assert.Nil(t, stacktrace.DeepestTrace(nil))
+	assert.Nil(t, stacktrace.DeepestTrace(fmt.Errorf("msg: %w", errors.New("plain"))))
+
+	cyclic := stacktrace.NewError("msg1").(*stacktrace.Stacktrace)
+	cyclic.Cause = stacktrace.Propagate(cyclic, "msg2")
+	assert.Equal(t, "msg2", stacktrace.DeepestTrace(cyclic).Message)
+}
+
+func TestLocation(t *testing.T) {
+	inner := stacktrace.NewError("msg").(*stacktrace.Stacktrace)
+	err := stacktrace.Propagate(stacktrace.PropagateWithCode(inner, EcodeNoSuchPseudo, ""), "")
+	loc, ok := stacktrace.Location(err)
+	assert.True(t, ok)
+	assert.Equal(t, stacktrace.Frame{File: inner.File, Function: "TestLocation", Line: inner.Line}, loc)
+
+	coded := stacktrace.NewMessageWithCode(EcodeNoSuchPseudo, "msg")
+	loc, ok = stacktrace.Location(fmt.Errorf("wrapped: %w", stacktrace.Propagate(coded, "")))
+	assert.True(t, ok)
+	assert.Equal(t, "TestLocation", loc.Function)
+
+	_, ok = stacktrace.Location(coded)
+	assert.False(t, ok)
+	_, ok = stacktrace.Location(nil)
+	assert.False(t, ok)
+}
+
+func TestHasStacktrace(t *testing.T) {
+	assert.False(t, stacktrace.HasStacktrace(nil))
+	assert.False(t, stacktrace.HasStacktrace(errors.New("plain")))
+	assert.False(t, stacktrace.HasStacktrace(stacktrace.NewMessageWithCode(EcodeNoSuchPseudo, "msg")))
+	assert.True(t, stacktrace.HasStacktrace(stacktrace.NewError("msg")))
+	assert.True(t, stacktrace.HasStacktrace(fmt.Errorf("msg: %w", stacktrace.NewError("msg"))))
+	assert.True(t, stacktrace.HasStacktrace(errors.Join(errors.New("plain"), stacktrace.NewError("msg"))))
+}
+
+func TestStacktraceRootCause(t *testing.T) {
+	wrapped := fmt.Errorf("msg1: %w", customError("msg2"))
+	assert.Equal(t, wrapped, stacktrace.StacktraceRootCause(stacktrace.Propagate(wrapped, "msg3")))
+	assert.Equal(t, errors.New("msg"), stacktrace.StacktraceRootCause(stacktrace.NewError("msg")))
+	assert.Nil(t, stacktrace.StacktraceRootCause(nil))
+}
+
+func TestRootCauseTruncated(t *testing.T) {
+	cyclic := stacktrace.NewError("msg1").(*stacktrace.Stacktrace)
+	cyclic.Cause = stacktrace.Propagate(cyclic, "msg2")
+	assert.Equal(t, errors.New("msg2"), stacktrace.RootCause(cyclic))
+
+	defer func(depth int) { stacktrace.MaxChainDepth = depth }(stacktrace.MaxChainDepth)
+	stacktrace.MaxChainDepth = 2
+	err := stacktrace.Propagate(stacktrace.Propagate(stacktrace.NewError("msg1"), "msg2"), "msg3")
+	assert.Equal(t, errors.New("msg2"), stacktrace.RootCause(err))
+}
+
+// loopError is an error with several causes, the first of which is itself.
+type loopError struct{}
+
+func (e *loopError) Error() string   { return "loop" }
+func (e *loopError) Unwrap() []error { return []error{e} }
+
+func TestRootCauseLoop(t *testing.T) {
+	loop := &loopError{}
+	assert.Equal(t, loop, stacktrace.RootCause(loop))
+	outer := &stacktrace.Stacktrace{Message: "msg", Cause: loop}
+	assert.Equal(t, loop, stacktrace.StacktraceRootCause(outer))
+	assert.Equal(t, outer, stacktrace.DeepestTrace(outer))
+	assert.Nil(t, stacktrace.DeepestTrace(loop))
+}
+
+func TestRootCauseTypedNil(t *testing.T) {
+	var nilTrace *stacktrace.Stacktrace
+	assert.Nil(t, stacktrace.RootCause(nilTrace))
+	assert.Nil(t, stacktrace.RootCausePreserve(nilTrace))
+	assert.Nil(t, stacktrace.DeepestTrace(nilTrace))
+
+	err := &stacktrace.Stacktrace{Message: "msg", Cause: nilTrace}
+	assert.Equal(t, errors.New("msg"), stacktrace.RootCause(err))
+	assert.Equal(t, error(err), stacktrace.RootCausePreserve(fmt.Errorf("wrapped: %w", err)))
+	assert.Equal(t, err, stacktrace.DeepestTrace(err))
+
+	wrapped := fmt.Errorf("wrapped: %w", nilTrace)
+	assert.Equal(t, wrapped, stacktrace.RootCause(wrapped))
+}
@@ -0,0 +1,157 @@
+/*
+Package cborstacktrace encodes Stacktrace errors as CBOR (RFC 8949), a compact
+binary format for embedding errors in event bus messages, such as on NATS or
+Kafka, where the size of JSON and its lack of integer types are a concern:
+
+	data, err := cborstacktrace.Marshal(jobErr)
+	...
+	jobErr, err := cborstacktrace.Unmarshal(data)
+
+The error is encoded as the stacktracepb.Error message, with each message a map
+from its field numbers to their values, so that both encodings keep the same
+parts of an error. Times are encoded as CBOR date/time strings (tag 0) to keep
+their full precision without resorting to floating point numbers.
+*/
+package cborstacktrace
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/palantir/stacktrace/stacktracepb"
+)
+
+var encMode, decMode = func() (cbor.EncMode, cbor.DecMode) {
+	enc, err := cbor.EncOptions{
+		Sort:    cbor.SortCoreDeterministic,
+		Time:    cbor.TimeRFC3339Nano,
+		TimeTag: cbor.EncTagRequired,
+	}.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	dec, err := cbor.DecOptions{}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return enc, dec
+}()
+
+/*
+Marshal encodes err as CBOR, keeping the same parts of it as
+stacktracepb.ToProto: its chain with codes, frames and causes, with causes that
+are not Stacktraces reduced to their text. MarshalBinary of a Stacktrace keeps
+all of it.
+*/
+func Marshal(err error) ([]byte, error) {
+	pb := stacktracepb.ToProto(err)
+	if pb == nil {
+		return encMode.Marshal(nil)
+	}
+	return encMode.Marshal(encodeMessage(pb.ProtoReflect()))
+}
+
+/*
+Unmarshal decodes an error encoded by Marshal, as stacktracepb.FromProto does.
+The first result is nil if nil was encoded.
+*/
+func Unmarshal(data []byte) (error, error) {
+	var pb stacktracepb.Error
+	if err := decodeMessage(data, pb.ProtoReflect()); err != nil {
+		return nil, err
+	}
+	return stacktracepb.FromProto(&pb), nil
+}
+
+// encodeMessage returns the populated fields of m by field number.
+func encodeMessage(m protoreflect.Message) map[uint64]interface{} {
+	fields := make(map[uint64]interface{})
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if !fd.IsList() {
+			fields[uint64(fd.Number())] = encodeValue(v)
+			return true
+		}
+		list := v.List()
+		items := make([]interface{}, list.Len())
+		for i := range items {
+			items[i] = encodeValue(list.Get(i))
+		}
+		fields[uint64(fd.Number())] = items
+		return true
+	})
+	return fields
+}
+
+func encodeValue(v protoreflect.Value) interface{} {
+	switch v := v.Interface().(type) {
+	case protoreflect.Message:
+		if ts, ok := v.Interface().(*timestamppb.Timestamp); ok {
+			return ts.AsTime()
+		}
+		return encodeMessage(v)
+	case protoreflect.EnumNumber:
+		return int32(v)
+	default:
+		return v
+	}
+}
+
+// decodeMessage sets the fields of m from data, skipping unknown field
+// numbers, which a newer version of the message may have.
+func decodeMessage(data []byte, m protoreflect.Message) error {
+	var fields map[uint64]cbor.RawMessage
+	if err := decMode.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for num, raw := range fields {
+		fd := m.Descriptor().Fields().ByNumber(protoreflect.FieldNumber(num))
+		if fd == nil {
+			continue
+		}
+		if !fd.IsList() {
+			v, err := decodeValue(raw, m.NewField(fd))
+			if err != nil {
+				return err
+			}
+			m.Set(fd, v)
+			continue
+		}
+		var items []cbor.RawMessage
+		if err := decMode.Unmarshal(raw, &items); err != nil {
+			return err
+		}
+		list := m.Mutable(fd).List()
+		for _, item := range items {
+			v, err := decodeValue(item, list.NewElement())
+			if err != nil {
+				return err
+			}
+			list.Append(v)
+		}
+	}
+	return nil
+}
+
+// decodeValue decodes data into zero, a new value of the type of a field.
+func decodeValue(data []byte, zero protoreflect.Value) (protoreflect.Value, error) {
+	if m, ok := zero.Interface().(protoreflect.Message); ok {
+		if ts, ok := m.Interface().(*timestamppb.Timestamp); ok {
+			var t time.Time
+			if err := decMode.Unmarshal(data, &t); err != nil {
+				return zero, err
+			}
+			ts.Seconds, ts.Nanos = t.Unix(), int32(t.Nanosecond())
+			return zero, nil
+		}
+		return zero, decodeMessage(data, m)
+	}
+	v := reflect.New(reflect.TypeOf(zero.Interface()))
+	if err := decMode.Unmarshal(data, v.Interface()); err != nil {
+		return zero, err
+	}
+	return protoreflect.ValueOf(v.Elem().Interface()), nil
+}
@@ -0,0 +1,96 @@
+package cborstacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+	"github.com/palantir/stacktrace/cborstacktrace"
+)
+
+func TestRoundTrip(t *testing.T) {
+	defer func(capture bool) { stacktrace.CaptureStack = capture }(stacktrace.CaptureStack)
+	defer func(capture bool) { stacktrace.CaptureTimestamps = capture }(stacktrace.CaptureTimestamps)
+	stacktrace.CaptureStack = true
+	stacktrace.CaptureTimestamps = true
+
+	err := stacktrace.NewErrorWithSeverity(stacktrace.SeverityWarning, "inner %d", 1)
+	err = stacktrace.AddSuppressed(err, stacktrace.NewErrorWithStringCode("CLEANUP", "cleanup failed"))
+	err = stacktrace.PropagateAll([]error{err, errors.New("plain")}, "middle")
+	err = stacktrace.WithRetryAfter(stacktrace.MarkRetryable(err), time.Minute)
+	err = stacktrace.PropagateWithCode(err, 7, "outer")
+
+	data, merr := cborstacktrace.Marshal(err)
+	require.NoError(t, merr)
+	decoded, uerr := cborstacktrace.Unmarshal(data)
+	require.NoError(t, uerr)
+
+	assert.Equal(t, fmt.Sprintf("%+s", err), fmt.Sprintf("%+s", decoded))
+	assert.Equal(t, fmt.Sprintf("%#s", err), fmt.Sprintf("%#s", decoded))
+	assert.Equal(t, stacktrace.ErrorCode(7), stacktrace.GetCode(decoded))
+	assert.Equal(t, stacktrace.SeverityWarning, stacktrace.GetSeverity(decoded))
+	assert.True(t, stacktrace.IsRetryable(decoded))
+	_, ok := stacktrace.RetryAfter(decoded)
+	assert.True(t, ok)
+	assert.Equal(t, err.(*stacktrace.Stacktrace).Stack, decoded.(*stacktrace.Stacktrace).Stack)
+	assert.True(t, stacktrace.Timestamp(err).Equal(stacktrace.Timestamp(decoded)))
+}
+
+func TestNil(t *testing.T) {
+	data, err := cborstacktrace.Marshal(nil)
+	require.NoError(t, err)
+	decoded, err := cborstacktrace.Unmarshal(data)
+	require.NoError(t, err)
+	assert.Nil(t, decoded)
+
+	_, err = cborstacktrace.Unmarshal([]byte{0xff})
+	assert.Error(t, err)
+}
+
+func TestCycle(t *testing.T) {
+	inner := stacktrace.NewError("inner").(*stacktrace.Stacktrace)
+	outer := stacktrace.Propagate(inner, "outer").(*stacktrace.Stacktrace)
+	inner.Cause = outer
+
+	data, err := cborstacktrace.Marshal(outer)
+	require.NoError(t, err)
+	decoded, err := cborstacktrace.Unmarshal(data)
+	require.NoError(t, err)
+	assert.Equal(t, "outer: inner: ... truncated", fmt.Sprintf("%#s", decoded))
+}
+
+// wrapped is like a *multierror.Error from github.com/hashicorp/go-multierror.
+type wrapped []error
+
+func (w wrapped) Error() string          { return "several errors" }
+func (w wrapped) WrappedErrors() []error { return w }
+
+func TestMultiErrors(t *testing.T) {
+	data, err := cborstacktrace.Marshal(stacktrace.Propagate(wrapped{errors.New("a"), nil, errors.New("b")}, "outer"))
+	require.NoError(t, err)
+	decoded, err := cborstacktrace.Unmarshal(data)
+	require.NoError(t, err)
+	assert.Equal(t, "outer: [a; b]", fmt.Sprintf("%#s", decoded))
+
+	data, err = cborstacktrace.Marshal(wrapped{nil})
+	require.NoError(t, err)
+	decoded, err = cborstacktrace.Unmarshal(data)
+	require.NoError(t, err)
+	assert.Nil(t, decoded)
+}
+
+func TestMaxChainDepth(t *testing.T) {
+	defer func(depth int) { stacktrace.MaxChainDepth = depth }(stacktrace.MaxChainDepth)
+	stacktrace.MaxChainDepth = 2
+
+	data, err := cborstacktrace.Marshal(stacktrace.Propagate(stacktrace.Propagate(stacktrace.NewError("inner"), "middle"), "outer"))
+	require.NoError(t, err)
+	decoded, err := cborstacktrace.Unmarshal(data)
+	require.NoError(t, err)
+	assert.Equal(t, "outer: middle: "+stacktrace.ChainTruncated, fmt.Sprintf("%#s", decoded))
+}
@@ -0,0 +1,82 @@
+package cleanpath
+
+import (
+	"sort"
+	"strings"
+)
+
+/*
+Chain returns a function that applies each of the given functions in turn, each
+one to the result of the previous one. It can be used to build a policy for
+Stacktrace.CleanPath:
+
+	stacktrace.CleanPath = cleanpath.Chain(
+		cleanpath.Default,
+		cleanpath.TrimPrefixes("github.com/", "gitlab.mycorp.com/"),
+		cleanpath.KeepLastN(3),
+	)
+*/
+func Chain(fns ...func(string) string) func(string) string {
+	return func(path string) string {
+		for _, fn := range fns {
+			path = fn(path)
+		}
+		return path
+	}
+}
+
+/*
+FirstOf returns a function that applies the first of the given functions that
+changes the path, or leaves the path unchanged if none of them does.
+*/
+func FirstOf(fns ...func(string) string) func(string) string {
+	return func(path string) string {
+		for _, fn := range fns {
+			if cleaned := fn(path); cleaned != path {
+				return cleaned
+			}
+		}
+		return path
+	}
+}
+
+/*
+TrimPrefixes returns a function that removes the longest of the given prefixes
+that the path starts with. Paths that start with none of them are returned
+unchanged.
+*/
+func TrimPrefixes(prefixes ...string) func(string) string {
+	sorted := append([]string(nil), prefixes...)
+	// Sort in decreasing order by length so the longest matching prefix is removed
+	sort.Stable(longestFirst(sorted))
+	return func(path string) string {
+		for _, prefix := range sorted {
+			if strings.HasPrefix(path, prefix) {
+				return path[len(prefix):]
+			}
+		}
+		return path
+	}
+}
+
+/*
+KeepLastN returns a function that keeps only the last n elements of a slash
+separated path, so KeepLastN(2) turns "github.com/foo/bar/baz.go" into
+"bar/baz.go". Paths with at most n elements, and all paths if n is not
+positive, are returned unchanged.
+*/
+func KeepLastN(n int) func(string) string {
+	return func(path string) string {
+		if n <= 0 {
+			return path
+		}
+		end := len(path)
+		for i := 0; i < n; i++ {
+			end = strings.LastIndex(path[:end], "/")
+			if end < 0 {
+				return path
+			}
+		}
+		return path[end+1:]
+	}
+}
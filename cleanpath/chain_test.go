@@ -0,0 +1,54 @@
+package cleanpath_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace/cleanpath"
+)
+
+func TestChain(t *testing.T) {
+	clean := cleanpath.Chain(
+		cleanpath.TrimPrefixes("github.com/", "gitlab.mycorp.com/"),
+		strings.ToUpper,
+	)
+	assert.Equal(t, "FOO/BAR/BAZ.GO", clean("github.com/foo/bar/baz.go"))
+	assert.Equal(t, "FOO/BAR/BAZ.GO", clean("gitlab.mycorp.com/foo/bar/baz.go"))
+	assert.Equal(t, "path", cleanpath.Chain()("path"))
+}
+
+func TestFirstOf(t *testing.T) {
+	clean := cleanpath.FirstOf(
+		cleanpath.TrimPrefixes("a/"),
+		cleanpath.TrimPrefixes("a/b/", "c/"),
+	)
+	assert.Equal(t, "b/c.go", clean("a/b/c.go"))
+	assert.Equal(t, "d.go", clean("c/d.go"))
+	assert.Equal(t, "e/f.go", clean("e/f.go"))
+}
+
+func TestTrimPrefixes(t *testing.T) {
+	trim := cleanpath.TrimPrefixes("github.com/", "github.com/palantir/")
+	assert.Equal(t, "stacktrace/stacktrace.go", trim("github.com/palantir/stacktrace/stacktrace.go"))
+	assert.Equal(t, "foo/bar.go", trim("github.com/foo/bar.go"))
+	assert.Equal(t, "gitlab.com/foo/bar.go", trim("gitlab.com/foo/bar.go"))
+}
+
+func TestKeepLastN(t *testing.T) {
+	for _, testcase := range []struct {
+		n        int
+		path     string
+		expected string
+	}{
+		{n: 2, path: "github.com/foo/bar/baz.go", expected: "bar/baz.go"},
+		{n: 1, path: "github.com/foo/bar/baz.go", expected: "baz.go"},
+		{n: 4, path: "github.com/foo/bar/baz.go", expected: "github.com/foo/bar/baz.go"},
+		{n: 5, path: "github.com/foo/bar/baz.go", expected: "github.com/foo/bar/baz.go"},
+		{n: 0, path: "github.com/foo/bar/baz.go", expected: "github.com/foo/bar/baz.go"},
+		{n: 2, path: "/abs/foo/baz.go", expected: "foo/baz.go"},
+	} {
+		assert.Equal(t, testcase.expected, cleanpath.KeepLastN(testcase.n)(testcase.path), "testcase: %+v", testcase)
+	}
+}
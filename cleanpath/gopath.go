@@ -2,6 +2,7 @@ package cleanpath
 
 import (
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -13,22 +14,49 @@ environment variable. If $GOPATH is empty or the input path is not contained
 within any of the src directories in $GOPATH, the original path is returned. If
 the input path is contained within multiple of the src directories in $GOPATH,
 it is made relative to the longest one of them.
+
+Both slashes and backslashes are accepted as separators, and the result always
+uses slashes, so paths recorded on Windows display the same as everywhere else.
 */
 func RemoveGoPath(path string) string {
 	dirs := filepath.SplitList(os.Getenv("GOPATH"))
 	// Sort in decreasing order by length so the longest matching prefix is removed
 	sort.Stable(longestFirst(dirs))
 	for _, dir := range dirs {
-		srcdir := filepath.Join(dir, "src")
-		rel, err := filepath.Rel(srcdir, path)
-		// filepath.Rel can traverse parent directories, don't want those
-		if err == nil && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		if dir == "" {
+			continue
+		}
+		if rel, ok := relative(dir+"/src", path); ok {
 			return rel
 		}
 	}
 	return path
 }
 
+// relative returns file relative to dir, with slashes as separators, and
+// whether file is within dir at all. Windows paths, which start with a drive
+// letter, are compared case-insensitively.
+func relative(dir, file string) (string, bool) {
+	dir = path.Clean(strings.ReplaceAll(dir, `\`, "/"))
+	file = path.Clean(strings.ReplaceAll(file, `\`, "/"))
+	if !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+	if len(file) <= len(dir) {
+		return "", false
+	}
+	prefix := file[:len(dir)]
+	if prefix != dir && !(hasDriveLetter(dir) && strings.EqualFold(prefix, dir)) {
+		return "", false
+	}
+	return file[len(dir):], true
+}
+
+func hasDriveLetter(path string) bool {
+	return len(path) >= 2 && path[1] == ':' &&
+		('a' <= path[0] && path[0] <= 'z' || 'A' <= path[0] && path[0] <= 'Z')
+}
+
 type longestFirst []string
 
 func (strs longestFirst) Len() int           { return len(strs) }
@@ -47,6 +47,30 @@ func TestRemoveGoPath(t *testing.T) {
 			path:     "/some/src/dir/src/pkg/prog.go",
 			expected: "pkg/prog.go",
 		},
+		{
+			// matching dir after a nonmatching and an empty one
+			gopath:   []string{"/other/dir", "", "/some/dir"},
+			path:     "/some/dir/src/pkg/prog.go",
+			expected: "pkg/prog.go",
+		},
+		{
+			// backslashes and a trailing separator
+			gopath:   []string{`/some/dir/`},
+			path:     `\some\dir\src\pkg\prog.go`,
+			expected: "pkg/prog.go",
+		},
+		{
+			// mixed separators
+			gopath:   []string{`\some\dir`},
+			path:     "/some/dir/src/pkg/sub/prog.go",
+			expected: "pkg/sub/prog.go",
+		},
+		{
+			// prefix of a directory name
+			gopath:   []string{"/some/di"},
+			path:     "/some/dir/src/pkg/prog.go",
+			expected: "/some/dir/src/pkg/prog.go",
+		},
 	} {
 		gopath := strings.Join(testcase.gopath, string(filepath.ListSeparator))
 		err := os.Setenv("GOPATH", gopath)
@@ -0,0 +1,34 @@
+package cleanpath
+
+import (
+	"os"
+	"runtime"
+)
+
+/*
+RemoveGoRoot makes a path relative to the src directory of the Go installation,
+so standard library files display as "runtime/proc.go". The installation is
+$GOROOT, or the one the program was built with if that is not set. If the input
+path is not contained within it, the original path is returned.
+*/
+func RemoveGoRoot(path string) string {
+	goroot := os.Getenv("GOROOT")
+	if goroot == "" {
+		goroot = runtime.GOROOT()
+	}
+	if goroot == "" {
+		return path
+	}
+	if rel, ok := relative(goroot+"/src", path); ok {
+		return rel
+	}
+	return path
+}
+
+/*
+Default cleans a path with the first of RemoveGoPath, RemoveModuleCache,
+RemoveGoRoot and RemoveModuleRoot that applies to it, so code in $GOPATH, in the
+module cache and in other modules displays as its import path and the standard
+library relative to $GOROOT, whether or not the binary was built with -trimpath.
+*/
+var Default = FirstOf(RemoveGoPath, RemoveModuleCache, RemoveGoRoot, RemoveModuleRoot)
@@ -0,0 +1,36 @@
+package cleanpath_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace/cleanpath"
+)
+
+func TestRemoveGoRoot(t *testing.T) {
+	defer os.Setenv("GOROOT", os.Getenv("GOROOT"))
+	assert.NoError(t, os.Setenv("GOROOT", "/usr/local/go"), "error setting goroot")
+
+	assert.Equal(t, "runtime/proc.go", cleanpath.RemoveGoRoot("/usr/local/go/src/runtime/proc.go"))
+	assert.Equal(t, "/some/dir/src/pkg/prog.go", cleanpath.RemoveGoRoot("/some/dir/src/pkg/prog.go"))
+}
+
+func TestDefault(t *testing.T) {
+	defer os.Setenv("GOROOT", os.Getenv("GOROOT"))
+	defer os.Setenv("GOPATH", os.Getenv("GOPATH"))
+	defer os.Setenv("GOMODCACHE", os.Getenv("GOMODCACHE"))
+	assert.NoError(t, os.Setenv("GOROOT", "/usr/local/go"), "error setting goroot")
+	assert.NoError(t, os.Setenv("GOPATH", "/some/dir"), "error setting gopath")
+	assert.NoError(t, os.Setenv("GOMODCACHE", ""), "error setting gomodcache")
+
+	for path, expected := range map[string]string{
+		"/some/dir/src/pkg/prog.go":                          "pkg/prog.go",
+		"/some/dir/pkg/mod/github.com/foo/bar@v1.4.2/baz.go": "github.com/foo/bar/baz.go",
+		"/usr/local/go/src/runtime/proc.go":                  "runtime/proc.go",
+		"/elsewhere/prog.go":                                 "/elsewhere/prog.go",
+	} {
+		assert.Equal(t, expected, cleanpath.Default(path), "path: %s", path)
+	}
+}
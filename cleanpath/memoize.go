@@ -0,0 +1,40 @@
+package cleanpath
+
+import (
+	"sync"
+)
+
+/*
+Memoize returns a function that caches the results of fn, keyed by path, so fn
+runs once per distinct path. Errors are created over and over again in the same
+few files, which makes this worthwhile for functions that consult the
+environment or the file system. The cache holds at most size paths; once it is
+full it is emptied and starts over. The returned function is safe for
+concurrent use if fn is.
+
+Since results are cached, fn should only depend on the path: a memoized
+RemoveGoPath does not notice later changes to $GOPATH.
+*/
+func Memoize(fn func(string) string, size int) func(string) string {
+	var (
+		mu    sync.RWMutex
+		cache = make(map[string]string)
+	)
+	return func(path string) string {
+		mu.RLock()
+		cleaned, ok := cache[path]
+		mu.RUnlock()
+		if ok {
+			return cleaned
+		}
+
+		cleaned = fn(path)
+		mu.Lock()
+		if len(cache) >= size {
+			cache = make(map[string]string)
+		}
+		cache[path] = cleaned
+		mu.Unlock()
+		return cleaned
+	}
+}
@@ -0,0 +1,42 @@
+package cleanpath_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace/cleanpath"
+)
+
+func TestMemoize(t *testing.T) {
+	var calls int32
+	clean := cleanpath.Memoize(func(path string) string {
+		atomic.AddInt32(&calls, 1)
+		return strings.TrimPrefix(path, "/src/")
+	}, 2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "pkg/prog.go", clean("/src/pkg/prog.go"))
+		}()
+	}
+	wg.Wait()
+	calls1 := atomic.LoadInt32(&calls)
+	assert.True(t, calls1 >= 1 && calls1 <= 10, "calls: %d", calls1)
+	assert.Equal(t, "pkg/prog.go", clean("/src/pkg/prog.go"))
+	assert.Equal(t, calls1, atomic.LoadInt32(&calls))
+
+	// Filling the cache beyond its size starts over
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, fmt.Sprintf("pkg/%d.go", i), clean(fmt.Sprintf("/src/pkg/%d.go", i)))
+	}
+	clean("/src/pkg/prog.go")
+	assert.Equal(t, calls1+4, atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,89 @@
+package cleanpath
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+/*
+RemoveModuleVersion removes the @version suffix from the module directory in a
+path, so "github.com/foo/bar@v1.4.2/baz.go" becomes "github.com/foo/bar/baz.go".
+Paths that do not contain a versioned directory are returned unchanged, others
+always use slashes as separators.
+*/
+func RemoveModuleVersion(path string) string {
+	segments := strings.Split(strings.ReplaceAll(path, `\`, "/"), "/")
+	changed := false
+	for i, segment := range segments {
+		// Only the directory segments can be versioned, not the file name
+		if i == len(segments)-1 {
+			break
+		}
+		if at := strings.LastIndex(segment, "@v"); at > 0 {
+			segments[i] = segment[:at]
+			changed = true
+		}
+	}
+	if !changed {
+		return path
+	}
+	return strings.Join(segments, "/")
+}
+
+/*
+RemoveModuleCache makes a path inside the module cache relative to the cache,
+leaving just the import path of the module followed by the File within it. The
+@version segment is removed and the case-encoding the module cache applies to
+upper-case letters ("!foo" for "Foo") is undone, so
+
+	/home/user/go/pkg/mod/github.com/!foo/bar@v1.4.2/baz.go
+
+becomes "github.com/Foo/bar/baz.go". The module cache is $GOMODCACHE, or pkg/mod
+within each of the directories in $GOPATH if that is not set. Paths outside the
+module cache are returned unchanged.
+*/
+func RemoveModuleCache(path string) string {
+	var dirs []string
+	if modcache := os.Getenv("GOMODCACHE"); modcache != "" {
+		dirs = []string{modcache}
+	} else {
+		for _, dir := range filepath.SplitList(os.Getenv("GOPATH")) {
+			if dir == "" {
+				continue
+			}
+			dirs = append(dirs, filepath.Join(dir, "pkg", "mod"))
+		}
+	}
+	// Sort in decreasing order by length so the longest matching prefix is removed
+	sort.Stable(longestFirst(dirs))
+	for _, dir := range dirs {
+		if rel, ok := relative(dir, path); ok {
+			return unescapeModulePath(RemoveModuleVersion(rel))
+		}
+	}
+	return path
+}
+
+// unescapeModulePath reverses the module cache encoding of upper-case letters
+// as an exclamation mark followed by the lower-case letter.
+func unescapeModulePath(path string) string {
+	if !strings.Contains(path, "!") {
+		return path
+	}
+	var b strings.Builder
+	bang := false
+	for _, r := range path {
+		if bang {
+			r = unicode.ToUpper(r)
+			bang = false
+		} else if r == '!' {
+			bang = true
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package cleanpath_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace/cleanpath"
+)
+
+func TestRemoveModuleVersion(t *testing.T) {
+	for _, testcase := range []struct {
+		path     string
+		expected string
+	}{
+		{
+			// no version
+			path:     "github.com/foo/bar/baz.go",
+			expected: "github.com/foo/bar/baz.go",
+		},
+		{
+			// versioned module directory
+			path:     "github.com/foo/bar@v1.4.2/baz.go",
+			expected: "github.com/foo/bar/baz.go",
+		},
+		{
+			// pseudo-version within an absolute path
+			path:     "/go/pkg/mod/github.com/foo/bar@v0.0.0-20200101000000-abcdef123456/sub/baz.go",
+			expected: "/go/pkg/mod/github.com/foo/bar/sub/baz.go",
+		},
+		{
+			// file name containing @v is left alone
+			path:     "github.com/foo/bar/baz@v1.go",
+			expected: "github.com/foo/bar/baz@v1.go",
+		},
+	} {
+		cleaned := cleanpath.RemoveModuleVersion(testcase.path)
+		assert.Equal(t, testcase.expected, cleaned, "testcase: %+v", testcase)
+	}
+}
+
+func TestRemoveModuleCache(t *testing.T) {
+	defer os.Setenv("GOPATH", os.Getenv("GOPATH"))
+	defer os.Setenv("GOMODCACHE", os.Getenv("GOMODCACHE"))
+
+	for _, testcase := range []struct {
+		gopath     []string
+		gomodcache string
+		path       string
+		expected   string
+	}{
+		{
+			// module cache inside gopath
+			gopath:   []string{"/some/dir"},
+			path:     "/some/dir/pkg/mod/github.com/foo/bar@v1.4.2/baz.go",
+			expected: "github.com/foo/bar/baz.go",
+		},
+		{
+			// upper-case letters are encoded in the module cache
+			gopath:   []string{"/some/dir"},
+			path:     "/some/dir/pkg/mod/github.com/!foo/!bar@v1.4.2/baz.go",
+			expected: "github.com/Foo/Bar/baz.go",
+		},
+		{
+			// gomodcache takes precedence over gopath
+			gopath:     []string{"/some/dir"},
+			gomodcache: "/cache",
+			path:       "/cache/github.com/foo/bar@v1.4.2/baz.go",
+			expected:   "github.com/foo/bar/baz.go",
+		},
+		{
+			// windows paths, with a different case for the drive letter
+			gomodcache: `C:\Users\me\go\pkg\mod`,
+			path:       "c:/Users/me/go/pkg/mod/github.com/foo/bar@v1.4.2/baz.go",
+			expected:   "github.com/foo/bar/baz.go",
+		},
+		{
+			// outside the module cache
+			gopath:   []string{"/some/dir"},
+			path:     "/some/dir/src/pkg/prog.go",
+			expected: "/some/dir/src/pkg/prog.go",
+		},
+	} {
+		gopath := strings.Join(testcase.gopath, string(filepath.ListSeparator))
+		assert.NoError(t, os.Setenv("GOPATH", gopath), "error setting gopath")
+		assert.NoError(t, os.Setenv("GOMODCACHE", testcase.gomodcache), "error setting gomodcache")
+
+		cleaned := cleanpath.RemoveModuleCache(testcase.path)
+		assert.Equal(t, testcase.expected, cleaned, "testcase: %+v", testcase)
+	}
+}
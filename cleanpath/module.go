@@ -0,0 +1,98 @@
+package cleanpath
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/*
+RemoveModuleRoot turns a path to a File within a Go module into the import path
+of the module followed by the File's path within it, so that developer builds
+and builds with -trimpath display the same paths.
+
+Absolute paths are resolved against the closest go.mod above them. If there is
+none, for example because the binary runs on a different machine than it was
+built on, the path of the main module of the binary is looked for among the
+directories in the path instead. Relative paths, as recorded by -trimpath
+builds, only have their @version segment removed. Paths that do not belong to
+any module are returned unchanged.
+*/
+func RemoveModuleRoot(path string) string {
+	if !filepath.IsAbs(path) {
+		return RemoveModuleVersion(path)
+	}
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if module := modulePathAt(dir); module != "" {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return path
+			}
+			return module + "/" + filepath.ToSlash(rel)
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+	return removeMainModule(path)
+}
+
+// removeMainModule looks for the last element of the main module path among
+// the directories in path and replaces everything up to it by the module path.
+func removeMainModule(path string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Path == "" {
+		return path
+	}
+	base := info.Main.Path[strings.LastIndex(info.Main.Path, "/")+1:]
+	segments := strings.Split(filepath.ToSlash(path), "/")
+	for i := len(segments) - 2; i >= 0; i-- {
+		if segments[i] == base {
+			return strings.Join(append([]string{info.Main.Path}, segments[i+1:]...), "/")
+		}
+	}
+	return path
+}
+
+// modulePaths caches the module path declared by the go.mod in a directory,
+// or "" if there is no go.mod, so the file system is read once per directory.
+var modulePaths sync.Map
+
+func modulePathAt(dir string) string {
+	if module, ok := modulePaths.Load(dir); ok {
+		return module.(string)
+	}
+	module := readModulePath(filepath.Join(dir, "go.mod"))
+	modulePaths.Store(dir, module)
+	return module
+}
+
+// readModulePath returns the path in the module directive of a go.mod file.
+func readModulePath(gomod string) string {
+	f, err := os.Open(gomod)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "module" {
+			continue
+		}
+		if module, err := strconv.Unquote(fields[1]); err == nil {
+			return module
+		}
+		return fields[1]
+	}
+	return ""
+}
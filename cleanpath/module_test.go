@@ -0,0 +1,47 @@
+package cleanpath_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace/cleanpath"
+)
+
+func TestRemoveModuleRoot(t *testing.T) {
+	dir := t.TempDir()
+	gomod := "// comment\nmodule \"github.com/foo/bar\" // trailing\n\ngo 1.21\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o644))
+
+	for _, testcase := range []struct {
+		path     string
+		expected string
+	}{
+		{
+			// developer build, file in the module root
+			path:     filepath.Join(dir, "baz.go"),
+			expected: "github.com/foo/bar/baz.go",
+		},
+		{
+			// developer build, file in a package of the module
+			path:     filepath.Join(dir, "pkg", "sub", "baz.go"),
+			expected: "github.com/foo/bar/pkg/sub/baz.go",
+		},
+		{
+			// -trimpath build, file in the main module
+			path:     "github.com/foo/bar/pkg/sub/baz.go",
+			expected: "github.com/foo/bar/pkg/sub/baz.go",
+		},
+		{
+			// -trimpath build, file in a dependency
+			path:     "github.com/foo/dep@v1.4.2/baz.go",
+			expected: "github.com/foo/dep/baz.go",
+		},
+	} {
+		cleaned := cleanpath.RemoveModuleRoot(testcase.path)
+		assert.Equal(t, testcase.expected, cleaned, "testcase: %+v", testcase)
+	}
+}
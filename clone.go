@@ -0,0 +1,72 @@
+package stacktrace
+
+/*
+Clone returns a deep copy of the Stacktrace levels of err, so that one consumer
+of a shared error can adjust it without racing with others:
+
+	copied := Stacktrace.Clone(err).(*Stacktrace.Stacktrace)
+	copied.Message = redact(copied.Message)
+
+Each consecutive Stacktrace Cause is copied, along with the Stack, the
+Suppressed errors, the Process, Build and Retryable mark, and the arguments of
+the Message. A lazy message from NewErrorLazy or PropagateLazy is produced and
+stored in the Message of the copy, so that it can be changed too. The first Cause that is not a Stacktrace error, which
+includes the causes passed to PropagateAll, is shared with err. A cyclic chain
+is copied as a cycle. If err is not a Stacktrace error, or is a nil *Stacktrace,
+Clone returns it as is.
+*/
+func Clone(err error) error {
+	st, ok := err.(*Stacktrace)
+	if !ok || st == nil {
+		return err
+	}
+	return cloneStacktrace(st, make(map[*Stacktrace]*Stacktrace))
+}
+
+func cloneStacktrace(st *Stacktrace, clones map[*Stacktrace]*Stacktrace) *Stacktrace {
+	if clone, ok := clones[st]; ok {
+		return clone
+	}
+	clone := new(Stacktrace)
+	*clone = *st
+	clones[st] = clone
+
+	if st.lazy != nil {
+		clone.Message, clone.lazy = st.message(), nil
+	}
+	if st.Stack != nil {
+		clone.Stack = append([]Frame(nil), st.Stack...)
+	}
+	if st.args != nil {
+		clone.args = append([]interface{}(nil), st.args...)
+	}
+	if st.Retryable != nil {
+		retryable := *st.Retryable
+		clone.Retryable = &retryable
+	}
+	if st.Process != nil {
+		process := *st.Process
+		clone.Process = &process
+	}
+	if st.Build != nil {
+		build := *st.Build
+		clone.Build = &build
+	}
+	if st.preferred != nil {
+		preferred := *st.preferred
+		clone.preferred = &preferred
+	}
+	if st.Suppressed != nil {
+		clone.Suppressed = make([]error, len(st.Suppressed))
+		for i, suppressed := range st.Suppressed {
+			if s, ok := suppressed.(*Stacktrace); ok {
+				suppressed = cloneStacktrace(s, clones)
+			}
+			clone.Suppressed[i] = suppressed
+		}
+	}
+	if cause, ok := st.Cause.(*Stacktrace); ok {
+		clone.Cause = cloneStacktrace(cause, clones)
+	}
+	return clone
+}
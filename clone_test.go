@@ -0,0 +1,62 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestClone(t *testing.T) {
+	plain := errors.New("plain")
+	inner := stacktrace.Propagate(plain, "inner")
+	err := stacktrace.AddSuppressed(stacktrace.Propagate(inner, "outer"), stacktrace.NewError("suppressed"))
+
+	clone := stacktrace.Clone(err)
+	assert.Equal(t, err, clone)
+	assert.Equal(t, err.Error(), clone.Error())
+
+	st := clone.(*stacktrace.Stacktrace)
+	assert.False(t, st == err)
+	assert.False(t, st.Suppressed[0] == err.(*stacktrace.Stacktrace).Suppressed[0])
+	middle := st.Cause.(*stacktrace.Stacktrace)
+	clonedInner := middle.Cause.(*stacktrace.Stacktrace)
+	assert.False(t, clonedInner == inner)
+	assert.True(t, clonedInner.Cause == plain)
+
+	clonedInner.Message = "changed"
+	assert.Equal(t, "inner", inner.(*stacktrace.Stacktrace).Message)
+
+	cyclic := stacktrace.NewError("msg1").(*stacktrace.Stacktrace)
+	cyclic.Cause = stacktrace.Propagate(cyclic, "msg2")
+	clonedCycle := stacktrace.Clone(cyclic).(*stacktrace.Stacktrace)
+	assert.True(t, clonedCycle.Cause.(*stacktrace.Stacktrace).Cause == clonedCycle)
+
+	assert.Nil(t, stacktrace.Clone(nil))
+	marked := stacktrace.MarkRetryable(stacktrace.NewError("msg")).(*stacktrace.Stacktrace)
+	marked.Process = &stacktrace.ProcessInfo{PID: 1}
+	marked.Build = &stacktrace.BuildInfo{Version: "v1"}
+	clonedMarked := stacktrace.Clone(marked).(*stacktrace.Stacktrace)
+	*clonedMarked.Retryable = false
+	clonedMarked.Process.PID = 2
+	clonedMarked.Build.Version = "v2"
+	assert.True(t, stacktrace.IsRetryable(marked))
+	assert.Equal(t, 1, marked.Process.PID)
+	assert.Equal(t, "v1", marked.Build.Version)
+
+	var nilStacktrace *stacktrace.Stacktrace
+	assert.True(t, stacktrace.Clone(nilStacktrace) == error(nilStacktrace))
+	assert.True(t, stacktrace.Clone(plain) == plain)
+}
+
+func TestCloneLazy(t *testing.T) {
+	err := stacktrace.NewErrorLazy(func() string { return "secret" })
+	copied := stacktrace.Clone(err).(*stacktrace.Stacktrace)
+	assert.Equal(t, "secret", copied.Message)
+
+	copied.Message = "redacted"
+	assert.Equal(t, "redacted", stacktrace.GetMessage(copied).Error())
+	assert.Equal(t, "secret", stacktrace.GetMessage(err).Error())
+}
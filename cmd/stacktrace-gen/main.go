@@ -0,0 +1,205 @@
+/*
+Stacktrace-gen generates the RegisterCode calls for the error codes declared in
+a package, so that their names stay in sync with the code.
+
+It looks for constants of type stacktrace.ErrorCode, usually declared with iota:
+
+	//go:generate stacktrace-gen
+
+	const (
+		// The manifest File does not exist
+		EcodeManifestNotFound = stacktrace.ErrorCode(iota)
+		EcodeBadInput // The request is malformed
+	)
+
+and writes an init function registering each of them under its own name, using
+its comment as the description. With the names registered, ErrorCode's String
+method and the formatted output of errors show "EcodeManifestNotFound" rather
+than "0".
+
+Usage:
+
+	stacktrace-gen [-output file] [dir]
+
+The default output file is errorcode_names.go in dir, which defaults to the
+current directory.
+*/
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const importPath = "github.com/palantir/stacktrace"
+
+const defaultOutput = "errorcode_names.go"
+
+func main() {
+	output := flag.String("output", "", "output file name; default dir/"+defaultOutput)
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	if *output == "" {
+		*output = filepath.Join(dir, defaultOutput)
+	}
+	if err := run(dir, *output); err != nil {
+		fmt.Fprintln(os.Stderr, "stacktrace-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir, output string) error {
+	// ImportDir selects the files of the package for the current build
+	// context, without tests, sorted by name.
+	pkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range pkg.GoFiles {
+		if name == filepath.Base(output) {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+	}
+	src, err := generate(pkg.Name, files)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(output, src, 0644)
+}
+
+type errorCode struct {
+	name        string
+	description string
+}
+
+// generate returns the source of a file in package pkgName registering the
+// error codes declared in files.
+func generate(pkgName string, files []*ast.File) ([]byte, error) {
+	var codes []errorCode
+	for _, file := range files {
+		codes = append(codes, findCodes(file)...)
+	}
+	if len(codes) == 0 {
+		return nil, fmt.Errorf("no stacktrace.ErrorCode constants found in package %s", pkgName)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by stacktrace-gen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkgName)
+	qualifier := "stacktrace."
+	if pkgName == "stacktrace" {
+		qualifier = ""
+	} else {
+		fmt.Fprintf(&b, "import %q\n\n", importPath)
+	}
+	fmt.Fprintf(&b, "func init() {\n")
+	fmt.Fprintf(&b, "\tfor _, c := range []struct {\n")
+	fmt.Fprintf(&b, "\t\tcode        %sErrorCode\n", qualifier)
+	fmt.Fprintf(&b, "\t\tname        string\n")
+	fmt.Fprintf(&b, "\t\tdescription string\n")
+	fmt.Fprintf(&b, "\t}{\n")
+	for _, code := range codes {
+		fmt.Fprintf(&b, "\t\t{%s, %q, %q},\n", code.name, code.name, code.description)
+	}
+	fmt.Fprintf(&b, "\t} {\n")
+	fmt.Fprintf(&b, "\t\tif err := %sRegisterCode(c.code, c.name, c.description); err != nil {\n", qualifier)
+	fmt.Fprintf(&b, "\t\t\tpanic(err)\n")
+	fmt.Fprintf(&b, "\t\t}\n")
+	fmt.Fprintf(&b, "\t}\n")
+	fmt.Fprintf(&b, "}\n")
+	return format.Source(b.Bytes())
+}
+
+// findCodes returns the ErrorCode constants declared in file, in order.
+func findCodes(file *ast.File) []errorCode {
+	pkgIdent := importName(file)
+	var codes []errorCode
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		// A spec with neither a type nor values repeats the previous one
+		isCode := false
+		for _, spec := range gen.Specs {
+			vspec := spec.(*ast.ValueSpec)
+			if vspec.Type != nil || len(vspec.Values) > 0 {
+				isCode = isErrorCodeType(vspec.Type, pkgIdent) ||
+					(vspec.Type == nil && len(vspec.Values) == 1 && isErrorCodeConversion(vspec.Values[0], pkgIdent))
+			}
+			if !isCode {
+				continue
+			}
+			description := commentText(vspec.Doc)
+			if description == "" {
+				description = commentText(vspec.Comment)
+			}
+			for _, name := range vspec.Names {
+				if name.Name == "_" {
+					continue
+				}
+				codes = append(codes, errorCode{name: name.Name, description: description})
+			}
+		}
+	}
+	return codes
+}
+
+// importName returns the identifier file uses for the stacktrace package, or ""
+// if file is in package stacktrace itself.
+func importName(file *ast.File) string {
+	if file.Name.Name == "stacktrace" {
+		return ""
+	}
+	for _, imp := range file.Imports {
+		if path, _ := strconv.Unquote(imp.Path.Value); path == importPath {
+			if imp.Name != nil {
+				return imp.Name.Name
+			}
+			return "stacktrace"
+		}
+	}
+	return "-"
+}
+
+func isErrorCodeType(expr ast.Expr, pkgIdent string) bool {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return pkgIdent == "" && t.Name == "ErrorCode"
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		return ok && x.Name == pkgIdent && t.Sel.Name == "ErrorCode"
+	}
+	return false
+}
+
+func isErrorCodeConversion(expr ast.Expr, pkgIdent string) bool {
+	call, ok := expr.(*ast.CallExpr)
+	return ok && len(call.Args) == 1 && isErrorCodeType(call.Fun, pkgIdent)
+}
+
+// commentText returns the text of a comment group on a single line.
+func commentText(group *ast.CommentGroup) string {
+	return strings.Join(strings.Fields(group.Text()), " ")
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const source = `package shield
+
+import (
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// The manifest File does not exist
+	EcodeManifestNotFound = stacktrace.ErrorCode(iota)
+	EcodeBadInput // The request is malformed
+	_
+	EcodeTimeout
+)
+
+const EcodeTyped stacktrace.ErrorCode = 10
+
+const (
+	maxRetries = 3
+	other
+)
+`
+
+const expected = `// Code generated by stacktrace-gen; DO NOT EDIT.
+
+package shield
+
+import "github.com/palantir/stacktrace"
+
+func init() {
+	for _, c := range []struct {
+		code        stacktrace.ErrorCode
+		name        string
+		description string
+	}{
+		{EcodeManifestNotFound, "EcodeManifestNotFound", "The manifest File does not exist"},
+		{EcodeBadInput, "EcodeBadInput", "The request is malformed"},
+		{EcodeTimeout, "EcodeTimeout", ""},
+		{EcodeTyped, "EcodeTyped", ""},
+	} {
+		if err := stacktrace.RegisterCode(c.code, c.name, c.description); err != nil {
+			panic(err)
+		}
+	}
+}
+`
+
+func TestGenerate(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "codes.go", source, parser.ParseComments)
+	if !assert.NoError(t, err) {
+		return
+	}
+	src, err := generate("shield", []*ast.File{file})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(src))
+}
+
+func TestGenerateNoCodes(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "codes.go", "package shield\n\nconst x = 1\n", 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = generate("shield", []*ast.File{file})
+	assert.Error(t, err)
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("codes.go", source)
+	write("codes_test.go", "package shield_test\n")
+	write("ignored.go", "//go:build ignore\n\npackage main\n")
+	// a stale output is replaced rather than read
+	write(defaultOutput, "package shield\n\nconst EcodeStale = stacktrace.ErrorCode(99)\n")
+
+	output := filepath.Join(dir, defaultOutput)
+	if !assert.NoError(t, run(dir, output)) {
+		return
+	}
+	src, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(src))
+}
@@ -0,0 +1,85 @@
+package stacktrace
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	codeSpacesMu sync.Mutex
+	codeSpaces   = map[string]bool{}
+	// nextAllocated is the next candidate for CodeSpace.Code. Allocation counts
+	// down from below NoCode to stay clear of iota-based codes.
+	nextAllocated = NoCode - 1
+)
+
+/*
+CodeSpace allocates error codes that are unique across all the packages of a
+program, so that teams do not need to coordinate iota-based codes. Codes are
+named after the space, as in "billing.InvalidInvoice":
+
+	var billing = Stacktrace.NewCodeSpace("billing")
+
+	var (
+		EcodeInvalidInvoice = billing.Code("InvalidInvoice", "The invoice failed validation")
+		EcodeCardDeclined   = billing.Code("CardDeclined", "The payment was declined")
+	)
+*/
+type CodeSpace struct {
+	name string
+}
+
+// NewCodeSpace returns the CodeSpace with the given name. It panics if the name
+// is empty or already in use, since two spaces with one name would collide.
+func NewCodeSpace(name string) *CodeSpace {
+	if name == "" {
+		panic("stacktrace: code space name must not be empty")
+	}
+	codeSpacesMu.Lock()
+	defer codeSpacesMu.Unlock()
+	if codeSpaces[name] {
+		panic(fmt.Sprintf("stacktrace: code space %q already exists", name))
+	}
+	codeSpaces[name] = true
+	return &CodeSpace{name: name}
+}
+
+// Name returns the name of the CodeSpace.
+func (s *CodeSpace) Name() string {
+	return s.name
+}
+
+/*
+Code allocates a new error Code and registers it as "space.name" with the given
+description (see RegisterCode). Codes that are registered already or that fall in
+the range of a category registered with RegisterCategory are skipped. Code
+panics if the name is already in use or if no codes are left, so it is meant for
+package-level variable declarations.
+*/
+func (s *CodeSpace) Code(name, description string) ErrorCode {
+	qualified := s.name + "." + name
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := codesByName[qualified]; ok {
+		panic(fmt.Sprintf("stacktrace: code %q already exists", qualified))
+	}
+	for nextAllocated != NoCode {
+		if r, ok := categoryRange(nextAllocated); ok {
+			// Codes of a category are assigned by whoever registered it
+			nextAllocated = r.first - 1
+			continue
+		}
+		if _, ok := codeInfos[nextAllocated]; !ok {
+			break
+		}
+		nextAllocated--
+	}
+	if nextAllocated == NoCode {
+		panic(fmt.Sprintf("stacktrace: no codes left to allocate %q", qualified))
+	}
+	code := nextAllocated
+	nextAllocated--
+	codeInfos[code] = codeInfo{name: qualified, description: description}
+	codesByName[qualified] = code
+	return code
+}
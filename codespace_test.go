@@ -0,0 +1,44 @@
+package stacktrace_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestCodeSpace(t *testing.T) {
+	billing := stacktrace.NewCodeSpace("billing")
+	shipping := stacktrace.NewCodeSpace("shipping")
+	assert.Equal(t, "billing", billing.Name())
+	assert.Panics(t, func() { stacktrace.NewCodeSpace("billing") })
+	assert.Panics(t, func() { stacktrace.NewCodeSpace("") })
+
+	invalid := billing.Code("InvalidInvoice", "The invoice failed validation")
+	declined := billing.Code("CardDeclined", "")
+	lost := shipping.Code("InvalidInvoice", "")
+	assert.Panics(t, func() { billing.Code("InvalidInvoice", "") })
+
+	assert.NotEqual(t, invalid, declined)
+	assert.NotEqual(t, invalid, lost)
+	assert.NotEqual(t, stacktrace.NoCode, invalid)
+	assert.Equal(t, "billing.InvalidInvoice", stacktrace.CodeName(invalid))
+	assert.Equal(t, "The invoice failed validation", stacktrace.CodeDescription(invalid))
+	assert.Equal(t, "shipping.InvalidInvoice", stacktrace.CodeName(lost))
+	assert.Error(t, stacktrace.RegisterCode(invalid, "Other", ""))
+
+	err := stacktrace.NewErrorWithCode(declined, "declined")
+	assert.Equal(t, "declined [code=billing.CardDeclined]", fmt.Sprintf("% #s", err))
+}
+
+func TestCodeSpaceSkipsCategories(t *testing.T) {
+	space := stacktrace.NewCodeSpace("reserved")
+	first := space.Code("First", "")
+	assert.NoError(t, stacktrace.RegisterCategory("reserved", first-3, first-1))
+
+	second := space.Code("Second", "")
+	assert.Equal(t, first-4, second)
+	assert.Equal(t, "", stacktrace.CodeCategory(second))
+}
@@ -0,0 +1,47 @@
+package stacktrace
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+	ansiRed   = "\x1b[31m"
+	ansiCyan  = "\x1b[36m"
+)
+
+/*
+WriteColor writes err to w followed by a newline. If err is a Stacktrace and w
+is a terminal, the full Stacktrace is written using FormatColor. Otherwise err
+is written in plain text according to DefaultFormat, so output redirected to a
+File or a pipe is not cluttered with escape sequences.
+
+	if err := run(); err != nil {
+		Stacktrace.WriteColor(os.Stderr, err)
+		os.Exit(1)
+	}
+
+Setting the NO_COLOR environment variable disables colors even on a terminal.
+*/
+func WriteColor(w io.Writer, err error) error {
+	text := fmt.Sprint(err)
+	if st, ok := err.(*Stacktrace); ok && isColorTerminal(w) {
+		text = formatColor(st)
+	}
+	_, werr := io.WriteString(w, text+"\n")
+	return werr
+}
+
+// isColorTerminal reports whether w is a terminal that colors should be
+// written to.
+func isColorTerminal(w io.Writer) bool {
+	if _, noColor := os.LookupEnv("NO_COLOR"); noColor {
+		return false
+	}
+	f, ok := w.(*os.File)
+	return ok && isTerminal(f)
+}
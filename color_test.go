@@ -0,0 +1,42 @@
+package stacktrace_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestFormatColor(t *testing.T) {
+	defer func(format stacktrace.Format) { stacktrace.DefaultFormat = format }(stacktrace.DefaultFormat)
+	stacktrace.DefaultFormat = stacktrace.FormatColor
+
+	err := stacktrace.Propagate(errors.New("plain"), "decorated")
+	expected := strings.Join([]string{
+		"\x1b[1mdecorated\x1b[0m",
+		"\x1b[36m --- at github.com/palantir/Stacktrace/color_test.go:# (TestFormatColor) ---\x1b[0m",
+		"\x1b[31mCaused by: \x1b[0m\x1b[1mplain\x1b[0m",
+	}, "\n")
+	assert.Equal(t, expected, normalizeLines(err.Error()))
+	assert.Equal(t, "decorated: plain", fmt.Sprintf("%#s", err))
+}
+
+func TestWriteColor(t *testing.T) {
+	defer func(format stacktrace.Format) { stacktrace.DefaultFormat = format }(stacktrace.DefaultFormat)
+	stacktrace.DefaultFormat = stacktrace.FormatBrief
+
+	// not a terminal, so no colors
+	var buf bytes.Buffer
+	err := stacktrace.Propagate(errors.New("plain"), "decorated")
+	assert.NoError(t, stacktrace.WriteColor(&buf, err))
+	assert.Equal(t, "decorated: plain\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, stacktrace.WriteColor(&buf, errors.New("plain")))
+	assert.Equal(t, "plain\n", buf.String())
+}
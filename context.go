@@ -0,0 +1,112 @@
+package stacktrace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+/*
+EcodeDeadline and EcodeCanceled are attached by PropagateCtx and NewErrorCtx to
+errors created after their context's deadline passed or after it was canceled,
+so that timeout storms can be told apart from real failures. They are NoCode,
+so that no Code is attached, until UseStandardCodes allocates them, or they can
+be set to an application's own codes:
+
+	Stacktrace.EcodeDeadline = EcodeTimeout
+*/
+var (
+	EcodeDeadline = NoCode
+	EcodeCanceled = NoCode
+)
+
+/*
+TraceIDsFromContext extracts the IDs of the active distributed trace and span
+from a context, for PropagateCtx and NewErrorCtx. It is nil by default, so no
+IDs are attached. To use OpenTelemetry:
+
+	Stacktrace.TraceIDsFromContext = otelstacktrace.TraceIDs
+*/
+var TraceIDsFromContext func(ctx context.Context) (traceID, spanID string)
+
+/*
+PropagateCtx is like Propagate, but also attaches the trace and span IDs of ctx
+as returned by TraceIDsFromContext, linking the logged error to its distributed
+trace:
+
+	if err := db.QueryRowContext(ctx, q).Scan(&row); err != nil {
+		return Stacktrace.PropagateCtx(ctx, err, "Failed to load row %d", id)
+	}
+
+If ctx has no trace, the IDs are inherited from the Cause. The IDs are included
+in the structured outputs: Log, the logfmt format and MarshalYAML.
+
+If ctx is already done, PropagateCtx notes the deadline in the Message:
+
+	Failed to load row 7 (context deadline exceeded 120ms ago)
+
+and, if the error has no Code, attaches EcodeDeadline or EcodeCanceled unless
+it is NoCode. The note is formatted into the Message as its last argument, so
+the format of the Message, which Fingerprint groups errors by, stays the same.
+*/
+func PropagateCtx(ctx context.Context, cause error, msg string, vals ...interface{}) error {
+	if cause == nil {
+		// Allow calling PropagateCtx without checking whether there is error
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	code, msg, vals := contextCode(ctx, GetCode(cause), msg, vals)
+	return createWith(cause, code, func(st *Stacktrace) { attachTraceIDs(ctx, st) }, msg, vals...)
+}
+
+// NewErrorCtx is like NewError, but also attaches the trace and span IDs and
+// the deadline state of ctx like PropagateCtx.
+func NewErrorCtx(ctx context.Context, msg string, vals ...interface{}) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	code, msg, vals := contextCode(ctx, NoCode, msg, vals)
+	return createWith(nil, code, func(st *Stacktrace) { attachTraceIDs(ctx, st) }, msg, vals...)
+}
+
+// contextCode returns the Code for an error created with ctx, which is NoCode
+// if it inherits one, and the format and arguments of its Message with the
+// note on the deadline state of ctx, if any.
+func contextCode(ctx context.Context, inherited ErrorCode, msg string, vals []interface{}) (ErrorCode, string, []interface{}) {
+	ctxErr := ctx.Err()
+	if ctxErr == nil {
+		return NoCode, msg, vals
+	}
+	code, note := EcodeCanceled, "context canceled"
+	if errors.Is(ctxErr, context.DeadlineExceeded) {
+		code, note = EcodeDeadline, "context deadline exceeded"
+	}
+	if inherited != NoCode {
+		code = NoCode
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline).Round(time.Millisecond); remaining > 0 {
+			note += fmt.Sprintf(" with %v left", remaining)
+		} else {
+			note += fmt.Sprintf(" %v ago", -remaining)
+		}
+	}
+	if msg == "" {
+		return code, "%s", []interface{}{note}
+	}
+	return code, msg + " (%s)", append(append([]interface{}(nil), vals...), note)
+}
+
+func attachTraceIDs(ctx context.Context, st *Stacktrace) {
+	if TraceIDsFromContext != nil {
+		st.TraceID, st.SpanID = TraceIDsFromContext(ctx)
+	}
+	if st.TraceID == "" {
+		if cause, ok := st.Cause.(*Stacktrace); ok {
+			st.TraceID, st.SpanID = cause.TraceID, cause.SpanID
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package stacktrace_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+type traceKey struct{}
+
+func TestPropagateCtxDone(t *testing.T) {
+	defer func(capture bool) { stacktrace.CaptureArgs = capture }(stacktrace.CaptureArgs)
+	stacktrace.CaptureArgs = true
+	stacktrace.UseStandardCodes()
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	err := stacktrace.PropagateCtx(ctx, errors.New("plain"), "Failed to load").(*stacktrace.Stacktrace)
+	assert.Equal(t, stacktrace.EcodeDeadline, err.Code)
+	assert.Regexp(t, `^Failed to load \(context deadline exceeded [\d.ms]+ ago\)$`, err.Message)
+	assert.Equal(t, "stacktrace.Deadline", stacktrace.CodeName(err.Code))
+	format, args := stacktrace.Template(err)
+	assert.Equal(t, "Failed to load (%s)", format)
+	assert.Regexp(t, `^context deadline exceeded [\d.ms]+ ago$`, args[0])
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Hour)
+	cancel()
+	err = stacktrace.NewErrorCtx(ctx, "").(*stacktrace.Stacktrace)
+	assert.Equal(t, stacktrace.EcodeCanceled, err.Code)
+	assert.Regexp(t, `^context canceled with [\d.hms]+ left$`, err.Message)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	coded := stacktrace.NewErrorWithCode(EcodeNoSuchPseudo, "coded")
+	err = stacktrace.PropagateCtx(ctx, coded, "outer").(*stacktrace.Stacktrace)
+	assert.Equal(t, EcodeNoSuchPseudo, err.Code)
+	assert.Equal(t, "outer (context canceled)", err.Message)
+	err = stacktrace.PropagateCtx(ctx, errors.New("plain"), "outer").(*stacktrace.Stacktrace)
+	assert.Equal(t, "outer (context canceled)", err.Message)
+
+	defer func(code stacktrace.ErrorCode) { stacktrace.EcodeCanceled = code }(stacktrace.EcodeCanceled)
+	stacktrace.EcodeCanceled = stacktrace.NoCode
+	err = stacktrace.PropagateCtx(ctx, errors.New("plain"), "outer").(*stacktrace.Stacktrace)
+	assert.Equal(t, stacktrace.NoCode, err.Code)
+	assert.Equal(t, "outer (context canceled)", err.Message)
+}
+
+func TestPropagateCtx(t *testing.T) {
+	defer func(f func(context.Context) (string, string)) { stacktrace.TraceIDsFromContext = f }(stacktrace.TraceIDsFromContext)
+	stacktrace.TraceIDsFromContext = func(ctx context.Context) (string, string) {
+		ids, _ := ctx.Value(traceKey{}).([2]string)
+		return ids[0], ids[1]
+	}
+
+	ctx := context.WithValue(context.Background(), traceKey{}, [2]string{"trace1", "span1"})
+	inner := stacktrace.NewErrorCtx(ctx, "inner").(*stacktrace.Stacktrace)
+	assert.Equal(t, "trace1", inner.TraceID)
+	assert.Equal(t, "span1", inner.SpanID)
+	assert.Equal(t, "TestPropagateCtx", inner.Function)
+
+	outer := stacktrace.PropagateCtx(context.Background(), inner, "outer").(*stacktrace.Stacktrace)
+	assert.Equal(t, "trace1", outer.TraceID)
+	assert.Equal(t, "span1", outer.SpanID)
+	assert.Equal(t, "TestPropagateCtx", outer.Function)
+
+	ctx = context.WithValue(context.Background(), traceKey{}, [2]string{"trace2", "span2"})
+	outer = stacktrace.PropagateCtx(ctx, errors.New("plain"), "outer").(*stacktrace.Stacktrace)
+	assert.Equal(t, "trace2", outer.TraceID)
+	assert.Equal(t, "span2", outer.SpanID)
+
+	assert.Nil(t, stacktrace.PropagateCtx(ctx, nil, "outer"))
+
+	stacktrace.TraceIDsFromContext = nil
+	assert.Equal(t, "", stacktrace.NewErrorCtx(ctx, "msg").(*stacktrace.Stacktrace).TraceID)
+}
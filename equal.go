@@ -0,0 +1,126 @@
+package stacktrace
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+EqualIgnoringFrames reports whether a and b have the same messages, codes and
+cause structure, ignoring the File, Line, Function and Stack of each level,
+which change with every edit. It is meant for tests of propagated errors:
+
+	expected := Stacktrace.PropagateWithCode(io.EOF, EcodeBadInput, "Failed to read manifest")
+	if !Stacktrace.EqualIgnoringFrames(expected, err) {
+		t.Errorf("unexpected error:\n%s", Stacktrace.Diff(expected, err))
+	}
+
+Errors that are not Stacktrace errors are compared by type and Error() string.
+*/
+func EqualIgnoringFrames(a, b error) bool {
+	return Diff(a, b) == ""
+}
+
+/*
+Diff describes the differences between a and b that EqualIgnoringFrames looks
+at, one per Line, or returns "" if there are none:
+
+	err.Cause.Message: "Failed to read manifest" != "Failed to parse manifest"
+	err.Cause.Code: EcodeBadInput != NoCode
+*/
+func Diff(a, b error) string {
+	d := differ{seen: make(map[[2]*Stacktrace]bool)}
+	d.diff("err", a, b)
+	return strings.Join(d.lines, "\n")
+}
+
+type differ struct {
+	lines []string
+	seen  map[[2]*Stacktrace]bool
+}
+
+func (d *differ) add(path, format string, vals ...interface{}) {
+	d.lines = append(d.lines, path+": "+fmt.Sprintf(format, vals...))
+}
+
+func (d *differ) diff(path string, a, b error) {
+	a, b = nilTrace(a), nilTrace(b)
+	if a == nil || b == nil {
+		if a != nil || b != nil {
+			d.add(path, "%s != %s", describe(a), describe(b))
+		}
+		return
+	}
+
+	stA, okA := a.(*Stacktrace)
+	stB, okB := b.(*Stacktrace)
+	if okA && okB {
+		d.diffStacktraces(path, stA, stB)
+		return
+	}
+
+	branchesA, okA := branchesOf(a)
+	branchesB, okB := branchesOf(b)
+	if okA && okB {
+		if len(branchesA) != len(branchesB) {
+			d.add(path, "%d causes != %d causes", len(branchesA), len(branchesB))
+			return
+		}
+		for i := range branchesA {
+			d.diff(fmt.Sprintf("%s[%d]", path, i), branchesA[i], branchesB[i])
+		}
+		return
+	}
+
+	if fmt.Sprintf("%T", a) != fmt.Sprintf("%T", b) || a.Error() != b.Error() {
+		d.add(path, "%s != %s", describe(a), describe(b))
+	}
+}
+
+func (d *differ) diffStacktraces(path string, a, b *Stacktrace) {
+	if d.seen[[2]*Stacktrace{a, b}] {
+		return
+	}
+	d.seen[[2]*Stacktrace{a, b}] = true
+
+	if a.message() != b.message() {
+		d.add(path+".Message", "%q != %q", a.message(), b.message())
+	}
+	if a.Code != b.Code {
+		d.add(path+".Code", "%s != %s", CodeName(a.Code), CodeName(b.Code))
+	}
+	if a.StringCode != b.StringCode {
+		d.add(path+".StringCode", "%q != %q", a.StringCode, b.StringCode)
+	}
+	if len(a.Suppressed) != len(b.Suppressed) {
+		d.add(path+".Suppressed", "%d errors != %d errors", len(a.Suppressed), len(b.Suppressed))
+	} else {
+		for i := range a.Suppressed {
+			d.diff(fmt.Sprintf("%s.Suppressed[%d]", path, i), a.Suppressed[i], b.Suppressed[i])
+		}
+	}
+	d.diff(path+".Cause", a.Cause, b.Cause)
+}
+
+// describe returns the type and brief message of err for Diff.
+func describe(err error) string {
+	switch err := err.(type) {
+	case nil:
+		return "<nil>"
+	case *Stacktrace:
+		if err == nil {
+			return "<nil>"
+		}
+		return fmt.Sprintf("%T(%q)", err, err.message())
+	}
+	return fmt.Sprintf("%T(%q)", err, err.Error())
+}
+
+// nilTrace returns nil if err is a nil *Stacktrace, which compares equal to a
+// nil error, and err otherwise.
+func nilTrace(err error) error {
+	if st, ok := err.(*Stacktrace); ok && st == nil {
+		return nil
+	}
+	return err
+}
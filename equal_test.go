@@ -0,0 +1,79 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestEqualIgnoringFrames(t *testing.T) {
+	expected := stacktrace.PropagateWithCode(io.EOF, EcodeInvalidVillain, "Failed to read")
+	actual := func() error {
+		return stacktrace.PropagateWithCode(io.EOF, EcodeInvalidVillain, "Failed to read")
+	}()
+	assert.True(t, stacktrace.EqualIgnoringFrames(expected, actual))
+	assert.Equal(t, "", stacktrace.Diff(expected, actual))
+
+	for _, test := range []struct {
+		a, b error
+		diff string
+	}{
+		{
+			a:    nil,
+			b:    nil,
+			diff: "",
+		},
+		{
+			a:    stacktrace.NewError("msg"),
+			b:    nil,
+			diff: `err: *stacktrace.Stacktrace("msg") != <nil>`,
+		},
+		{
+			a:    stacktrace.NewError("msg"),
+			b:    (*stacktrace.Stacktrace)(nil),
+			diff: `err: *stacktrace.Stacktrace("msg") != <nil>`,
+		},
+		{
+			a:    (*stacktrace.Stacktrace)(nil),
+			b:    nil,
+			diff: "",
+		},
+		{
+			a:    &stacktrace.Stacktrace{Message: "msg", Cause: (*stacktrace.Stacktrace)(nil)},
+			b:    &stacktrace.Stacktrace{Message: "msg", Cause: io.EOF},
+			diff: `err.Cause: <nil> != *errors.errorString("EOF")`,
+		},
+		{
+			a:    stacktrace.Propagate(stacktrace.NewErrorWithCode(EcodeInvalidVillain, "inner"), "outer"),
+			b:    stacktrace.Propagate(stacktrace.NewError("other"), "outer"),
+			diff: "err.Code: 0 != NoCode\nerr.Cause.Message: \"inner\" != \"other\"\nerr.Cause.Code: 0 != NoCode",
+		},
+		{
+			a:    stacktrace.Propagate(io.EOF, ""),
+			b:    stacktrace.Propagate(errors.New("EOF"), ""),
+			diff: "",
+		},
+		{
+			a:    stacktrace.Propagate(io.EOF, ""),
+			b:    stacktrace.Propagate(io.ErrUnexpectedEOF, ""),
+			diff: `err.Cause: *errors.errorString("EOF") != *errors.errorString("unexpected EOF")`,
+		},
+		{
+			a:    stacktrace.PropagateAll([]error{io.EOF, stacktrace.NewError("a")}, ""),
+			b:    stacktrace.PropagateAll([]error{io.EOF, stacktrace.NewError("b")}, ""),
+			diff: `err.Cause[1].Message: "a" != "b"`,
+		},
+		{
+			a:    stacktrace.AddSuppressed(io.EOF, io.EOF),
+			b:    stacktrace.Propagate(io.EOF, ""),
+			diff: "err.Suppressed: 1 errors != 0 errors",
+		},
+	} {
+		assert.Equal(t, test.diff, stacktrace.Diff(test.a, test.b))
+		assert.Equal(t, test.diff == "", stacktrace.EqualIgnoringFrames(test.a, test.b))
+	}
+}
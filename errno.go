@@ -0,0 +1,63 @@
+package stacktrace
+
+import (
+	"errors"
+	"io/fs"
+	"syscall"
+)
+
+/*
+Error codes attached by PropagateSyscall to errors from the operating system.
+They are NoCode, which leaves the corresponding errors uncoded, until
+UseStandardCodes allocates them, or they can be set to an application's own
+codes:
+
+	Stacktrace.EcodeNotFound = EcodeMissingFile
+*/
+var (
+	EcodePermission = NoCode
+	EcodeNotFound   = NoCode
+	EcodeNoSpace    = NoCode
+)
+
+/*
+PropagateSyscall is like Propagate, but attaches an error Code to errors from the
+operating system, such as the *os.PathError of a failed os.Open, easing triage
+of file system and network errors:
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Stacktrace.PropagateSyscall(err, "Failed to open config")
+	}
+
+See SyscallCode for the errors that are recognized. Other errors are propagated
+as by Propagate.
+*/
+func PropagateSyscall(cause error, msg string, vals ...interface{}) error {
+	if cause == nil {
+		// Allow calling PropagateSyscall without checking whether there is error
+		return nil
+	}
+	return create(cause, SyscallCode(cause), msg, vals...)
+}
+
+/*
+SyscallCode returns the error Code for err, looking through wrappers such as
+*os.PathError and *net.OpError down to the syscall.Errno: EcodePermission for
+EACCES and EPERM, EcodeNotFound for ENOENT, and EcodeNoSpace for ENOSPC. It
+returns NoCode for other errors.
+*/
+func SyscallCode(err error) ErrorCode {
+	switch {
+	case err == nil:
+		return NoCode
+	case errors.Is(err, fs.ErrPermission):
+		return EcodePermission
+	case errors.Is(err, fs.ErrNotExist):
+		return EcodeNotFound
+	case errors.Is(err, syscall.ENOSPC):
+		return EcodeNoSpace
+	default:
+		return NoCode
+	}
+}
@@ -0,0 +1,46 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestSyscallCode(t *testing.T) {
+	stacktrace.UseStandardCodes()
+	for _, testcase := range []struct {
+		err      error
+		expected stacktrace.ErrorCode
+	}{
+		{err: nil, expected: stacktrace.NoCode},
+		{err: errors.New("plain"), expected: stacktrace.NoCode},
+		{err: syscall.EACCES, expected: stacktrace.EcodePermission},
+		{err: &os.PathError{Op: "open", Path: "file", Err: syscall.EPERM}, expected: stacktrace.EcodePermission},
+		{err: fmt.Errorf("wrapped: %w", &os.PathError{Op: "open", Path: "file", Err: syscall.ENOENT}), expected: stacktrace.EcodeNotFound},
+		{err: &os.SyscallError{Syscall: "write", Err: syscall.ENOSPC}, expected: stacktrace.EcodeNoSpace},
+		{err: syscall.EINVAL, expected: stacktrace.NoCode},
+	} {
+		assert.Equal(t, testcase.expected, stacktrace.SyscallCode(testcase.err), "error: %v", testcase.err)
+	}
+}
+
+func TestPropagateSyscall(t *testing.T) {
+	stacktrace.UseStandardCodes()
+	assert.Nil(t, stacktrace.PropagateSyscall(nil, "msg"))
+
+	_, err := os.Open(filepath.Join(t.TempDir(), "missing"))
+	err = stacktrace.PropagateSyscall(err, "Failed to open %s", "config")
+	assert.Equal(t, stacktrace.EcodeNotFound, stacktrace.GetCode(err))
+	assert.Equal(t, "TestPropagateSyscall", err.(*stacktrace.Stacktrace).Function)
+	assert.Equal(t, "stacktrace.NotFound", stacktrace.CodeName(stacktrace.GetCode(err)))
+
+	err = stacktrace.PropagateSyscall(stacktrace.NewErrorWithCode(EcodeNotFastEnough, "inner"), "outer")
+	assert.Equal(t, EcodeNotFastEnough, stacktrace.GetCode(err))
+}
@@ -0,0 +1,49 @@
+package stacktrace
+
+/*
+ErrorList accumulates errors, typically from the iterations of a loop that
+should not stop at the first failure. The zero value is an empty list ready to
+use:
+
+	var errs Stacktrace.ErrorList
+	for _, record := range records {
+		errs.Add(validate(record))
+	}
+	return Stacktrace.Propagate(errs.Err(), "Found invalid records")
+
+An ErrorList is not safe for concurrent use.
+*/
+type ErrorList struct {
+	errs []error
+}
+
+// Add appends err to the list. Nil errors are ignored, so the result of a
+// fallible call can be added without checking it first.
+func (l *ErrorList) Add(err error) {
+	if err != nil {
+		l.errs = append(l.errs, err)
+	}
+}
+
+// Len returns the number of errors added to the list.
+func (l *ErrorList) Len() int {
+	return len(l.errs)
+}
+
+// Errors returns the errors added to the list, in the order they were added.
+func (l *ErrorList) Errors() []error {
+	return append([]error(nil), l.errs...)
+}
+
+/*
+Err returns an error wrapping all the errors in the list, with Line number
+information for the call to Err. The full format lists them as numbered "Caused
+by (i of n):" sections. If the list is empty, Err returns nil.
+*/
+func (l *ErrorList) Err() error {
+	cause := joinCauses(l.errs)
+	if cause == nil {
+		return nil
+	}
+	return create(cause, NoCode, "")
+}
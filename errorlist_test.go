@@ -0,0 +1,41 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestErrorList(t *testing.T) {
+	var errs stacktrace.ErrorList
+	assert.Nil(t, errs.Err())
+
+	errs.Add(nil)
+	assert.Equal(t, 0, errs.Len())
+	assert.Nil(t, errs.Err())
+
+	first := errors.New("record 1 is invalid")
+	errs.Add(first)
+	assert.Equal(t, "record 1 is invalid", fmt.Sprintf("%#s", errs.Err()))
+	assert.Equal(t, first, errs.Err().(*stacktrace.Stacktrace).Cause)
+
+	errs.Add(stacktrace.NewError("record 2 is invalid"))
+	assert.Equal(t, 2, errs.Len())
+	assert.Len(t, errs.Errors(), 2)
+
+	err := stacktrace.Propagate(errs.Err(), "Found invalid records")
+	assert.True(t, errors.Is(err, first))
+	assert.Equal(t, strings.Join([]string{
+		"Found invalid records",
+		" --- at github.com/palantir/Stacktrace/errorlist_test.go:# (TestErrorList) ---",
+		" --- at github.com/palantir/Stacktrace/errorlist_test.go:# (TestErrorList) ---",
+		"Caused by (1 of 2): record 1 is invalid",
+		"Caused by (2 of 2): record 2 is invalid",
+		"     --- at github.com/palantir/Stacktrace/errorlist_test.go:# (TestErrorList) ---",
+	}, "\n"), normalizeLines(fmt.Sprintf("%+s", err)))
+}
@@ -0,0 +1,167 @@
+package stacktrace
+
+import (
+	"fmt"
+	"strconv"
+)
+
+/*
+TrackerError describes an error the way error trackers such as Rollbar,
+Bugsnag, Sentry and Google Cloud Error Reporting model errors. Package
+reportstacktrace builds the payloads of those trackers from it, and it can be
+used to write an exporter for another one.
+*/
+type TrackerError struct {
+	// Class names the kind of error, for grouping: the string Code of the
+	// error, else the name of its Code, else its type.
+	Class string
+	// Exceptions are the sections of the chain, outermost first.
+	Exceptions []Exception
+	// Stack is the stack of the innermost Stacktrace in the chain, most
+	// recent call first. It is empty for errors that are not Stacktraces.
+	Stack []Frame
+	// Metadata holds the fields attached to the error, with the same names as
+	// the fields of LogAt.
+	Metadata map[string]interface{}
+}
+
+/*
+Exception is a section of a chain: a level with a Message, with the levels
+without one that it wraps, or the Cause that is not a Stacktrace at the end of
+the chain.
+*/
+type Exception struct {
+	Class   string
+	Message string
+	// Frames are the locations of the levels of the section, followed by the
+	// Stack of the innermost of them if CaptureStack was enabled, most recent
+	// call first.
+	Frames []Frame
+}
+
+/*
+ToTrackerError describes err for error trackers. It returns nil if err is nil.
+*/
+func ToTrackerError(err error) *TrackerError {
+	if err == nil {
+		return nil
+	}
+	st, ok := err.(*Stacktrace)
+	if !ok || st == nil {
+		class := fmt.Sprintf("%T", err)
+		return &TrackerError{Class: class, Exceptions: []Exception{{Class: class, Message: err.Error()}}}
+	}
+	return &TrackerError{
+		Class:      exceptionClass(st),
+		Exceptions: exceptionsOf(st),
+		Stack:      stackFrames(st),
+		Metadata:   errorMetadata(st),
+	}
+}
+
+// exceptionsOf splits the chain of st into Exceptions, outermost first.
+func exceptionsOf(st *Stacktrace) []Exception {
+	levels, truncated := chain(st)
+	var exceptions []Exception
+	for start := 0; start < len(levels); {
+		end := start + 1
+		for end < len(levels) && levels[end].message() == "" {
+			end++
+		}
+		e := Exception{Class: exceptionClass(levels[start]), Message: levels[start].message()}
+		for i := end - 1; i >= start; i-- {
+			if levels[i].File != "" {
+				e.Frames = append(e.Frames, Frame{File: levels[i].File, Function: levels[i].Function, Line: levels[i].Line})
+			}
+		}
+		if last := levels[end-1]; len(last.Stack) > 0 {
+			e.Frames = append(e.Frames, last.Stack...)
+		}
+		exceptions = append(exceptions, e)
+		start = end
+	}
+
+	if last := levels[len(levels)-1]; !truncated && last.Cause != nil {
+		exceptions = append(exceptions, Exception{Class: fmt.Sprintf("%T", last.Cause), Message: last.Cause.Error()})
+	}
+	return exceptions
+}
+
+// stackFrames returns the stack of the innermost Stacktrace in the chain of st,
+// most recent call first: its location followed by its Stack, or by the
+// locations of the levels wrapping it if it has none.
+func stackFrames(st *Stacktrace) []Frame {
+	levels, _ := chain(st)
+	var frames []Frame
+	innermost := levels[len(levels)-1]
+	for i := len(levels) - 1; i >= 0; i-- {
+		curr := levels[i]
+		if curr.File == "" {
+			continue
+		}
+		frame := Frame{File: curr.File, Function: curr.Function, Line: curr.Line}
+		if n := len(frames); n > 0 && frames[n-1] == frame {
+			continue
+		}
+		frames = append(frames, frame)
+		if curr == innermost && len(curr.Stack) > 0 {
+			frames = append(frames, curr.Stack...)
+			break
+		}
+	}
+	return frames
+}
+
+// exceptionClass names the kind of error st is, for grouping: its string Code,
+// else the name of its Code, else its type.
+func exceptionClass(st *Stacktrace) string {
+	if st.StringCode != "" {
+		return st.StringCode
+	}
+	if st.Code != NoCode {
+		return CodeName(st.Code)
+	}
+	return "*stacktrace.Stacktrace"
+}
+
+// errorMetadata returns the fields attached to err that error trackers show as
+// custom metadata, using the same names as LogAt.
+func errorMetadata(err error) map[string]interface{} {
+	st, ok := err.(*Stacktrace)
+	if !ok || st == nil {
+		return nil
+	}
+	fields := make(map[string]interface{})
+	if st.Code != NoCode {
+		fields["code"] = int(st.Code)
+		if name := CodeName(st.Code); name != strconv.Itoa(int(st.Code)) {
+			fields["code_name"] = name
+		}
+	}
+	if st.StringCode != "" {
+		fields["string_code"] = st.StringCode
+	}
+	if st.Severity != SeverityUnset {
+		fields["severity"] = st.Severity.String()
+	}
+	if st.ID != "" {
+		fields["id"] = st.ID
+	}
+	if st.TraceID != "" {
+		fields["trace_id"] = st.TraceID
+		fields["span_id"] = st.SpanID
+	}
+	if t := Timestamp(st); !t.IsZero() {
+		fields["error_time"] = t
+	}
+	if st.Retryable != nil {
+		fields["retryable"] = *st.Retryable
+	}
+	if !st.RetryAt.IsZero() {
+		fields["retry_at"] = st.RetryAt
+	}
+	if st.Build != nil {
+		fields["build"] = st.Build.String()
+	}
+	return fields
+}
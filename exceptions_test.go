@@ -0,0 +1,41 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestToTrackerError(t *testing.T) {
+	err := stacktrace.PropagateWithStringCode(errors.New("timed out"), "PULSE_FAILED", "Failed to pulse")
+	err = PublicObj{}.DoPublic(err)
+	err = stacktrace.PropagateWithSeverity(err, stacktrace.SeverityWarning, "Failed to register")
+
+	tracker := stacktrace.ToTrackerError(err)
+	require.NotNil(t, tracker)
+	assert.Equal(t, "PULSE_FAILED", tracker.Class)
+	assert.Equal(t, map[string]interface{}{"string_code": "PULSE_FAILED", "severity": "warning"}, tracker.Metadata)
+
+	require.Len(t, tracker.Exceptions, 3)
+	assert.Equal(t, "Failed to register", tracker.Exceptions[0].Message)
+	require.Len(t, tracker.Exceptions[0].Frames, 2)
+	assert.Equal(t, "PublicObj.DoPublic", tracker.Exceptions[0].Frames[0].Function)
+	assert.Equal(t, "TestToTrackerError", tracker.Exceptions[0].Frames[1].Function)
+	assert.Equal(t, stacktrace.Exception{Class: "*errors.errorString", Message: "timed out"}, tracker.Exceptions[2])
+
+	// the innermost location first, then the locations wrapping it
+	require.Len(t, tracker.Stack, 3)
+	assert.Equal(t, "TestToTrackerError", tracker.Stack[0].Function)
+	assert.Equal(t, "PublicObj.DoPublic", tracker.Stack[1].Function)
+	assert.Equal(t, "TestToTrackerError", tracker.Stack[2].Function)
+
+	assert.Equal(t, &stacktrace.TrackerError{
+		Class:      "*errors.errorString",
+		Exceptions: []stacktrace.Exception{{Class: "*errors.errorString", Message: "plain"}},
+	}, stacktrace.ToTrackerError(errors.New("plain")))
+	assert.Nil(t, stacktrace.ToTrackerError(nil))
+}
@@ -0,0 +1,76 @@
+package stacktrace
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+)
+
+/*
+ExecStderrTailLength is the maximum number of bytes from the end of the standard
+error of a command that WrapExec includes in the Message.
+*/
+var ExecStderrTailLength = 1024
+
+/*
+WrapExec returns an error for a failed run of cmd, including the command line
+and the tail of what the command wrote to its standard error:
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "fetch", remote)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return Stacktrace.WrapExec(cmd, err, stderr.Bytes())
+	}
+
+The Message looks like
+
+	Command /usr/bin/git fetch origin failed: fatal: 'origin' does not appear to be a git repository
+
+with err as the Cause. If stderr is nil, the standard error captured by
+cmd.Output in the *exec.ExitError is used. If the command exited with a status
+between 1 and 255, ExitCode returns that status for the error unless it has a
+Code. WrapExec returns nil if err is nil.
+*/
+func WrapExec(cmd *exec.Cmd, err error, stderr []byte) error {
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *exec.ExitError
+	isExit := errors.As(err, &exitErr)
+	if stderr == nil && isExit {
+		stderr = exitErr.Stderr
+	}
+	msg := "Command failed"
+	if cmd != nil {
+		msg = "Command " + cmd.String() + " failed"
+	}
+	if tail := stderrTail(stderr); tail != "" {
+		msg += ": " + tail
+	}
+
+	return createWith(err, NoCode, func(st *Stacktrace) {
+		literalMessage(msg)(st)
+		if isExit && exitErr.ExitCode() >= 1 && exitErr.ExitCode() <= maxExitCode {
+			st.exitStatus = exitErr.ExitCode()
+		}
+	}, "")
+}
+
+// stderrTail returns the last ExecStderrTailLength bytes of stderr, starting
+// at a line boundary if possible, without surrounding whitespace.
+func stderrTail(stderr []byte) string {
+	stderr = bytes.TrimSpace(stderr)
+	if ExecStderrTailLength <= 0 {
+		return ""
+	}
+	if len(stderr) <= ExecStderrTailLength {
+		return string(stderr)
+	}
+	tail := stderr[len(stderr)-ExecStderrTailLength:]
+	if i := bytes.IndexByte(tail, '\n'); i >= 0 && i < len(tail)-1 {
+		tail = tail[i+1:]
+	}
+	return "..." + string(bytes.TrimSpace(tail))
+}
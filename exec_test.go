@@ -0,0 +1,55 @@
+package stacktrace_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestWrapExec(t *testing.T) {
+	if os.Getenv("STACKTRACE_TEST_EXEC") != "" {
+		fmt.Fprint(os.Stderr, "first line\nfatal: 100% broken\n")
+		os.Exit(3)
+	}
+
+	assert.Nil(t, stacktrace.WrapExec(nil, nil, nil))
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(os.Args[0], "-test.run=^TestWrapExec$")
+	cmd.Env = append(os.Environ(), "STACKTRACE_TEST_EXEC=1")
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+	require.Error(t, runErr)
+
+	err := stacktrace.WrapExec(cmd, runErr, stderr.Bytes())
+	prefix := "Command " + cmd.String() + " failed: "
+	assert.Equal(t, prefix+"first line\nfatal: 100% broken: exit status 3", fmt.Sprintf("%#s", err))
+	assert.Equal(t, "TestWrapExec", err.(*stacktrace.Stacktrace).Function)
+	assert.Equal(t, 3, stacktrace.ExitCode(err))
+	assert.Equal(t, 3, stacktrace.ExitCode(stacktrace.Propagate(err, "outer")))
+	assert.Equal(t, 3, err.(*stacktrace.Stacktrace).ExitCode())
+	assert.Equal(t, int(EcodeNotFastEnough), stacktrace.ExitCode(stacktrace.PropagateWithCode(err, EcodeNotFastEnough, "outer")))
+	var exitErr *exec.ExitError
+	assert.True(t, errors.As(err, &exitErr))
+
+	// the standard error captured by Output, cut to the last lines
+	defer func(length int) { stacktrace.ExecStderrTailLength = length }(stacktrace.ExecStderrTailLength)
+	stacktrace.ExecStderrTailLength = 24
+	cmd = exec.Command(os.Args[0], "-test.run=^TestWrapExec$")
+	cmd.Env = append(os.Environ(), "STACKTRACE_TEST_EXEC=1")
+	_, runErr = cmd.Output()
+	err = stacktrace.WrapExec(cmd, runErr, nil)
+	assert.Equal(t, prefix+"...fatal: 100% broken: exit status 3", fmt.Sprintf("%#s", err))
+
+	err = stacktrace.WrapExec(nil, errors.New("exec: not started"), []byte(" \n"))
+	assert.Equal(t, "Command failed: exec: not started", fmt.Sprintf("%#s", err))
+	assert.Equal(t, 1, stacktrace.ExitCode(err))
+}
@@ -0,0 +1,78 @@
+package stacktrace
+
+import (
+	"fmt"
+	"sync"
+)
+
+// maxExitCode is the largest exit status that survives os.Exit on all
+// platforms; larger values are truncated to their lowest byte by the system.
+const maxExitCode = 255
+
+var (
+	exitCodesMu sync.RWMutex
+	exitCodes   = map[ErrorCode]int{}
+)
+
+/*
+RegisterExitCode makes processes failing with an error with the given Code exit
+with the given status, see ExitCode and Fatal:
+
+	func init() {
+		Stacktrace.RegisterExitCode(EcodeBadInput, 2)
+	}
+
+RegisterExitCode returns an error if exit is not between 1 and 255 or if code is
+already registered with a different exit status.
+*/
+func RegisterExitCode(code ErrorCode, exit int) error {
+	if exit < 1 || exit > maxExitCode {
+		return fmt.Errorf("stacktrace: exit status %d for code %d is not between 1 and %d", exit, code, maxExitCode)
+	}
+
+	exitCodesMu.Lock()
+	defer exitCodesMu.Unlock()
+	if other, ok := exitCodes[code]; ok && other != exit {
+		return fmt.Errorf("stacktrace: code %d is already registered with exit status %d", code, other)
+	}
+	exitCodes[code] = exit
+	return nil
+}
+
+/*
+ExitCode returns the exit status for a process failing with err: 0 if err is nil
+and otherwise the exit status for the Code of err as found by GetCode. That is
+the one registered with RegisterExitCode, if any. Otherwise it is 1 for Code 0,
+which would look like success, 255 for Codes above 255, which would be
+truncated by the system, and the Code itself for anything in between. For
+NoCode, it is the exit status of the failed command if err comes from WrapExec,
+and 1 otherwise.
+*/
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	code := GetCode(err)
+	if code == NoCode {
+		if st := deepest(err, func(st *Stacktrace) bool { return st.exitStatus != 0 }); st != nil {
+			return st.exitStatus
+		}
+	}
+	return exitCodeFor(code)
+}
+
+func exitCodeFor(code ErrorCode) int {
+	exitCodesMu.RLock()
+	exit, ok := exitCodes[code]
+	exitCodesMu.RUnlock()
+	switch {
+	case ok:
+		return exit
+	case code == NoCode || code == 0:
+		return 1
+	case code > maxExitCode:
+		return maxExitCode
+	default:
+		return int(code)
+	}
+}
@@ -0,0 +1,36 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 0, stacktrace.ExitCode(nil))
+	assert.Equal(t, 1, stacktrace.ExitCode(errors.New("plain")))
+	assert.Equal(t, 1, stacktrace.ExitCode(stacktrace.NewError("msg")))
+	assert.Equal(t, 1, stacktrace.ExitCode(stacktrace.NewErrorWithCode(EcodeInvalidVillain, "msg")))
+	assert.Equal(t, 255, stacktrace.ExitCode(stacktrace.NewErrorWithCode(256, "msg")))
+	err := stacktrace.NewErrorWithCode(EcodeNotFastEnough, "msg")
+	assert.Equal(t, int(EcodeNotFastEnough), stacktrace.ExitCode(err))
+	assert.Equal(t, err.(*stacktrace.Stacktrace).ExitCode(), stacktrace.ExitCode(err))
+}
+
+func TestRegisterExitCode(t *testing.T) {
+	const code = stacktrace.ErrorCode(1853)
+	err := stacktrace.Propagate(stacktrace.NewErrorWithCode(code, "inner"), "outer")
+	assert.Equal(t, 255, stacktrace.ExitCode(err))
+
+	assert.NoError(t, stacktrace.RegisterExitCode(code, 42))
+	assert.NoError(t, stacktrace.RegisterExitCode(code, 42))
+	assert.Equal(t, 42, stacktrace.ExitCode(err))
+	assert.Equal(t, 42, err.(*stacktrace.Stacktrace).ExitCode())
+
+	assert.EqualError(t, stacktrace.RegisterExitCode(code, 43), "stacktrace: code 1853 is already registered with exit status 42")
+	assert.EqualError(t, stacktrace.RegisterExitCode(code+1, 0), "stacktrace: exit status 0 for code 1854 is not between 1 and 255")
+	assert.EqualError(t, stacktrace.RegisterExitCode(code+1, 256), "stacktrace: exit status 256 for code 1854 is not between 1 and 255")
+}
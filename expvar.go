@@ -0,0 +1,42 @@
+package stacktrace
+
+import (
+	"expvar"
+	"sync"
+)
+
+var publishOnce sync.Once
+
+/*
+PublishExpvar publishes the expvar map "stacktrace.errors_by_code", which counts
+the errors created from then on by error Code name (see CodeName), so that
+scraping /debug/vars picks up error rates:
+
+	"stacktrace.errors_by_code": {"EcodeTimeout": 12, "NoCode": 3}
+
+Only errors starting a new chain are counted: propagating an error that already
+has a Stacktrace does not count it again. Calling PublishExpvar more than once
+has no further effect.
+*/
+func PublishExpvar() {
+	publishOnce.Do(func() {
+		counts := expvar.NewMap("stacktrace.errors_by_code")
+		RegisterHook(func(st *Stacktrace) {
+			if !wrapsStacktrace(st.Cause) {
+				counts.Add(CodeName(st.Code), 1)
+			}
+		})
+	})
+}
+
+// wrapsStacktrace reports whether there is a *Stacktrace in the chain of err.
+// Unlike HasStacktrace, it also finds those without Line number information,
+// such as the errors of NewMessageWithCode, which were already counted.
+func wrapsStacktrace(err error) bool {
+	found := false
+	Walk(err, func(err error) bool {
+		_, found = err.(*Stacktrace)
+		return !found
+	})
+	return found
+}
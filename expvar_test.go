@@ -0,0 +1,33 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"expvar"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	stacktrace.PublishExpvar()
+	stacktrace.PublishExpvar()
+	counts := expvar.Get("stacktrace.errors_by_code").(*expvar.Map)
+	count := func(name string) int64 {
+		if v, ok := counts.Get(name).(*expvar.Int); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	before := count("2001")
+
+	err := stacktrace.NewErrorWithCode(2001, "msg")
+	_ = stacktrace.Propagate(err, "")
+	_ = stacktrace.PropagateWithCode(errors.New("plain"), 2001, "")
+	assert.Equal(t, before+2, count("2001"))
+
+	// errors without a location are counted once too
+	_ = stacktrace.Propagate(stacktrace.NewMessageWithCode(2001, "msg"), "")
+	assert.Equal(t, before+3, count("2001"))
+}
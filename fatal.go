@@ -0,0 +1,38 @@
+package stacktrace
+
+import (
+	"fmt"
+	"os"
+)
+
+/*
+FatalDebugEnv is the environment variable that makes Fatal print the whole
+Stacktrace rather than just the brief message.
+*/
+var FatalDebugEnv = "STACKTRACE_DEBUG"
+
+/*
+Fatal prints err to stderr and exits with ExitCode(err). It does nothing if err
+is nil, so the main Function of a command line tool can be as short as:
+
+	func main() {
+		Stacktrace.Fatal(run())
+	}
+
+By default only the brief message is printed, which is what users of the tool
+care about. If the environment variable named by FatalDebugEnv is set to a
+non-empty value, err is printed according to DefaultFormat instead, in color if
+stderr is a terminal; see WriteColor. Errors that are not Stacktraces are
+printed with their Error method.
+*/
+func Fatal(err error) {
+	if err == nil {
+		return
+	}
+	if os.Getenv(FatalDebugEnv) != "" {
+		_ = WriteColor(os.Stderr, err)
+	} else {
+		fmt.Fprintf(os.Stderr, "%#s\n", err)
+	}
+	os.Exit(ExitCode(err))
+}
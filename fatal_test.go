@@ -0,0 +1,47 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestFatal(t *testing.T) {
+	if os.Getenv("STACKTRACE_TEST_FATAL") != "" {
+		stacktrace.Fatal(nil)
+		err := stacktrace.NewErrorWithCode(EcodeNotFastEnough, "inner")
+		stacktrace.Fatal(stacktrace.Propagate(err, "outer"))
+		return
+	}
+
+	for _, testcase := range []struct {
+		debug    string
+		expected string
+	}{
+		{
+			debug:    "",
+			expected: "outer: inner\n",
+		},
+		{
+			debug: "1",
+			expected: "outer\n" +
+				" --- at github.com/palantir/Stacktrace/fatal_test.go:# (TestFatal) ---\n" +
+				"Caused by: inner\n" +
+				" --- at github.com/palantir/Stacktrace/fatal_test.go:# (TestFatal) ---\n",
+		},
+	} {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestFatal$")
+		cmd.Env = append(os.Environ(), "STACKTRACE_TEST_FATAL=1", "STACKTRACE_DEBUG="+testcase.debug)
+		stdout, err := cmd.Output()
+		var exitErr *exec.ExitError
+		require.True(t, errors.As(err, &exitErr), "unexpected error: %v", err)
+		assert.Equal(t, int(EcodeNotFastEnough), exitErr.ExitCode())
+		assert.Equal(t, testcase.expected, normalizeLines(string(exitErr.Stderr)), "debug: %q, stdout: %q", testcase.debug, stdout)
+	}
+}
@@ -0,0 +1,53 @@
+package stacktrace
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+/*
+Fingerprint returns a short hash identifying the kind of failure err represents,
+so that alerting pipelines can group occurrences of the same failure that differ
+only in their parameters:
+
+	alerts.Dedupe(Stacktrace.Fingerprint(err), err)
+
+The hash covers the error Code, the message templates of the chain, the types of
+the errors that are not Stacktrace errors, and the File and Function of the
+innermost location. The arguments interpolated into messages are left out, and
+so are Line numbers, which change with unrelated edits. Errors whose messages
+were not created by this package, such as a Stacktrace error built by hand,
+contribute their whole Message instead of its template, and lazy messages from
+NewErrorLazy and PropagateLazy contribute the name of the function that produces
+them, which is not called.
+
+Fingerprint returns "" if err is nil.
+*/
+func Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "code=%d\n", GetCode(err))
+	Walk(err, func(err error) bool {
+		st, ok := err.(*Stacktrace)
+		switch {
+		case !ok:
+			fmt.Fprintf(h, "type=%T\n", err)
+		case st.format != "":
+			fmt.Fprintf(h, "msg=%q\n", st.format)
+		case st.lazy != nil:
+			// Producing the message could be expensive, and it is not a template
+			fmt.Fprintf(h, "lazy=%s\n", st.lazy.source())
+		case st.Message != "":
+			fmt.Fprintf(h, "msg=%q\n", st.Message)
+		}
+		return true
+	})
+	if loc, ok := Location(err); ok {
+		fmt.Fprintf(h, "at=%s %s\n", loc.File, loc.Function)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
@@ -0,0 +1,58 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func openConfig(path string) error {
+	_, err := os.Open(path)
+	return stacktrace.Propagate(err, "Failed to open %s", path)
+}
+
+func lookupUser(id int) error {
+	return stacktrace.NewErrorWithCode(EcodeNoSuchPseudo, "No user %d", id)
+}
+
+func TestFingerprint(t *testing.T) {
+	a := stacktrace.Propagate(openConfig("/no/such/a.yaml"), "Failed to start")
+	b := stacktrace.Propagate(openConfig("/no/such/b.yaml"), "Failed to start")
+	assert.Len(t, stacktrace.Fingerprint(a), 16)
+	assert.Equal(t, stacktrace.Fingerprint(a), stacktrace.Fingerprint(b))
+
+	for _, other := range []error{
+		stacktrace.Propagate(openConfig("/no/such/a.yaml"), "Failed to stop"),
+		stacktrace.PropagateWithCode(openConfig("/no/such/a.yaml"), EcodeTimeIsIllusion, "Failed to start"),
+		stacktrace.Propagate(lookupUser(1), "Failed to start"),
+		stacktrace.Propagate(stacktrace.Propagate(errors.New("/no/such/a.yaml"), "Failed to open %s", "a"), "Failed to start"),
+	} {
+		assert.NotEqual(t, stacktrace.Fingerprint(a), stacktrace.Fingerprint(other))
+	}
+
+	assert.Equal(t, stacktrace.Fingerprint(lookupUser(1)), stacktrace.Fingerprint(lookupUser(2)))
+	assert.NotEqual(t, stacktrace.Fingerprint(errors.New("a")), stacktrace.Fingerprint(lookupUser(1)))
+	assert.Equal(t, "", stacktrace.Fingerprint(nil))
+}
+
+func lazyUser(id int, calls *int) error {
+	return stacktrace.NewErrorLazy(func() string {
+		*calls++
+		return "No user " + strconv.Itoa(id)
+	})
+}
+
+func TestFingerprintLazy(t *testing.T) {
+	calls := 0
+	a, b := lazyUser(1, &calls), lazyUser(2, &calls)
+	assert.Equal(t, stacktrace.Fingerprint(a), stacktrace.Fingerprint(b))
+	assert.NotEqual(t, stacktrace.Fingerprint(a), stacktrace.Fingerprint(stacktrace.NewErrorLazy(func() string {
+		return "No user 1"
+	})))
+	assert.Zero(t, calls)
+}
@@ -15,8 +15,13 @@
 package stacktrace
 
 import (
+	"bytes"
 	"fmt"
+	"maps"
+	"strconv"
 	"strings"
+	"sync"
+	"text/template"
 )
 
 /*
@@ -27,12 +32,116 @@ number information. To have them produce a condensed single-Line output, set
 this value to Stacktrace.FormatBrief.
 
 The formatting specifier "%+s" can be used to force a full Stacktrace regardless
-of the value of DefaultFormat. Similarly, the formatting specifier "%#s" can be
-used to force a brief output.
+of the value of DefaultFormat, followed by the dump attached by
+WithGoroutineDump if there is one. Similarly, the formatting specifier "%#s" can
+be used to force a brief output.
+
+The space flag, as in "% s", "% v", "% +s" or "% #s", includes error codes in
+the full and brief output as if ShowCodes were set.
+
+The formatting specifier "%d" prints the numeric error Code instead, which is
+the value of NoCode if there is none.
+
+DefaultFormat can also be set to a custom Format created by NewFormat. It can
+be overridden for a single error with WithFormat.
 */
 var DefaultFormat = FormatFull
 
-// Format is the type of the two possible values of Stacktrace.DefaultFormat.
+/*
+CollapseDuplicateFrames controls whether the full format merges consecutive
+identical "--- at" lines into one, annotated with the number of times it was
+repeated. This happens when the same call site propagates an error several
+times in a row, for example from a deferred function:
+
+	Failed to commit transaction
+	 --- at github.com/palantir/shield/store/tx.go:58 (Tx.Commit) --- (repeated 3 times)
+*/
+var CollapseDuplicateFrames = false
+
+/*
+ShowCodes controls whether the full and brief formats include error codes. The
+Code is shown by its registered name (see RegisterCode), or its number if it has
+none, after the Message of the level where it was attached or changed, rather
+than at every level that inherited it:
+
+	Failed to load manifest [code=EcodeManifestNotFound]
+	 --- at github.com/palantir/shield/manifest.go:51 (Load) ---
+*/
+var ShowCodes = false
+
+/*
+FrameTemplate, if not nil, replaces the default rendering of each "--- at" line
+in the full format. It is executed with a Frame as data, so it can match the
+layout expected by existing log processing or make locations clickable in an
+IDE:
+
+	Stacktrace.FrameTemplate = template.Must(template.New("frame").Parse(
+		"    at {{.Function}} ({{.File}}:{{.Line}})"))
+
+If executing the template fails, the default rendering is used.
+*/
+var FrameTemplate *template.Template
+
+/*
+WrapWidth, if positive, makes the full format soft-wrap each line of a Message
+that would extend past that column, breaking between words and indenting the
+continuation lines by four spaces. The "--- at" lines, stacks and labels are not
+wrapped, so they stay on their own lines:
+
+	Caused by: Failed to reconcile the deployment of service shield-api in
+	    namespace production after 5 attempts
+	 --- at github.com/palantir/shield/deploy/reconcile.go:112 (Reconcile) ---
+
+Words longer than WrapWidth are not broken. Parse returns wrapped messages with
+their line breaks and indentation.
+*/
+var WrapWidth = 0
+
+/*
+GroupFramesByPackage controls whether the full format shows consecutive frames
+of a stack (see CaptureStack) that are in the same package under a single line
+naming the package, with only the File names beneath it:
+
+	Failed to handle request
+	 --- at github.com/palantir/shield/server.go:61 (Server.handle) ---
+	     in github.com/palantir/shield/server:
+	         server.go:61 (Server.handle)
+	         middleware.go:25 (withAuth.func1)
+	     in net/http:
+	         server.go:2166 (HandlerFunc.ServeHTTP)
+	         server.go:3210 (serverHandler.ServeHTTP)
+
+Frames that are alone in their package are shown as usual. Frames are not
+grouped if FrameTemplate is set.
+*/
+var GroupFramesByPackage = false
+
+/*
+FormatV1 is version 1 of the full and brief formats, which is stable: tooling
+that parses these formats, such as alerts and grep-based runbooks, can rely on
+it across upgrades of this package. With the default settings, version 1 of the
+full format is made of the levels of a chain, outermost first:
+
+	Message
+	 --- at File:Line (Function) ---
+	Caused by: Message
+	 --- at File:Line (Function) ---
+	Caused by: Error() of a Cause that is not a Stacktrace
+
+A level with an empty Message only adds its "--- at" Line to the level that
+wraps it. A Message spanning several lines is printed as is. Version 1 of the
+brief format joins the non-empty messages, and the Error() of a Cause that is
+not a Stacktrace, with ": ".
+
+Both formats depend only on the error and on the settings of this package, not
+on the locale or environment. Settings that are off by default, like ShowCodes,
+CollapseDuplicateFrames, FrameTemplate, WrapWidth, MaxFormatSize and
+GroupFramesByPackage, extend the formats as documented, as
+does the boundary line of chains merged by Graft.
+*/
+const FormatV1 = 1
+
+// Format is the type of the possible values of Stacktrace.DefaultFormat.
 type Format int
 
 const (
@@ -40,23 +149,144 @@ const (
 	FormatFull Format = iota
 	// FormatBrief means Format on a single Line without Line number information.
 	FormatBrief
+	// FormatFullWithSource means format as a full Stacktrace, followed under
+	// each Line number by the source code around it when the File can be found
+	// on disk. Intended for development and CI logs.
+	FormatFullWithSource
+	// FormatColor means format as a full Stacktrace with ANSI color escape
+	// sequences, for printing to a terminal. See also WriteColor.
+	FormatColor
+	// FormatLogfmt means format on a single Line of logfmt key=value pairs:
+	// msg, code, cause, File and Line.
+	FormatLogfmt
+	// FormatTree means format as a full Stacktrace, drawing errors with several
+	// causes, such as the ones returned by errors.Join, as a tree.
+	FormatTree
+	// FormatFullReversed means format as a full Stacktrace, but starting from
+	// the root Cause and ending with the outermost Message, for reading logs
+	// bottom-up like a Python traceback.
+	FormatFullReversed
+	// FormatBriefWithLocation means Format on a single Line like FormatBrief,
+	// followed by the File and Line number of the innermost call site.
+	FormatBriefWithLocation
+)
+
+/*
+Formatter renders a Stacktrace as text. Applications can install their own
+Formatter with NewFormat to control the output of err.Error() and the "%v", "%s"
+and "%q" formatting specifiers.
+*/
+type Formatter interface {
+	Format(st *Stacktrace) string
+}
+
+// FormatterFunc adapts an ordinary function to the Formatter interface.
+type FormatterFunc func(st *Stacktrace) string
+
+// Format calls f(st).
+func (f FormatterFunc) Format(st *Stacktrace) string {
+	return f(st)
+}
+
+var (
+	formattersMu sync.RWMutex
+	formatters   = map[Format]Formatter{
+		FormatFull:              FormatterFunc(formatFull),
+		FormatBrief:             FormatterFunc(formatBrief),
+		FormatFullWithSource:    FormatterFunc(formatFullWithSource),
+		FormatColor:             FormatterFunc(formatColor),
+		FormatLogfmt:            FormatterFunc(formatLogfmt),
+		FormatTree:              FormatterFunc(formatTree),
+		FormatFullReversed:      FormatterFunc(formatFullReversed),
+		FormatBriefWithLocation: FormatterFunc(formatBriefWithLocation),
+	}
+	// Formats returned by NewFormat start here to stay clear of the built-ins.
+	nextCustomFormat = Format(1 << 16)
 )
 
+/*
+NewFormat registers formatter and returns a new Format value that selects it.
+Set DefaultFormat to the returned value to use formatter for all Stacktrace
+errors:
+
+	var FormatJSON = Stacktrace.NewFormat(Stacktrace.FormatterFunc(toJSON))
+
+	func main() {
+		Stacktrace.DefaultFormat = FormatJSON
+		...
+	}
+
+The built-in formats cannot be replaced, and "%+s" and "%#s" always produce the
+built-in full and brief output.
+*/
+func NewFormat(formatter Formatter) Format {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+	format := nextCustomFormat
+	nextCustomFormat++
+	formatters[format] = formatter
+	return format
+}
+
+// formatterFor returns the Formatter registered for format, falling back to
+// the full format for unknown values.
+func formatterFor(format Format) Formatter {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	if formatter, ok := formatters[format]; ok {
+		return formatter
+	}
+	return FormatterFunc(formatFull)
+}
+
 var _ fmt.Formatter = (*Stacktrace)(nil)
 
 func (st *Stacktrace) Format(f fmt.State, c rune) {
-	var text string
+	if st == nil {
+		if c == 'd' {
+			// "<nil>" is not a number
+			c = 's'
+		}
+		fmt.Fprintf(f, reconstructVerb(f, c), "<nil>")
+		return
+	}
+
+	if c == 'd' { // "%d"
+		fmt.Fprintf(f, reconstructVerb(f, c), int(st.Code))
+		return
+	}
+
+	text := st.formatText(f, c)
+	fmt.Fprintf(f, reconstructVerb(f, c), truncate(text, MaxFormattedLength))
+}
+
+// formatText renders st for Format.
+func (st *Stacktrace) formatText(f fmt.State, c rune) (text string) {
+	defer recoverFormat(&text)
 	if f.Flag('+') && !f.Flag('#') && c == 's' { // "%+s"
-		text = formatFull(st)
+		full := renderFull(st, fullSettings(ShowCodes || f.Flag(' ')))
+		if dump := GoroutineDump(st); dump != "" {
+			full += "\n" + goroutineDumpHeader + "\n" + dump
+		}
+		return full
 	} else if f.Flag('#') && !f.Flag('+') && c == 's' { // "%#s"
-		text = formatBrief(st)
-	} else {
-		text = map[Format]func(*Stacktrace) string{
-			FormatFull:  formatFull,
-			FormatBrief: formatBrief,
-		}[DefaultFormat](st)
+		return renderBrief(st, renderOptions{codes: ShowCodes || f.Flag(' ')})
+	}
+	format := DefaultFormat
+	if st.preferred != nil {
+		format = *st.preferred
 	}
+	if f.Flag(' ') && format == FormatBrief { // "% s"
+		return renderBrief(st, renderOptions{codes: true})
+	} else if f.Flag(' ') && format == FormatFull {
+		return renderFull(st, fullSettings(true))
+	}
+	return formatterFor(format).Format(st)
+}
 
+// reconstructVerb returns the formatting directive for c with the flags, width
+// and precision of f.
+func reconstructVerb(f fmt.State, c rune) string {
 	formatString := "%"
 	// keep the flags recognized by fmt package
 	for _, flag := range "-+# 0" {
@@ -72,63 +302,564 @@ func (st *Stacktrace) Format(f fmt.State, c rune) {
 		formatString += fmt.Sprint(precision)
 	}
 	formatString += string(c)
-	fmt.Fprintf(f, formatString, text)
+	return formatString
 }
 
-func formatFull(st *Stacktrace) string {
-	var str string
+// renderOptions holds the variations of the full and brief formats selected by
+// Format and by the options of Fprint.
+type renderOptions struct {
+	source bool
+	color  bool
+	codes  bool
+	// depth, if positive, limits the number of levels of each chain shown.
+	depth int
+	// hideLocations omits the "--- at" lines and stacks of the full format.
+	hideLocations bool
+	// width is the column at which messages are wrapped, see WrapWidth. The
+	// output will be written to start at column first and to have its other
+	// lines indented by indent columns.
+	width, first, indent int
+	// maxFrames, if positive, limits the number of frames of each stack shown,
+	// and keepLevels, if positive, the number of levels shown at each end of a
+	// chain. The frames and levels in the middle are omitted.
+	maxFrames, keepLevels int
+	// module is the File prefix of the frames highlighted in stacks, or the
+	// only frames shown if onlyModule is true.
+	module     string
+	onlyModule bool
+	// group shows consecutive frames of stacks in the same package under a
+	// single line naming it, see GroupFramesByPackage.
+	group bool
+	// size, if positive, is the budget of the output in bytes, see
+	// MaxFormatSize.
+	size int
+	// seen holds the levels on the path to the error being rendered. Each
+	// branch of an error with several causes gets its own copy.
+	seen map[*Stacktrace]bool
+}
+
+// fullSettings returns the options of the full format selected by the settings
+// of this package, showing codes if codes is true.
+func fullSettings(codes bool) renderOptions {
+	return renderOptions{
+		codes: codes,
+		width: WrapWidth,
+		size:  MaxFormatSize,
+		group: GroupFramesByPackage,
+	}
+}
+
+// inModule reports whether frame is in opts.module.
+func (opts renderOptions) inModule(frame Frame) bool {
+	return strings.HasPrefix(frame.File, opts.module)
+}
+
+func formatFull(st *Stacktrace) (text string) {
+	defer recoverFormat(&text)
+	return renderFull(st, fullSettings(ShowCodes))
+}
+
+// recoverFormat replaces the text being produced by a formatter with a note if
+// it panics, for example because the Error method of a Cause does, so that
+// logging an error cannot take the process down.
+func recoverFormat(text *string) {
+	if r := recover(); r != nil {
+		*text = fmt.Sprintf("<error while formatting: %v>", r)
+	}
+}
+
+func formatFullWithSource(st *Stacktrace) string {
+	opts := fullSettings(ShowCodes)
+	opts.source = true
+	return renderFull(st, opts)
+}
+
+func formatColor(st *Stacktrace) string {
+	opts := fullSettings(ShowCodes)
+	opts.color = true
+	return renderFull(st, opts)
+}
+
+func renderFull(st *Stacktrace, opts renderOptions) string {
+	if opts.size > 0 {
+		return renderWithin(st, opts)
+	}
+	// Only the outermost call, for the error being formatted, shows the build
+	top := opts.seen == nil
+	if top {
+		opts.seen = make(map[*Stacktrace]bool)
+	}
+	levels, truncated := chainFrom(st, opts.seen)
+	if len(levels) == 0 {
+		return truncatedMarker
+	}
+	if opts.depth > 0 && len(levels) > opts.depth {
+		levels, truncated = levels[:opts.depth], true
+	}
+
+	var b strings.Builder
+	var num [20]byte
+	var enclosing []Frame
+	b.Grow(estimateSize(levels))
 	newline := func() {
-		if str != "" && !strings.HasSuffix(str, "\n") {
-			str += "\n"
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	paint := func(color string) {
+		if opts.color {
+			b.WriteString(color)
 		}
 	}
 
-	for curr, ok := st, true; ok; curr, ok = curr.Cause.(*Stacktrace) {
-		str += curr.Message
+	for i := 0; i < len(levels); i++ {
+		curr := levels[i]
+		label := levelLabel(curr, opts.codes)
+		if curr.message() != "" {
+			paint(ansiBold)
+			if opts.width > 0 {
+				writeWrapped(&b, curr.message(), opts)
+			} else {
+				b.WriteString(curr.message())
+			}
+			paint(ansiReset)
+			if label != "" {
+				b.WriteByte(' ')
+				b.WriteString(label)
+				label = ""
+			}
+		}
 
-		if curr.File != "" {
+		if curr.File != "" && !opts.hideLocations {
 			newline()
-			if curr.Function == "" {
-				str += fmt.Sprintf(" --- at %v:%v ---", curr.File, curr.Line)
-			} else {
-				str += fmt.Sprintf(" --- at %v:%v (%v) ---", curr.File, curr.Line, curr.Function)
+			paint(ansiCyan)
+			writeFrame(&b, Frame{File: curr.File, Function: curr.Function, Line: curr.Line}, " --- at ", " ---")
+			if label != "" {
+				b.WriteByte(' ')
+				b.WriteString(label)
+				label = ""
+			}
+
+			if CollapseDuplicateFrames {
+				repeats := 1
+				for i+repeats < len(levels) && isRepeatedFrame(curr, levels[i+repeats]) {
+					repeats++
+				}
+				if repeats > 1 {
+					b.WriteString(" (repeated ")
+					b.Write(strconv.AppendInt(num[:0], int64(repeats), 10))
+					b.WriteString(" times)")
+					i += repeats - 1
+					curr = levels[i]
+				}
+			}
+			paint(ansiReset)
+		}
+		if label != "" {
+			newline()
+			b.WriteString(label)
+		}
+
+		if curr.File != "" && !opts.hideLocations {
+			if opts.source {
+				writeSource(&b, curr.File, curr.Line)
+			}
+
+			if len(curr.Stack) > 0 {
+				shared := commonSuffix(curr.Stack, enclosing)
+				unique := curr.Stack[:len(curr.Stack)-shared]
+				head, tail := len(unique), 0
+				if opts.maxFrames > 0 && len(unique) > opts.maxFrames {
+					head, tail = (opts.maxFrames+1)/2, opts.maxFrames/2
+				}
+				// stackLine writes the line for frame, dimmed or marked
+				// depending on whether it is in the highlighted module, and
+				// without the directory of its File if grouped is true
+				stackLine := func(frame Frame, grouped bool) {
+					newline()
+					prefix, marked := "     at ", "   * at "
+					if grouped {
+						prefix, marked = "         ", "       * "
+					}
+					if opts.module == "" || opts.onlyModule || !opts.inModule(frame) {
+						paint(ansiDim)
+					} else if !opts.color {
+						prefix = marked
+					}
+					if grouped {
+						frame.File = frame.File[len(framePackage(frame))+1:]
+					}
+					writeFrame(&b, frame, prefix, "")
+					paint(ansiReset)
+				}
+				for j := 0; j < len(unique); j++ {
+					omitted := 0
+					if j == head {
+						omitted = len(unique) - head - tail
+					} else if opts.onlyModule {
+						for j+omitted < len(unique) && j+omitted != head && !opts.inModule(unique[j+omitted]) {
+							omitted++
+						}
+					}
+					if omitted > 0 {
+						newline()
+						paint(ansiDim)
+						b.WriteString("     ")
+						b.WriteString(omittedMarker(omitted, "frame"))
+						paint(ansiReset)
+						j += omitted - 1
+						continue
+					}
+
+					pkg, grouped := framePackage(unique[j]), 1
+					if opts.group && FrameTemplate == nil && pkg != "" {
+						for j+grouped < len(unique) && j+grouped != head &&
+							(!opts.onlyModule || opts.inModule(unique[j+grouped])) &&
+							framePackage(unique[j+grouped]) == pkg {
+							grouped++
+						}
+					}
+					if grouped == 1 {
+						stackLine(unique[j], false)
+						continue
+					}
+					newline()
+					paint(ansiDim)
+					b.WriteString("     in ")
+					b.WriteString(pkg)
+					b.WriteByte(':')
+					paint(ansiReset)
+					for _, frame := range unique[j : j+grouped] {
+						stackLine(frame, true)
+					}
+					j += grouped - 1
+				}
+				if curr.OmittedFrames > 0 {
+					newline()
+					paint(ansiDim)
+					b.WriteString("     ")
+					b.WriteString(omittedMarker(curr.OmittedFrames, "frame"))
+					paint(ansiReset)
+				}
+				if shared > 0 {
+					newline()
+					paint(ansiDim)
+					b.WriteString("     ... ")
+					b.Write(strconv.AppendInt(num[:0], int64(shared), 10))
+					b.WriteString(" more")
+					paint(ansiReset)
+				}
+				enclosing = curr.Stack
 			}
 		}
 
-		if curr.Cause != nil {
+		for _, suppressed := range curr.Suppressed {
+			newline()
+			writeBlock(&b, "    ", "Suppressed:", suppressed, opts)
+		}
+
+		if keep := opts.keepLevels; keep > 0 && i+1 >= keep && len(levels)-(i+1) > keep {
+			omitted := len(levels) - keep - (i + 1)
+			newline()
+			paint(ansiDim)
+			b.WriteString(omittedMarker(omitted, "cause"))
+			paint(ansiReset)
+			i += omitted
+			curr = levels[i]
+		}
+
+		if truncated && i == len(levels)-1 {
 			newline()
-			if cause, ok := curr.Cause.(*Stacktrace); !ok {
-				str += "Caused by: "
-				str += curr.Cause.Error()
-			} else if cause.Message != "" {
-				str += "Caused by: "
+			paint(ansiDim)
+			b.WriteString(truncatedMarker)
+			paint(ansiReset)
+		} else if curr.Cause != nil {
+			newline()
+			if curr.remoteCause {
+				paint(ansiDim)
+				b.WriteString(remoteBoundary)
+				paint(ansiReset)
+				b.WriteByte('\n')
+			}
+			if causes, ok := branchesOf(curr.Cause); ok {
+				writeBranches(&b, causes, opts)
+			} else if cause, ok := curr.Cause.(*Stacktrace); !ok || cause == nil {
+				paint(ansiRed)
+				b.WriteString("Caused by: ")
+				paint(ansiReset)
+				paint(ansiBold)
+				b.WriteString(curr.Cause.Error())
+				paint(ansiReset)
+			} else if cause.message() != "" {
+				paint(ansiRed)
+				b.WriteString("Caused by: ")
+				paint(ansiReset)
+			}
+		}
+	}
+
+	if top && st.Build != nil {
+		newline()
+		paint(ansiDim)
+		b.WriteString("Build: ")
+		b.WriteString(st.Build.String())
+		paint(ansiReset)
+	}
+	return b.String()
+}
+
+// writeBranches writes each of causes as an indented block headed by
+// "Caused by (i of n):".
+func writeBranches(b *strings.Builder, causes []error, opts renderOptions) {
+	for i, cause := range causes {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		writeBlock(b, "", fmt.Sprintf("Caused by (%d of %d):", i+1, len(causes)), cause, opts)
+	}
+}
+
+// writeBlock writes the full format of err headed by header, with every line
+// prefixed by indent and the lines after the header indented by four more
+// spaces.
+func writeBlock(b *strings.Builder, indent, header string, err error, opts renderOptions) {
+	inline := true
+	if st, ok := err.(*Stacktrace); ok {
+		inline = st.message() != ""
+	}
+	nestedOpts := opts
+	nestedOpts.seen = maps.Clone(opts.seen)
+	nestedOpts.indent += len(indent) + 4
+	nestedOpts.first = nestedOpts.indent
+	if inline {
+		nestedOpts.first = opts.indent + len(indent) + len(header) + 1
+	}
+
+	var text string
+	if st, ok := err.(*Stacktrace); ok {
+		text = renderFull(st, nestedOpts)
+	} else if causes, ok := branchesOf(err); ok {
+		var nested strings.Builder
+		writeBranches(&nested, causes, nestedOpts)
+		text = nested.String()
+	} else {
+		text = err.Error()
+	}
+
+	b.WriteString(indent)
+	if opts.color {
+		b.WriteString(ansiRed)
+	}
+	b.WriteString(header)
+	if opts.color {
+		b.WriteString(ansiReset)
+	}
+	lines := strings.Split(text, "\n")
+	if inline {
+		b.WriteByte(' ')
+		b.WriteString(lines[0])
+		lines = lines[1:]
+	}
+	for _, line := range lines {
+		b.WriteByte('\n')
+		b.WriteString(indent)
+		b.WriteString("    ")
+		b.WriteString(line)
+	}
+}
+
+// framePackage returns the directory of the File of frame, which is the import
+// path of its package after CleanPath, or "" if it has none.
+func framePackage(frame Frame) string {
+	if i := strings.LastIndexByte(frame.File, '/'); i > 0 {
+		return frame.File[:i]
+	}
+	return ""
+}
+
+// writeWrapped writes msg to b, breaking each of its lines between words before
+// they extend past opts.width, and indenting the continuation lines by four
+// spaces.
+func writeWrapped(b *strings.Builder, msg string, opts renderOptions) {
+	last := strings.LastIndexByte(b.String(), '\n')
+	column := opts.indent
+	if last < 0 {
+		column = opts.first
+	}
+	column += len(ansiEscape.ReplaceAllString(b.String()[last+1:], ""))
+	for i, line := range strings.Split(msg, "\n") {
+		if i > 0 {
+			b.WriteByte('\n')
+			column = opts.indent
+		}
+		for j, word := range strings.Split(line, " ") {
+			if j > 0 {
+				if column > opts.indent+4 && column+1+len(word) > opts.width {
+					b.WriteString("\n    ")
+					column = opts.indent + 4
+				} else {
+					b.WriteByte(' ')
+					column++
+				}
 			}
+			b.WriteString(word)
+			column += len(word)
+		}
+	}
+}
+
+// writeFrame writes the line for frame in the full format, using FrameTemplate
+// if it is set and otherwise the location between prefix and suffix.
+func writeFrame(b *strings.Builder, frame Frame, prefix, suffix string) {
+	if FrameTemplate != nil {
+		var buf bytes.Buffer
+		if err := FrameTemplate.Execute(&buf, frame); err == nil {
+			b.Write(buf.Bytes())
+			return
 		}
 	}
+	b.WriteString(prefix)
+	writeLocation(b, frame.File, frame.Line, frame.Function)
+	b.WriteString(suffix)
+}
+
+// writeLocation writes "file:line (function)", or "file:line" if function is
+// unknown.
+func writeLocation(b *strings.Builder, file string, line int, function string) {
+	var num [20]byte
+	b.WriteString(file)
+	b.WriteByte(':')
+	b.Write(strconv.AppendInt(num[:0], int64(line), 10))
+	if function != "" {
+		b.WriteString(" (")
+		b.WriteString(function)
+		b.WriteByte(')')
+	}
+}
+
+// codeLabel returns "[code=name]" if st attaches or changes an error Code, or
+// "" if its Code is NoCode or inherited from its Cause. See CodeName.
+func codeLabel(st *Stacktrace) string {
+	if st.Code == NoCode || st.Code == GetCode(st.Cause) {
+		return ""
+	}
+	return "[code=" + CodeName(st.Code) + "]"
+}
+
+// levelLabel returns the labels shown after the Message of st: its ID label and,
+// if codes is true, its Code label.
+func levelLabel(st *Stacktrace, codes bool) string {
+	label := idLabel(st)
+	if code := codeLabel(st); codes && code != "" {
+		label = strings.TrimPrefix(label+" "+code, " ")
+	}
+	return label
+}
+
+// isRepeatedFrame reports whether next would be printed by formatFull as an
+// identical "--- at" line immediately following the one for curr.
+func isRepeatedFrame(curr, next *Stacktrace) bool {
+	return next.message() == "" &&
+		len(curr.Suppressed) == 0 &&
+		len(next.Suppressed) == 0 &&
+		next.Code == curr.Code &&
+		codeLabel(next) == "" &&
+		idLabel(next) == "" &&
+		next.File == curr.File &&
+		next.Line == curr.Line &&
+		next.Function == curr.Function
+}
 
-	return str
+func formatBrief(st *Stacktrace) (text string) {
+	defer recoverFormat(&text)
+	return renderBrief(st, renderOptions{codes: ShowCodes})
 }
 
-func formatBrief(st *Stacktrace) string {
-	var str string
+func renderBrief(st *Stacktrace, opts renderOptions) string {
+	if opts.seen == nil {
+		opts.seen = make(map[*Stacktrace]bool)
+	}
+	levels, truncated := chainFrom(st, opts.seen)
+	if len(levels) == 0 {
+		return truncatedMarker
+	}
+	if opts.depth > 0 && len(levels) > opts.depth {
+		levels, truncated = levels[:opts.depth], true
+	}
+
+	var b strings.Builder
+	b.Grow(estimateSize(levels))
 	concat := func(msg string) {
-		if str != "" && msg != "" {
-			str += ": "
+		if b.Len() > 0 && msg != "" {
+			b.WriteString(": ")
 		}
-		str += msg
+		b.WriteString(msg)
+	}
+
+	for _, curr := range levels {
+		msg := curr.message()
+		if label := levelLabel(curr, opts.codes); label != "" {
+			msg = strings.TrimPrefix(msg+" "+label, " ")
+		}
+		concat(msg)
+	}
+	if last := levels[len(levels)-1]; truncated {
+		concat(truncatedMarker)
+	} else if last.Cause != nil {
+		concat(briefCause(last.Cause, opts))
 	}
+	return b.String()
+}
+
+// formatBriefWithLocation renders the brief format followed by
+// " (file:line)" for the deepest level of the chain that has a location.
+func formatBriefWithLocation(st *Stacktrace) string {
+	return appendLocation(formatBrief(st), st)
+}
 
-	curr := st
-	for {
-		concat(curr.Message)
-		if cause, ok := curr.Cause.(*Stacktrace); ok {
-			curr = cause
-		} else {
-			break
+// appendLocation appends " (file:line)" for the deepest level of the chain of
+// st that has a location to brief.
+func appendLocation(brief string, st *Stacktrace) string {
+	levels, _ := chain(st)
+	for i := len(levels) - 1; i >= 0; i-- {
+		if levels[i].File != "" {
+			return fmt.Sprintf("%s (%s:%d)", brief, levels[i].File, levels[i].Line)
 		}
 	}
-	if curr.Cause != nil {
-		concat(curr.Cause.Error())
+	return brief
+}
+
+// briefCause renders a cause in the brief format. Several causes are listed
+// between brackets, separated by semicolons.
+func briefCause(cause error, opts renderOptions) string {
+	if st, ok := cause.(*Stacktrace); ok && st != nil {
+		return renderBrief(st, opts)
+	}
+	causes, ok := branchesOf(cause)
+	if !ok {
+		return cause.Error()
+	}
+	briefs := make([]string, len(causes))
+	for i, cause := range causes {
+		branchOpts := opts
+		branchOpts.seen = maps.Clone(opts.seen)
+		briefs[i] = briefCause(cause, branchOpts)
+	}
+	return "[" + strings.Join(briefs, "; ") + "]"
+}
+
+// estimateSize returns a rough upper bound on the length of the full format of
+// levels, not counting the text of a non-Stacktrace root cause. It is only used
+// to preallocate the output buffer, so it does not need to be exact.
+func estimateSize(levels []*Stacktrace) int {
+	// " --- at " + ":" + line number + " (" + ")" + " ---" + "\n" + "Caused by: "
+	const overhead = 40
+	size := len(truncatedMarker)
+	for _, curr := range levels {
+		size += len(curr.message()) + len(curr.File) + len(curr.Function) + overhead
+		for _, frame := range curr.Stack {
+			size += len(frame.File) + len(frame.Function) + overhead
+		}
 	}
-	return str
+	return size
 }
@@ -18,7 +18,9 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 	"testing"
+	"text/template"
 
 	"github.com/stretchr/testify/assert"
 
@@ -95,3 +97,343 @@ func TestFormat(t *testing.T) {
 		assert.Equal(t, test.expectedStacktrace, actualStacktrace)
 	}
 }
+
+func deepChain(depth int) error {
+	err := errors.New("root cause")
+	for i := 0; i < depth; i++ {
+		err = stacktrace.Propagate(err, "failed at depth %d", i)
+	}
+	return err
+}
+
+func BenchmarkFormatFull(b *testing.B) {
+	for _, depth := range []int{1, 10, 100} {
+		err := deepChain(depth)
+		b.Run(fmt.Sprintf("depth=%d", depth), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = fmt.Sprintf("%+s", err)
+			}
+		})
+	}
+}
+
+func BenchmarkFormatBrief(b *testing.B) {
+	for _, depth := range []int{1, 10, 100} {
+		err := deepChain(depth)
+		b.Run(fmt.Sprintf("depth=%d", depth), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = fmt.Sprintf("%#s", err)
+			}
+		})
+	}
+}
+
+// TestFormatV1 locks the output of version 1 of the full and brief formats,
+// which must not change.
+func TestFormatV1(t *testing.T) {
+	assert.Equal(t, 1, stacktrace.FormatV1)
+
+	err := stacktrace.Propagate(func() error {
+		return stacktrace.PropagateWithCode(stacktrace.Propagate(errors.New("root\ncause"), "middle %d", 1), EcodeTimeIsIllusion, "")
+	}(), "outer")
+
+	full := `outer
+ --- at github.com/palantir/Stacktrace/format_test.go:# (TestFormatV1) ---
+ --- at github.com/palantir/Stacktrace/format_test.go:# (TestFormatV1.func1) ---
+Caused by: middle 1
+ --- at github.com/palantir/Stacktrace/format_test.go:# (TestFormatV1.func1) ---
+Caused by: root
+cause`
+	assert.Equal(t, full, normalizeLines(fmt.Sprintf("%+s", err)))
+	assert.Equal(t, "outer: middle 1: root\ncause", fmt.Sprintf("%#s", err))
+}
+
+func TestFormatTruncated(t *testing.T) {
+	defer func(format stacktrace.Format) { stacktrace.DefaultFormat = format }(stacktrace.DefaultFormat)
+	stacktrace.DefaultFormat = stacktrace.FormatFull
+
+	cyclic := stacktrace.NewError("inner").(*stacktrace.Stacktrace)
+	cyclic.Cause = stacktrace.Propagate(cyclic, "outer")
+	assert.Equal(t, "inner: outer: ... truncated", fmt.Sprintf("%#s", cyclic))
+	assert.Equal(t, strings.Join([]string{
+		"inner",
+		" --- at github.com/palantir/Stacktrace/format_test.go:# (TestFormatTruncated) ---",
+		"Caused by: outer",
+		" --- at github.com/palantir/Stacktrace/format_test.go:# (TestFormatTruncated) ---",
+		"... truncated",
+	}, "\n"), normalizeLines(cyclic.Error()))
+
+	defer func(depth int) { stacktrace.MaxChainDepth = depth }(stacktrace.MaxChainDepth)
+	stacktrace.MaxChainDepth = 1
+	err := stacktrace.Propagate(stacktrace.Propagate(errors.New("root"), "middle"), "top")
+	assert.Equal(t, "top: ... truncated", fmt.Sprintf("%#s", err))
+	stacktrace.MaxChainDepth = 2
+	assert.Equal(t, "top: middle: root", fmt.Sprintf("%#s", err))
+}
+
+func TestFormatCollapseDuplicateFrames(t *testing.T) {
+	defer func(collapse bool) { stacktrace.CollapseDuplicateFrames = collapse }(stacktrace.CollapseDuplicateFrames)
+
+	err := errors.New("root")
+	for i := 0; i < 3; i++ {
+		err = stacktrace.Propagate(err, "")
+	}
+	err = stacktrace.Propagate(err, "outer")
+
+	stacktrace.CollapseDuplicateFrames = false
+	assert.Equal(t, strings.Join([]string{
+		"outer",
+		" --- at github.com/palantir/Stacktrace/format_test.go:# (TestFormatCollapseDuplicateFrames) ---",
+		" --- at github.com/palantir/Stacktrace/format_test.go:# (TestFormatCollapseDuplicateFrames) ---",
+		" --- at github.com/palantir/Stacktrace/format_test.go:# (TestFormatCollapseDuplicateFrames) ---",
+		" --- at github.com/palantir/Stacktrace/format_test.go:# (TestFormatCollapseDuplicateFrames) ---",
+		"Caused by: root",
+	}, "\n"), normalizeLines(fmt.Sprintf("%+s", err)))
+
+	stacktrace.CollapseDuplicateFrames = true
+	assert.Equal(t, strings.Join([]string{
+		"outer",
+		" --- at github.com/palantir/Stacktrace/format_test.go:# (TestFormatCollapseDuplicateFrames) ---",
+		" --- at github.com/palantir/Stacktrace/format_test.go:# (TestFormatCollapseDuplicateFrames) --- (repeated 3 times)",
+		"Caused by: root",
+	}, "\n"), normalizeLines(fmt.Sprintf("%+s", err)))
+}
+
+var lineNumbers = regexp.MustCompile(`\.go:\d+`)
+
+// normalizeLines replaces line numbers in formatted output with "#" so tests
+// don't break whenever code moves around.
+func normalizeLines(s string) string {
+	return lineNumbers.ReplaceAllString(s, ".go:#")
+}
+
+func TestNewFormat(t *testing.T) {
+	defer func(format stacktrace.Format) { stacktrace.DefaultFormat = format }(stacktrace.DefaultFormat)
+
+	upper := stacktrace.NewFormat(stacktrace.FormatterFunc(func(st *stacktrace.Stacktrace) string {
+		return strings.ToUpper(st.Message)
+	}))
+	lower := stacktrace.NewFormat(stacktrace.FormatterFunc(func(st *stacktrace.Stacktrace) string {
+		return strings.ToLower(st.Message)
+	}))
+	assert.NotEqual(t, upper, lower)
+
+	err := stacktrace.Propagate(errors.New("plain"), "Decorated")
+	stacktrace.DefaultFormat = upper
+	assert.Equal(t, "DECORATED", err.Error())
+	assert.Equal(t, "DECORATED", fmt.Sprintf("%v", err))
+	assert.Equal(t, `"DECORATED"`, fmt.Sprintf("%q", err))
+	assert.Equal(t, "Decorated: plain", fmt.Sprintf("%#s", err))
+
+	stacktrace.DefaultFormat = lower
+	assert.Equal(t, "decorated", err.Error())
+
+	// unknown formats fall back to the full format
+	stacktrace.DefaultFormat = stacktrace.Format(-1)
+	assert.Equal(t, fmt.Sprintf("%+s", err), err.Error())
+}
+
+func TestFrameTemplate(t *testing.T) {
+	defer func(tmpl *template.Template) { stacktrace.FrameTemplate = tmpl }(stacktrace.FrameTemplate)
+
+	err := stacktrace.Propagate(errors.New("plain"), "decorated")
+	stacktrace.FrameTemplate = template.Must(template.New("frame").Parse("    at {{.Function}} ({{.File}}:{{.Line}})"))
+	assert.Equal(t, strings.Join([]string{
+		"decorated",
+		"    at TestFrameTemplate (github.com/palantir/Stacktrace/format_test.go:#)",
+		"Caused by: plain",
+	}, "\n"), normalizeLines(fmt.Sprintf("%+s", err)))
+
+	// fall back to the default when the template fails
+	stacktrace.FrameTemplate = template.Must(template.New("frame").Parse("{{.NoSuchField}}"))
+	assert.Equal(t, strings.Join([]string{
+		"decorated",
+		" --- at github.com/palantir/Stacktrace/format_test.go:# (TestFrameTemplate) ---",
+		"Caused by: plain",
+	}, "\n"), normalizeLines(fmt.Sprintf("%+s", err)))
+}
+
+func TestWrapWidth(t *testing.T) {
+	defer func(width int) { stacktrace.WrapWidth = width }(stacktrace.WrapWidth)
+
+	err := stacktrace.Propagate(stacktrace.NewError("the villain escaped through the sewers beneath the city"), "failed to capture")
+	stacktrace.WrapWidth = 30
+	expected := strings.Join([]string{
+		"failed to capture",
+		" --- at github.com/palantir/Stacktrace/format_test.go:# (TestWrapWidth) ---",
+		"Caused by: the villain escaped",
+		"    through the sewers beneath",
+		"    the city",
+		" --- at github.com/palantir/Stacktrace/format_test.go:# (TestWrapWidth) ---",
+	}, "\n")
+	assert.Equal(t, expected, normalizeLines(fmt.Sprintf("%+s", err)))
+
+	parsed, perr := stacktrace.Parse(fmt.Sprintf("%+s", err))
+	assert.NoError(t, perr)
+	assert.Equal(t, "the villain escaped\n    through the sewers beneath\n    the city", parsed.Cause.(*stacktrace.Stacktrace).Message)
+
+	// suppressed errors are wrapped within their indentation
+	withSuppressed := stacktrace.NewError("failed to capture").(*stacktrace.Stacktrace)
+	withSuppressed.Suppressed = []error{stacktrace.NewError("the villain escaped through the sewers")}
+	for _, line := range strings.Split(fmt.Sprintf("%+s", withSuppressed), "\n") {
+		if !strings.Contains(line, " --- at ") {
+			assert.LessOrEqual(t, len(line), 30, line)
+		}
+	}
+
+	stacktrace.WrapWidth = 0
+	assert.Contains(t, fmt.Sprintf("%+s", err), "Caused by: the villain escaped through the sewers beneath the city\n")
+}
+
+func TestFormatMultipleCauses(t *testing.T) {
+	first := stacktrace.Propagate(errors.New("connection refused"), "Failed to reach replica 1")
+	second := stacktrace.Propagate(stacktrace.NewError("Failed to write to replica 2"), "")
+	nested := errors.Join(errors.New("disk full"), errors.New("quota exceeded"))
+	err := stacktrace.PropagateAll([]error{first, second, nested}, "Failed to sync replicas")
+
+	assert.Equal(t, strings.Join([]string{
+		"Failed to sync replicas",
+		" --- at github.com/palantir/Stacktrace/format_test.go:# (TestFormatMultipleCauses) ---",
+		"Caused by (1 of 3): Failed to reach replica 1",
+		"     --- at github.com/palantir/Stacktrace/format_test.go:# (TestFormatMultipleCauses) ---",
+		"    Caused by: connection refused",
+		"Caused by (2 of 3):",
+		"     --- at github.com/palantir/Stacktrace/format_test.go:# (TestFormatMultipleCauses) ---",
+		"    Caused by: Failed to write to replica 2",
+		"     --- at github.com/palantir/Stacktrace/format_test.go:# (TestFormatMultipleCauses) ---",
+		"Caused by (3 of 3): Caused by (1 of 2): disk full",
+		"    Caused by (2 of 2): quota exceeded",
+	}, "\n"), normalizeLines(fmt.Sprintf("%+s", err)))
+	assert.Equal(t, "Failed to sync replicas: [Failed to reach replica 1: connection refused; Failed to write to replica 2; [disk full; quota exceeded]]", fmt.Sprintf("%#s", err))
+
+	// a cause shared by several branches is shown in each of them
+	err = stacktrace.PropagateAll([]error{first, first}, "twice")
+	assert.Equal(t, "twice: [Failed to reach replica 1: connection refused; Failed to reach replica 1: connection refused]", fmt.Sprintf("%#s", err))
+	assert.NotContains(t, fmt.Sprintf("%+s", err), "truncated")
+	assert.Equal(t, 2, strings.Count(fmt.Sprintf("%+s", err), "Failed to reach replica 1"))
+}
+
+func TestFormatBriefWithLocation(t *testing.T) {
+	defer func(format stacktrace.Format) { stacktrace.DefaultFormat = format }(stacktrace.DefaultFormat)
+	stacktrace.DefaultFormat = stacktrace.FormatBriefWithLocation
+
+	inner := stacktrace.NewError("inner")
+	err := stacktrace.Propagate(inner, "outer")
+	expected := fmt.Sprintf("outer: inner (%s:%d)", inner.(*stacktrace.Stacktrace).File, inner.(*stacktrace.Stacktrace).Line)
+	assert.Equal(t, expected, err.Error())
+
+	err = stacktrace.Propagate(stacktrace.NewMessageWithCode(EcodeNoSuchPseudo, "no location"), "outer")
+	assert.Equal(t, "outer: no location (github.com/palantir/Stacktrace/format_test.go:#)", normalizeLines(err.Error()))
+	assert.Equal(t, "no location", stacktrace.NewMessageWithCode(EcodeNoSuchPseudo, "no location").Error())
+}
+
+func TestFormatCode(t *testing.T) {
+	err := stacktrace.NewErrorWithCode(EcodeTimeIsIllusion, "msg")
+	assert.Equal(t, fmt.Sprint(int(EcodeTimeIsIllusion)), fmt.Sprintf("%d", err))
+	assert.Equal(t, fmt.Sprintf("%03d", int(EcodeTimeIsIllusion)), fmt.Sprintf("%03d", err))
+	assert.Equal(t, "code 65535", fmt.Sprintf("code %d", stacktrace.NewError("msg")))
+}
+
+func TestShowCodes(t *testing.T) {
+	defer func(show bool) { stacktrace.ShowCodes = show }(stacktrace.ShowCodes)
+	defer func(format stacktrace.Format) { stacktrace.DefaultFormat = format }(stacktrace.DefaultFormat)
+
+	err := stacktrace.NewErrorWithCode(EcodeNotFastEnough, "inner")
+	err = stacktrace.Propagate(err, "middle")
+	err = stacktrace.PropagateWithCode(err, EcodeTimeIsIllusion, "")
+	err = stacktrace.PropagateWithCode(err, EcodeTimeIsIllusion, "outer")
+
+	full := strings.Join([]string{
+		"outer",
+		" --- at github.com/palantir/Stacktrace/format_test.go:# (TestShowCodes) ---",
+		fmt.Sprintf(" --- at github.com/palantir/Stacktrace/format_test.go:# (TestShowCodes) --- [code=%d]", EcodeTimeIsIllusion),
+		"Caused by: middle",
+		" --- at github.com/palantir/Stacktrace/format_test.go:# (TestShowCodes) ---",
+		fmt.Sprintf("Caused by: inner [code=%d]", EcodeNotFastEnough),
+		" --- at github.com/palantir/Stacktrace/format_test.go:# (TestShowCodes) ---",
+	}, "\n")
+	brief := fmt.Sprintf("outer: [code=%d]: middle: inner [code=%d]", EcodeTimeIsIllusion, EcodeNotFastEnough)
+
+	stacktrace.ShowCodes = false
+	stacktrace.DefaultFormat = stacktrace.FormatFull
+	assert.Equal(t, full, normalizeLines(fmt.Sprintf("% s", err)))
+	assert.Equal(t, full, normalizeLines(fmt.Sprintf("% +s", err)))
+	assert.Equal(t, brief, fmt.Sprintf("% #s", err))
+	assert.Equal(t, "outer: middle: inner", fmt.Sprintf("%#s", err))
+	stacktrace.DefaultFormat = stacktrace.FormatBrief
+	assert.Equal(t, brief, fmt.Sprintf("% v", err))
+
+	stacktrace.ShowCodes = true
+	assert.Equal(t, brief, err.Error())
+	assert.Equal(t, full, normalizeLines(fmt.Sprintf("%+s", err)))
+}
+
+type panickyError struct{}
+
+func (*panickyError) Error() string { panic("broken error") }
+
+func TestFormatPanics(t *testing.T) {
+	err := stacktrace.Propagate(&panickyError{}, "msg")
+	assert.Equal(t, "<error while formatting: broken error>", err.Error())
+	assert.Equal(t, "<error while formatting: broken error>", fmt.Sprintf("%#s", err))
+
+	var buf strings.Builder
+	stacktrace.Log(stacktrace.LoggerFunc(func(level stacktrace.Level, msg string, fields map[string]interface{}) {
+		buf.WriteString(msg)
+	}), err)
+	assert.Equal(t, "<error while formatting: broken error>", buf.String())
+
+	var nilStacktrace *stacktrace.Stacktrace
+	assert.Equal(t, "<nil>", fmt.Sprint(nilStacktrace))
+	assert.Equal(t, "<nil>", fmt.Sprintf("%d", nilStacktrace))
+	err = stacktrace.Propagate(nilStacktrace, "msg")
+	assert.Equal(t, "msg: <nil>", fmt.Sprintf("%#s", err))
+	assert.Equal(t, "msg\n --- at github.com/palantir/Stacktrace/format_test.go:# (TestFormatPanics) ---\nCaused by: <nil>", normalizeLines(fmt.Sprintf("%+s", err)))
+}
+
+func TestGroupFramesByPackage(t *testing.T) {
+	defer func(group bool) { stacktrace.GroupFramesByPackage = group }(stacktrace.GroupFramesByPackage)
+	stacktrace.GroupFramesByPackage = true
+
+	err := &stacktrace.Stacktrace{
+		Message:  "failed to handle request",
+		File:     "github.com/palantir/shield/server.go",
+		Line:     61,
+		Function: "Server.handle",
+		Stack: []stacktrace.Frame{
+			{File: "github.com/palantir/shield/server/server.go", Line: 61, Function: "Server.handle"},
+			{File: "github.com/palantir/shield/server/middleware.go", Line: 25, Function: "withAuth.func1"},
+			{File: "github.com/palantir/shield/main.go", Line: 17, Function: "main"},
+			{File: "net/http/server.go", Line: 2166, Function: "HandlerFunc.ServeHTTP"},
+			{File: "net/http/server.go", Line: 3210, Function: "serverHandler.ServeHTTP"},
+		},
+	}
+	text := fmt.Sprintf("%+s", err)
+	assert.Equal(t, strings.Join([]string{
+		"failed to handle request",
+		" --- at github.com/palantir/shield/server.go:61 (Server.handle) ---",
+		"     in github.com/palantir/shield/server:",
+		"         server.go:61 (Server.handle)",
+		"         middleware.go:25 (withAuth.func1)",
+		"     at github.com/palantir/shield/main.go:17 (main)",
+		"     in net/http:",
+		"         server.go:2166 (HandlerFunc.ServeHTTP)",
+		"         server.go:3210 (serverHandler.ServeHTTP)",
+	}, "\n"), text)
+
+	parsed, perr := stacktrace.Parse(text)
+	if assert.NoError(t, perr) {
+		assert.Equal(t, err.Stack, parsed.Stack)
+	}
+
+	highlighted := stacktrace.Sprint(err, stacktrace.PrintFull(), stacktrace.PrintHighlightModule("net/http/"))
+	assert.Contains(t, highlighted, "     in net/http:\n       * server.go:2166 (HandlerFunc.ServeHTTP)\n")
+	parsed, perr = stacktrace.Parse(highlighted)
+	if assert.NoError(t, perr) {
+		assert.Equal(t, err.Stack, parsed.Stack)
+	}
+
+	assert.NotContains(t, stacktrace.Sprint(err, stacktrace.PrintGroupByPackage(false)), " in ")
+}
@@ -0,0 +1,136 @@
+package stacktrace
+
+import (
+	"runtime"
+	"strings"
+)
+
+/*
+CaptureStack controls whether errors created by NewError, Propagate and friends
+also record the callers of their call site, not just the call site itself. It is
+disabled by default because the intent of this package is to keep stack traces
+compact; enable it when the extra context is worth the noise:
+
+	stacktrace.CaptureStack = true
+
+Captured callers are stored in Stacktrace.Stack, up to MaxFrames of them, and
+rendered in the full format beneath the call site, Java style. Callers that an
+error shares with the error it caused are elided as "... N more".
+*/
+var CaptureStack = false
+
+/*
+FrameFilter, if not nil, is called on each caller captured with CaptureStack,
+after CleanPath, and the callers for which it returns false are left out of the
+Stack. It keeps traces focused on application code, for example with:
+
+	Stacktrace.FrameFilter = Stacktrace.IsApplicationFrame
+
+The call site of each level is always kept. Since callers are dropped before the
+Stack is stored, the filter also applies to encoded and exported errors.
+*/
+var FrameFilter func(Frame) bool
+
+/*
+MaxFrames limits the number of callers recorded in the Stack of each error when
+CaptureStack is enabled. The callers beyond the limit are counted but not
+resolved to a File and Function, which is the expensive part of capturing a
+stack, so the FrameFilter does not apply to them. Their number is kept in
+OmittedFrames and noted in the full format as "(+N frames omitted)", like the
+frames left out for MaxFormatSize. Zero or a negative value removes the limit.
+
+MaxFrames applies to the whole program: the codes of a CodeSpace or
+CategorySpace have no limit of their own.
+*/
+var MaxFrames = 32
+
+// Frame is a single location in a Stacktrace: the call site of NewError,
+// Propagate and friends, or one of its callers captured with CaptureStack.
+type Frame struct {
+	File     string
+	Function string
+	Line     int
+}
+
+// captureStack returns up to MaxFrames frames of the calling goroutine's stack,
+// starting skip frames above the caller of captureStack, and the number of
+// frames beyond them, which are not resolved.
+func captureStack(skip int) (stack []Frame, omitted int) {
+	var pcs []uintptr
+	for chunk := make([]uintptr, 64); ; {
+		// +2 to skip runtime.Callers and captureStack itself.
+		n := runtime.Callers(skip+2+len(pcs), chunk)
+		pcs = append(pcs, chunk[:n]...)
+		if n < len(chunk) {
+			break
+		}
+	}
+	if len(pcs) == 0 {
+		return nil, 0
+	}
+
+	frames := runtime.CallersFrames(pcs)
+	// runtime.Callers returns one PC per frame, including inlined ones
+	for resolved := 1; ; resolved++ {
+		frame, more := frames.Next()
+		file := frame.File
+		if CleanPath != nil {
+			file = CleanPath(file)
+		}
+		f := Frame{
+			File:     file,
+			Function: shortFuncName(frame.Function),
+			Line:     frame.Line,
+		}
+		if FrameFilter == nil || FrameFilter(f) {
+			stack = append(stack, f)
+		}
+		if !more {
+			return stack, 0
+		}
+		if MaxFrames > 0 && len(stack) == MaxFrames {
+			return stack, len(pcs) - resolved
+		}
+	}
+}
+
+// IsRuntimeFrame reports whether f is in the runtime package, such as the
+// runtime.main and runtime.goexit frames at the bottom of every stack.
+func IsRuntimeFrame(f Frame) bool {
+	return strings.HasPrefix(f.File, "runtime/") || strings.Contains(f.File, "/src/runtime/")
+}
+
+// IsVendoredFrame reports whether f is in a vendor directory.
+func IsVendoredFrame(f Frame) bool {
+	return strings.HasPrefix(f.File, "vendor/") || strings.Contains(f.File, "/vendor/")
+}
+
+/*
+IsGeneratedFrame reports whether f is in a File that is conventionally
+generated, such as protobuf and gRPC stubs ("*.pb.go"), mocks and the output of
+stringer and similar tools ("*_gen.go", "*_string.go", "zz_generated*.go").
+*/
+func IsGeneratedFrame(f Frame) bool {
+	name := f.File[strings.LastIndexByte(f.File, '/')+1:]
+	return strings.HasSuffix(name, ".pb.go") ||
+		strings.HasSuffix(name, ".pb.gw.go") ||
+		strings.HasSuffix(name, "_gen.go") ||
+		strings.HasSuffix(name, "_string.go") ||
+		strings.HasPrefix(name, "zz_generated")
+}
+
+// IsApplicationFrame reports whether f is not a runtime, vendored or generated
+// frame. It is meant to be used as FrameFilter.
+func IsApplicationFrame(f Frame) bool {
+	return !IsRuntimeFrame(f) && !IsVendoredFrame(f) && !IsGeneratedFrame(f)
+}
+
+// commonSuffix returns the number of frames at the end of inner that are
+// identical to the frames at the end of outer.
+func commonSuffix(inner, outer []Frame) int {
+	n := 0
+	for n < len(inner) && n < len(outer) && inner[len(inner)-1-n] == outer[len(outer)-1-n] {
+		n++
+	}
+	return n
+}
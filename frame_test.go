@@ -0,0 +1,117 @@
+package stacktrace_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func innerCall() error {
+	return stacktrace.NewError("inner")
+}
+
+func outerCall() error {
+	return stacktrace.Propagate(innerCall(), "outer")
+}
+
+func TestCaptureStack(t *testing.T) {
+	defer func(capture bool) { stacktrace.CaptureStack = capture }(stacktrace.CaptureStack)
+
+	stacktrace.CaptureStack = false
+	assert.Empty(t, outerCall().(*stacktrace.Stacktrace).Stack)
+
+	stacktrace.CaptureStack = true
+	err := outerCall()
+	outer := err.(*stacktrace.Stacktrace)
+	inner := outer.Cause.(*stacktrace.Stacktrace)
+	if assert.NotEmpty(t, outer.Stack) && assert.NotEmpty(t, inner.Stack) {
+		assert.Equal(t, "TestCaptureStack", outer.Stack[0].Function)
+		assert.Equal(t, "github.com/palantir/Stacktrace/frame_test.go", outer.Stack[0].File)
+		assert.Equal(t, "outerCall", inner.Stack[0].Function)
+		assert.Equal(t, outer.Stack, inner.Stack[1:])
+	}
+
+	expectedSuffix := strings.Join([]string{
+		"Caused by: inner",
+		" --- at github.com/palantir/Stacktrace/frame_test.go:# (innerCall) ---",
+		"     at github.com/palantir/Stacktrace/frame_test.go:# (outerCall)",
+		fmt.Sprintf("     ... %d more", len(outer.Stack)),
+	}, "\n")
+	assert.True(t, strings.HasSuffix(normalizeLines(fmt.Sprintf("%+s", err)), expectedSuffix))
+	assert.Equal(t, "outer: inner", fmt.Sprintf("%#s", err))
+}
+
+func TestFrameFilter(t *testing.T) {
+	defer func(capture bool) { stacktrace.CaptureStack = capture }(stacktrace.CaptureStack)
+	defer func(filter func(stacktrace.Frame) bool) { stacktrace.FrameFilter = filter }(stacktrace.FrameFilter)
+	stacktrace.CaptureStack = true
+
+	stacktrace.FrameFilter = nil
+	unfiltered := outerCall().(*stacktrace.Stacktrace).Stack
+	assert.True(t, stacktrace.IsRuntimeFrame(unfiltered[len(unfiltered)-1]), unfiltered[len(unfiltered)-1].File)
+
+	stacktrace.FrameFilter = stacktrace.IsApplicationFrame
+	filtered := outerCall().(*stacktrace.Stacktrace)
+	for _, frame := range filtered.Stack {
+		assert.False(t, stacktrace.IsRuntimeFrame(frame), frame.File)
+	}
+	assert.Equal(t, "TestFrameFilter", filtered.Stack[0].Function)
+	assert.Less(t, len(filtered.Stack), len(unfiltered))
+
+	stacktrace.FrameFilter = func(frame stacktrace.Frame) bool { return frame.Function != "outerCall" }
+	outer := outerCall().(*stacktrace.Stacktrace)
+	assert.Equal(t, "TestFrameFilter", outer.Cause.(*stacktrace.Stacktrace).Stack[0].Function)
+	assert.Equal(t, "outerCall", outer.Function, "call sites are kept")
+
+	for file, generated := range map[string]bool{
+		"github.com/palantir/shield/api/shield.pb.go":    true,
+		"github.com/palantir/shield/api/color_string.go": true,
+		"k8s.io/api/core/v1/zz_generated.deepcopy.go":    true,
+		"github.com/palantir/shield/api/shield.go":       false,
+	} {
+		assert.Equal(t, generated, stacktrace.IsGeneratedFrame(stacktrace.Frame{File: file}), file)
+	}
+	assert.True(t, stacktrace.IsVendoredFrame(stacktrace.Frame{File: "github.com/palantir/shield/vendor/golang.org/x/sync/errgroup/errgroup.go"}))
+	assert.False(t, stacktrace.IsApplicationFrame(stacktrace.Frame{File: "runtime/proc.go"}))
+}
+
+func recurse(depth int) error {
+	if depth == 0 {
+		return stacktrace.NewError("bottom")
+	}
+	return recurse(depth - 1)
+}
+
+func TestMaxFrames(t *testing.T) {
+	defer func(capture bool) { stacktrace.CaptureStack = capture }(stacktrace.CaptureStack)
+	defer func(max int) { stacktrace.MaxFrames = max }(stacktrace.MaxFrames)
+	stacktrace.CaptureStack = true
+
+	stacktrace.MaxFrames = 0
+	unlimited := recurse(100).(*stacktrace.Stacktrace)
+	assert.Greater(t, len(unlimited.Stack), 100)
+	assert.Zero(t, unlimited.OmittedFrames)
+
+	stacktrace.MaxFrames = 5
+	limited := recurse(100).(*stacktrace.Stacktrace)
+	assert.Equal(t, unlimited.Stack[:5], limited.Stack)
+	assert.Equal(t, len(unlimited.Stack)-5, limited.OmittedFrames)
+
+	text := fmt.Sprintf("%+s", limited)
+	assert.Contains(t, text, fmt.Sprintf("\n     (+%d frames omitted)", limited.OmittedFrames))
+	parsed, err := stacktrace.Parse(text)
+	if assert.NoError(t, err) {
+		assert.Equal(t, limited.Stack, parsed.Stack)
+		assert.Equal(t, limited.OmittedFrames, parsed.OmittedFrames)
+	}
+
+	var decoded stacktrace.Stacktrace
+	data, err := limited.MarshalBinary()
+	assert.NoError(t, err)
+	assert.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, limited.OmittedFrames, decoded.OmittedFrames)
+}
@@ -0,0 +1,197 @@
+package stacktrace
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"maps"
+	"time"
+)
+
+func init() {
+	// Allow Stacktrace errors in interface-typed fields of gob values, such as
+	// the error of a net/rpc reply.
+	gob.Register(&Stacktrace{})
+}
+
+// wireError is the encoded form of an error in a chain: either a run of
+// Stacktrace levels followed by their Cause, several causes, or the text of
+// any other error.
+type wireError struct {
+	Levels   []wireLevel
+	Cause    *wireError
+	Branches []wireError
+	Text     string
+}
+
+// wireLevel is the encoded form of a single Stacktrace level.
+type wireLevel struct {
+	Message    string
+	Code       ErrorCode
+	File       string
+	Function   string
+	Line       int
+	Stack      []Frame
+	Omitted    int
+	Suppressed []wireError
+	StringCode string
+	ID         string
+	TraceID    string
+	SpanID     string
+	Time       time.Time
+	Process    *ProcessInfo
+	Build      *BuildInfo
+	Retryable  *bool
+	RetryAt    time.Time
+	Severity   Severity
+	// Format is only set for messages without arguments, which are not kept.
+	Format      string
+	ExitStatus  int
+	RemoteCause bool
+	Preferred   *Format
+	Goroutines  string
+}
+
+/*
+MarshalBinary implements encoding.BinaryMarshaler, so that errors can cross
+process boundaries, for example through net/rpc, or be stored with a queued job,
+and be reconstructed by UnmarshalBinary with their chain, codes and frames
+intact. It is also used by encoding/gob.
+
+Causes that are not Stacktraces keep only their text, and lazy messages are
+produced first. The arguments of the Message are not kept, so the format string
+is only kept for messages without arguments, and Template and Localize treat the
+Message of a decoded error with arguments as a literal one. All other fields,
+including the marks of WithFormat and Graft and the dump of WithGoroutineDump,
+are kept.
+*/
+func (st *Stacktrace) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(toWire(st, make(map[*Stacktrace]bool))); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, reconstructing an
+// error encoded by MarshalBinary into st.
+func (st *Stacktrace) UnmarshalBinary(data []byte) error {
+	var w wireError
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&w); err != nil {
+		return err
+	}
+	decoded, ok := fromWire(w).(*Stacktrace)
+	if !ok {
+		return errors.New("stacktrace: encoded error is not a Stacktrace")
+	}
+	*st = *decoded
+	return nil
+}
+
+func toWire(err error, seen map[*Stacktrace]bool) wireError {
+	if st, ok := err.(*Stacktrace); ok && st != nil {
+		levels, truncated := chainFrom(st, seen)
+		if len(levels) == 0 {
+			return wireError{Text: truncatedMarker}
+		}
+		var w wireError
+		for _, curr := range levels {
+			level := wireLevel{
+				Message:     curr.message(),
+				Code:        curr.Code,
+				File:        curr.File,
+				Function:    curr.Function,
+				Line:        curr.Line,
+				Stack:       curr.Stack,
+				Omitted:     curr.OmittedFrames,
+				StringCode:  curr.StringCode,
+				ID:          curr.ID,
+				TraceID:     curr.TraceID,
+				SpanID:      curr.SpanID,
+				Time:        curr.Time,
+				Process:     curr.Process,
+				Build:       curr.Build,
+				Retryable:   curr.Retryable,
+				RetryAt:     curr.RetryAt,
+				Severity:    curr.Severity,
+				ExitStatus:  curr.exitStatus,
+				RemoteCause: curr.remoteCause,
+				Preferred:   curr.preferred,
+				Goroutines:  curr.goroutines,
+			}
+			if len(curr.args) == 0 && !curr.argsDropped {
+				level.Format = curr.format
+			}
+			for _, suppressed := range curr.Suppressed {
+				level.Suppressed = append(level.Suppressed, toWire(suppressed, maps.Clone(seen)))
+			}
+			w.Levels = append(w.Levels, level)
+		}
+		if last := levels[len(levels)-1]; truncated {
+			w.Cause = &wireError{Text: truncatedMarker}
+		} else if last.Cause != nil {
+			cause := toWire(last.Cause, seen)
+			w.Cause = &cause
+		}
+		return w
+	}
+	if causes, ok := branchesOf(err); ok {
+		w := wireError{Branches: make([]wireError, len(causes))}
+		for i, cause := range causes {
+			w.Branches[i] = toWire(cause, maps.Clone(seen))
+		}
+		return w
+	}
+	return wireError{Text: err.Error()}
+}
+
+func fromWire(w wireError) error {
+	if len(w.Levels) == 0 {
+		if w.Branches != nil {
+			causes := make([]error, len(w.Branches))
+			for i, branch := range w.Branches {
+				causes[i] = fromWire(branch)
+			}
+			return errors.Join(causes...)
+		}
+		return errors.New(w.Text)
+	}
+
+	var cause error
+	if w.Cause != nil {
+		cause = fromWire(*w.Cause)
+	}
+	for i := len(w.Levels) - 1; i >= 0; i-- {
+		level := w.Levels[i]
+		st := &Stacktrace{
+			Message:       level.Message,
+			Cause:         cause,
+			Code:          level.Code,
+			File:          level.File,
+			Function:      level.Function,
+			Line:          level.Line,
+			Stack:         level.Stack,
+			OmittedFrames: level.Omitted,
+			StringCode:    level.StringCode,
+			ID:            level.ID,
+			TraceID:       level.TraceID,
+			SpanID:        level.SpanID,
+			Time:          level.Time,
+			Process:       level.Process,
+			Build:         level.Build,
+			Retryable:     level.Retryable,
+			RetryAt:       level.RetryAt,
+			Severity:      level.Severity,
+			format:        level.Format,
+			exitStatus:    level.ExitStatus,
+			remoteCause:   level.RemoteCause,
+			preferred:     level.Preferred,
+			goroutines:    level.Goroutines,
+		}
+		for _, suppressed := range level.Suppressed {
+			st.Suppressed = append(st.Suppressed, fromWire(suppressed))
+		}
+		cause = st
+	}
+	return cause
+}
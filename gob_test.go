@@ -0,0 +1,95 @@
+package stacktrace_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestMarshalBinary(t *testing.T) {
+	defer func(capture bool) { stacktrace.CaptureStack = capture }(stacktrace.CaptureStack)
+	stacktrace.CaptureStack = true
+
+	err := stacktrace.NewErrorWithSeverity(stacktrace.SeverityWarning, "inner %d", 1)
+	err = stacktrace.AddSuppressed(err, stacktrace.NewErrorWithStringCode("CLEANUP", "cleanup failed"))
+	err = stacktrace.PropagateAll([]error{err, errors.New("plain")}, "middle")
+	err = stacktrace.MarkRetryable(err)
+	err = stacktrace.PropagateWithCode(err, EcodeNotFastEnough, "outer")
+
+	data, merr := err.(*stacktrace.Stacktrace).MarshalBinary()
+	require.NoError(t, merr)
+	decoded := new(stacktrace.Stacktrace)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+
+	assert.Equal(t, fmt.Sprintf("%+s", err), fmt.Sprintf("%+s", decoded))
+	assert.Equal(t, fmt.Sprintf("%#s", err), fmt.Sprintf("%#s", decoded))
+	assert.Equal(t, EcodeNotFastEnough, stacktrace.GetCode(decoded))
+	assert.Equal(t, stacktrace.SeverityWarning, stacktrace.GetSeverity(decoded))
+	assert.True(t, stacktrace.IsRetryable(decoded))
+	assert.Equal(t, err.(*stacktrace.Stacktrace).Stack, decoded.Stack)
+	assert.NotEmpty(t, decoded.Stack)
+	format, _ := stacktrace.Template(decoded)
+	assert.Equal(t, "outer", format)
+
+	assert.Error(t, new(stacktrace.Stacktrace).UnmarshalBinary([]byte("garbage")))
+}
+
+func TestMarshalBinaryMarks(t *testing.T) {
+	remote := stacktrace.NewError("Failed to load invoice %d", 7)
+	err := stacktrace.Graft(stacktrace.NewError("Failed to call billing"), remote)
+	err = stacktrace.WithGoroutineDump(stacktrace.WithFormat(err, stacktrace.FormatBrief))
+
+	data, merr := err.(*stacktrace.Stacktrace).MarshalBinary()
+	require.NoError(t, merr)
+	decoded := new(stacktrace.Stacktrace)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+
+	assert.Equal(t, err.Error(), decoded.Error())
+	assert.Equal(t, fmt.Sprintf("%+s", err), fmt.Sprintf("%+s", decoded))
+	assert.Contains(t, fmt.Sprintf("%+s", decoded), "remote boundary")
+	assert.Equal(t, stacktrace.GoroutineDump(err), stacktrace.GoroutineDump(decoded))
+
+	// the format of a Message with arguments is dropped along with them
+	var ids []string
+	catalog := func(id string, args []interface{}) (string, bool) {
+		ids = append(ids, id)
+		return "", false
+	}
+	assert.Equal(t, "Failed to call billing: Failed to load invoice 7", stacktrace.Localize(decoded, catalog))
+	assert.Equal(t, []string{"Failed to call billing"}, ids)
+}
+
+func TestGob(t *testing.T) {
+	type reply struct {
+		Err error
+	}
+
+	err := stacktrace.Propagate(stacktrace.NewErrorWithCode(EcodeTimeIsIllusion, "inner"), "outer")
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(reply{Err: err}))
+	var decoded reply
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+
+	assert.IsType(t, &stacktrace.Stacktrace{}, decoded.Err)
+	assert.Equal(t, normalizeLines(err.Error()), normalizeLines(decoded.Err.Error()))
+	assert.Equal(t, EcodeTimeIsIllusion, stacktrace.GetCode(decoded.Err))
+}
+
+func TestMarshalBinaryCycle(t *testing.T) {
+	inner := stacktrace.NewError("inner").(*stacktrace.Stacktrace)
+	outer := stacktrace.Propagate(inner, "outer").(*stacktrace.Stacktrace)
+	inner.Cause = outer
+
+	data, err := outer.MarshalBinary()
+	require.NoError(t, err)
+	decoded := new(stacktrace.Stacktrace)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, fmt.Sprintf("%#s", outer), fmt.Sprintf("%#s", decoded))
+}
@@ -0,0 +1,74 @@
+package stacktrace
+
+import (
+	"runtime"
+	"strings"
+)
+
+// goroutineDumpHeader precedes the dump attached by WithGoroutineDump in the
+// output of "%+s".
+const goroutineDumpHeader = "Goroutine dump:"
+
+/*
+MaxGoroutineDumpSize is the number of bytes of the dump of all goroutines that
+WithGoroutineDump keeps. Longer dumps end with "... truncated" after the last
+line that fits.
+*/
+var MaxGoroutineDumpSize = 64 << 10
+
+/*
+WithGoroutineDump attaches the stacks of all goroutines, as written by
+runtime.Stack, to err, for diagnosing deadlocks and leaks where the stack of a
+single goroutine is not enough:
+
+	select {
+	case <-done:
+	case <-time.After(time.Minute):
+		return Stacktrace.WithGoroutineDump(Stacktrace.NewError("Timed out waiting for workers"))
+	}
+
+The dump is added as a new Stacktrace level with an empty Message, and is only
+shown by "%+s", after the full format, so that logs are not flooded by errors
+printed in the usual ways. Use GoroutineDump to retrieve it. Taking the dump
+stops the world, so avoid it on hot paths. WithGoroutineDump returns nil if err
+is nil.
+*/
+func WithGoroutineDump(err error) error {
+	if err == nil {
+		return nil
+	}
+	dump := dumpGoroutines(MaxGoroutineDumpSize)
+	return createWith(err, NoCode, func(st *Stacktrace) { st.goroutines = dump }, "")
+}
+
+/*
+GoroutineDump returns the dump attached by WithGoroutineDump to the outermost
+error in the chain of err that has one, or "" if there is none. See Walk for
+which errors are part of the chain.
+*/
+func GoroutineDump(err error) string {
+	var dump string
+	Walk(err, func(err error) bool {
+		if st, ok := err.(*Stacktrace); ok && st != nil {
+			dump = st.goroutines
+		}
+		return dump == ""
+	})
+	return dump
+}
+
+// dumpGoroutines returns the stacks of all goroutines, cut after the last line
+// that fits in size bytes.
+func dumpGoroutines(size int) string {
+	buf := make([]byte, size+1)
+	n := runtime.Stack(buf, true)
+	if n <= size {
+		return strings.TrimRight(string(buf[:n]), "\n")
+	}
+	const marker = "\n" + truncatedMarker
+	cut := strings.LastIndexByte(string(buf[:max(size-len(marker)+1, 0)]), '\n')
+	if cut < 0 {
+		return truncatedMarker
+	}
+	return string(buf[:cut]) + marker
+}
@@ -0,0 +1,55 @@
+package stacktrace_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestWithGoroutineDump(t *testing.T) {
+	blocked := make(chan struct{})
+	defer close(blocked)
+	go func() { <-blocked }()
+
+	err := stacktrace.WithGoroutineDump(stacktrace.NewError("timed out waiting for workers"))
+	err = stacktrace.Propagate(err, "failed to shut down")
+
+	dump := stacktrace.GoroutineDump(err)
+	assert.True(t, strings.HasPrefix(dump, "goroutine "), dump)
+	assert.Contains(t, dump, "TestWithGoroutineDump.func1")
+
+	full := fmt.Sprintf("%+s", err)
+	assert.Contains(t, full, "\nGoroutine dump:\ngoroutine ")
+	assert.NotContains(t, fmt.Sprintf("%+v", err), "Goroutine dump:")
+	assert.NotContains(t, err.Error(), "Goroutine dump:")
+
+	parsed, perr := stacktrace.Parse(full)
+	require.NoError(t, perr)
+	assert.Equal(t, "failed to shut down: timed out waiting for workers", fmt.Sprintf("%#s", parsed))
+
+	// a Message with the text of the header is not taken for a dump
+	parsed, perr = stacktrace.Parse(fmt.Sprintf("%+s", stacktrace.NewError("Goroutine dump:")))
+	require.NoError(t, perr)
+	assert.Equal(t, "Goroutine dump:", parsed.Message)
+	_, perr = stacktrace.Parse("Goroutine dump:")
+	assert.Error(t, perr)
+	_, perr = stacktrace.Parse("Goroutine dump:\ngoroutine 1 [running]:")
+	assert.Error(t, perr)
+
+	assert.Equal(t, "", stacktrace.GoroutineDump(stacktrace.NewError("no dump")))
+	assert.Nil(t, stacktrace.WithGoroutineDump(nil))
+}
+
+func TestMaxGoroutineDumpSize(t *testing.T) {
+	defer func(size int) { stacktrace.MaxGoroutineDumpSize = size }(stacktrace.MaxGoroutineDumpSize)
+	stacktrace.MaxGoroutineDumpSize = 200
+
+	dump := stacktrace.GoroutineDump(stacktrace.WithGoroutineDump(stacktrace.NewError("timed out")))
+	assert.LessOrEqual(t, len(dump), 200)
+	assert.True(t, strings.HasSuffix(dump, "\n... truncated"), dump)
+}
@@ -0,0 +1,70 @@
+package stacktrace
+
+// remoteBoundary is the line of the full format between the local levels of a
+// chain merged by Graft and the remote ones.
+const remoteBoundary = " --- remote boundary ---"
+
+/*
+Graft merges a chain received from another process, for example decoded with
+UnmarshalBinary, into the local chain of the call that received it:
+
+	resp, err := client.Call(req)
+	if err != nil {
+		return Stacktrace.Graft(Stacktrace.Propagate(err, "Failed to call billing"), remoteErr)
+	}
+
+The result is a copy of local whose innermost level has remote as its Cause.
+The Cause that level had before, usually the error through which the remote
+chain arrived, is kept among its suppressed errors. The full format marks where
+the remote levels start with a line
+
+	--- remote boundary ---
+
+Levels of local without a Code, StringCode, Severity, retryable mark or time to
+retry of their own take the ones of remote. If local
+is not a Stacktrace, Graft returns a new error with the text of local as its
+Message and Line number information for the call to Graft. If either error is
+nil, Graft returns the other.
+*/
+func Graft(local, remote error) error {
+	if remote == nil {
+		return local
+	}
+	if local == nil {
+		return remote
+	}
+	st, ok := local.(*Stacktrace)
+	if !ok || st == nil {
+		return createWith(remote, NoCode, func(st *Stacktrace) {
+			literalMessage(local.Error())(st)
+			st.remoteCause = true
+		}, "")
+	}
+
+	levels, _ := chainFrom(Clone(st).(*Stacktrace), make(map[*Stacktrace]bool))
+	innermost := levels[len(levels)-1]
+	if innermost.Cause != nil {
+		innermost.Suppressed = append(innermost.Suppressed, innermost.Cause)
+	}
+	innermost.Cause = remote
+	innermost.remoteCause = true
+	for i := len(levels) - 1; i >= 0; i-- {
+		curr := levels[i]
+		if curr.Code == NoCode {
+			curr.Code = GetCode(curr.Cause)
+		}
+		if curr.StringCode == "" {
+			curr.StringCode = GetStringCode(curr.Cause)
+		}
+		if curr.Severity == SeverityUnset {
+			curr.Severity = GetSeverity(curr.Cause)
+		}
+		if curr.Retryable == nil {
+			curr.Retryable = retryableOf(curr.Cause)
+		}
+		if curr.RetryAt.IsZero() {
+			curr.RetryAt = retryAtOf(curr.Cause)
+		}
+	}
+	return levels[0]
+}
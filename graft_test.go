@@ -0,0 +1,77 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestGraft(t *testing.T) {
+	remote := stacktrace.PropagateWithCode(errors.New("disk full"), EcodeNotFastEnough, "Failed to write")
+	data, err := remote.(*stacktrace.Stacktrace).MarshalBinary()
+	require.NoError(t, err)
+	decoded := new(stacktrace.Stacktrace)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+
+	transport := errors.New("remote call failed")
+	local := stacktrace.Propagate(transport, "Failed to call billing")
+	merged := stacktrace.Graft(local, decoded)
+
+	assert.Equal(t, strings.Join([]string{
+		"Failed to call billing",
+		" --- at github.com/palantir/Stacktrace/graft_test.go:# (TestGraft) ---",
+		"    Suppressed: remote call failed",
+		" --- remote boundary ---",
+		"Caused by: Failed to write",
+		" --- at github.com/palantir/Stacktrace/graft_test.go:# (TestGraft) ---",
+		"Caused by: disk full",
+	}, "\n"), normalizeLines(fmt.Sprintf("%+s", merged)))
+	assert.Equal(t, "Failed to call billing: Failed to write: disk full", fmt.Sprintf("%#s", merged))
+	assert.Equal(t, EcodeNotFastEnough, stacktrace.GetCode(merged))
+
+	// local is left untouched
+	assert.Equal(t, "Failed to call billing: remote call failed", fmt.Sprintf("%#s", local))
+	assert.Equal(t, stacktrace.NoCode, stacktrace.GetCode(local))
+
+	// the boundary survives parsing
+	parsed, err := stacktrace.Parse(fmt.Sprintf("%+s", merged))
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%+s", merged), fmt.Sprintf("%+s", parsed))
+}
+
+func TestGraftInherits(t *testing.T) {
+	remote := stacktrace.NewErrorWithStringCode("QUOTA", "Over quota")
+	remote = stacktrace.PropagateWithSeverity(remote, stacktrace.SeverityWarning, "")
+	remote = stacktrace.MarkRetryable(remote)
+	merged := stacktrace.Graft(stacktrace.NewError("Failed to call billing"), remote)
+
+	assert.Equal(t, "QUOTA", stacktrace.GetStringCode(merged))
+	assert.Equal(t, stacktrace.SeverityWarning, stacktrace.GetSeverity(merged))
+	assert.True(t, stacktrace.IsRetryable(merged))
+
+	// the marks of local win
+	local := stacktrace.NewErrorWithSeverity(stacktrace.SeverityCritical, "Failed to call billing")
+	assert.Equal(t, stacktrace.SeverityCritical, stacktrace.GetSeverity(stacktrace.Graft(local, remote)))
+}
+
+func TestGraftPlain(t *testing.T) {
+	remote := stacktrace.NewError("Failed remotely")
+	merged := stacktrace.Graft(errors.New("call failed"), remote)
+	assert.Equal(t, strings.Join([]string{
+		"call failed",
+		" --- at github.com/palantir/Stacktrace/graft_test.go:# (TestGraftPlain) ---",
+		" --- remote boundary ---",
+		"Caused by: Failed remotely",
+		" --- at github.com/palantir/Stacktrace/graft_test.go:# (TestGraftPlain) ---",
+	}, "\n"), normalizeLines(fmt.Sprintf("%+s", merged)))
+
+	assert.Equal(t, remote, stacktrace.Graft(nil, remote))
+	local := stacktrace.NewError("local")
+	assert.Equal(t, local, stacktrace.Graft(local, nil))
+}
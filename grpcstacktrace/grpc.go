@@ -0,0 +1,186 @@
+/*
+Package grpcstacktrace carries Stacktrace errors across gRPC calls. The server
+interceptors pack the error chain of a failed call into the details of its
+status, and the client interceptors unpack it and propagate it from the call
+site, so the client sees a single trace with both the remote frames and its
+own:
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(grpcstacktrace.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(grpcstacktrace.StreamServerInterceptor()),
+	)
+
+	conn, err := grpc.NewClient(target,
+		grpc.WithChainUnaryInterceptor(grpcstacktrace.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(grpcstacktrace.StreamClientInterceptor()),
+	)
+
+Like full stack traces, the packed chains reveal internals of the server, so
+only install the server interceptors for services whose clients are trusted.
+*/
+package grpcstacktrace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/palantir/stacktrace"
+	"github.com/palantir/stacktrace/stacktracepb"
+)
+
+// UnaryServerInterceptor returns an interceptor that packs the errors returned
+// by unary handlers into their status, see ToStatus.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, ToStatus(err)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor that packs the errors returned
+// by stream handlers into their status, see ToStatus.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return ToStatus(handler(srv, ss))
+	}
+}
+
+// UnaryClientInterceptor returns an interceptor that unpacks the error chains
+// packed by the server interceptors, see FromStatus.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return FromStatus(invoker(ctx, method, req, reply, cc, opts...), method)
+	}
+}
+
+// StreamClientInterceptor returns an interceptor that unpacks the error chains
+// packed by the server interceptors when a stream is created, see FromStatus.
+// Errors from later calls on the stream are returned as they are.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		return stream, FromStatus(err, method)
+	}
+}
+
+/*
+ToStatus returns a gRPC status error for err, with the chain of err attached to
+the details as a stacktracepb.Error. The status code is the one of a status
+error in the chain of err, or codes.Unknown if there is none, and the status
+message is the brief format of err. Errors that are not Stacktraces are returned
+as they are.
+*/
+func ToStatus(err error) error {
+	st, ok := err.(*stacktrace.Stacktrace)
+	if !ok || st == nil {
+		return err
+	}
+	code := codes.Unknown
+	var withStatus interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &withStatus) {
+		code = withStatus.GRPCStatus().Code()
+	}
+	s, derr := status.New(code, fmt.Sprintf("%#s", err)).WithDetails(stacktracepb.ToProto(err))
+	if derr != nil {
+		return status.Error(code, fmt.Sprintf("%#s", err))
+	}
+	return s.Err()
+}
+
+/*
+FromStatus reconstructs the error chain packed into a gRPC status error by
+ToStatus, and merges it with stacktrace.Graft into a new error with the Message
+"RPC <method> failed" at the Function that made the call, outside of gRPC and
+generated client code, so that the full format marks the remote boundary. The
+status itself is kept in the chain, in place of its innermost Cause, so
+status.Code and status.FromError keep working on the result. Errors without a
+packed chain are returned as they are.
+*/
+func FromStatus(err error, method string) error {
+	s, ok := status.FromError(err)
+	if !ok || s == nil {
+		return err
+	}
+	var pb *stacktracepb.Error
+	for _, detail := range s.Details() {
+		if d, ok := detail.(*stacktracepb.Error); ok {
+			pb = d
+			break
+		}
+	}
+	if pb == nil {
+		return err
+	}
+
+	remote := attachStatus(stacktracepb.FromProto(pb), s)
+	return stacktrace.Graft(stacktrace.NewErrorSkip(callSiteSkip(), "RPC %s failed", method), remote)
+}
+
+// statusError is an error that came across gRPC along with its status.
+type statusError struct {
+	text   string
+	status *status.Status
+}
+
+func (e *statusError) Error() string              { return e.text }
+func (e *statusError) GRPCStatus() *status.Status { return e.status }
+
+// attachStatus puts s at the root of the chain of err: in place of its
+// innermost Cause if that is a plain error, or as the Cause of its innermost
+// level otherwise.
+func attachStatus(err error, s *status.Status) error {
+	st, ok := err.(*stacktrace.Stacktrace)
+	if !ok {
+		return &statusError{text: err.Error(), status: s}
+	}
+	for {
+		next, ok := st.Cause.(*stacktrace.Stacktrace)
+		if !ok {
+			break
+		}
+		st = next
+	}
+	switch cause := st.Cause.(type) {
+	case nil:
+		st.Cause = &statusError{text: "rpc error: code = " + s.Code().String(), status: s}
+	case interface{ Unwrap() []error }:
+		// Several causes, keep them and only add the status
+		st.Cause = errors.Join(append(cause.Unwrap(), &statusError{text: "rpc error: code = " + s.Code().String(), status: s})...)
+	default:
+		st.Cause = &statusError{text: cause.Error(), status: s}
+	}
+	return err
+}
+
+// callSiteSkip returns the skip for the call to NewErrorSkip in FromStatus
+// that attributes the error to the first Function above FromStatus that is
+// outside of this package, gRPC and generated gRPC client code, or 0 if there
+// is none.
+func callSiteSkip() int {
+	pcs := make([]uintptr, 32)
+	// Skip runtime.Callers, callSiteSkip and FromStatus
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for skip := 1; ; skip++ {
+		frame, more := frames.Next()
+		if !isInternal(frame) {
+			return skip
+		}
+		if !more {
+			return 0
+		}
+	}
+}
+
+func isInternal(frame runtime.Frame) bool {
+	return strings.HasPrefix(frame.Function, "google.golang.org/grpc") ||
+		strings.HasPrefix(frame.Function, "github.com/palantir/stacktrace/grpcstacktrace.") ||
+		strings.HasSuffix(frame.File, "_grpc.pb.go")
+}
@@ -0,0 +1,95 @@
+package grpcstacktrace_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/palantir/stacktrace"
+	"github.com/palantir/stacktrace/grpcstacktrace"
+)
+
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	err error
+}
+
+func (s *healthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	return nil, s.err
+}
+
+func dial(t *testing.T, server *healthServer) grpc_health_v1.HealthClient {
+	listener := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcstacktrace.UnaryServerInterceptor()))
+	grpc_health_v1.RegisterHealthServer(s, server)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(grpcstacktrace.UnaryClientInterceptor()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return grpc_health_v1.NewHealthClient(conn)
+}
+
+var lineNumbers = regexp.MustCompile(`\.go:\d+`)
+
+func TestRoundTrip(t *testing.T) {
+	remote := stacktrace.PropagateWithCode(errors.New("disk full"), 7, "Failed to write")
+	remote = stacktrace.Propagate(remote, "Failed to check")
+	client := dial(t, &healthServer{err: remote})
+
+	var hooked []string
+	defer stacktrace.RegisterHook(func(st *stacktrace.Stacktrace) { hooked = append(hooked, st.Function) })()
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	require.Error(t, err)
+	assert.Equal(t, "TestRoundTrip", hooked[len(hooked)-1])
+	assert.Equal(t, "RPC /grpc.health.v1.Health/Check failed: Failed to check: Failed to write: disk full", fmt.Sprintf("%#s", err))
+	assert.Equal(t, stacktrace.ErrorCode(7), stacktrace.GetCode(err))
+	assert.Equal(t, codes.Unknown, status.Code(err))
+	assert.Equal(t, "TestRoundTrip", err.(*stacktrace.Stacktrace).Function)
+
+	full := lineNumbers.ReplaceAllString(fmt.Sprintf("%+s", err), ".go:#")
+	assert.Contains(t, full, "RPC /grpc.health.v1.Health/Check failed\n --- at github.com/palantir/Stacktrace/grpcstacktrace/grpc_test.go:# (TestRoundTrip) ---\n --- remote boundary ---\n")
+	assert.Contains(t, full, "Caused by: Failed to check\n --- at github.com/palantir/Stacktrace/grpcstacktrace/grpc_test.go:# (TestRoundTrip) ---\n")
+}
+
+func TestStatusCode(t *testing.T) {
+	remote := stacktrace.Propagate(status.Error(codes.NotFound, "no such user"), "Failed to load user")
+	client := dial(t, &healthServer{err: remote})
+
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	require.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "RPC /grpc.health.v1.Health/Check failed: Failed to load user: rpc error: code = NotFound desc = no such user", fmt.Sprintf("%#s", err))
+
+	// a chain without a plain root cause gets the status as its root
+	client = dial(t, &healthServer{err: stacktrace.NewError("Not ready")})
+	_, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.Equal(t, codes.Unknown, status.Code(err))
+	assert.Equal(t, "RPC /grpc.health.v1.Health/Check failed: Not ready: rpc error: code = Unknown", fmt.Sprintf("%#s", err))
+}
+
+func TestPlainErrors(t *testing.T) {
+	client := dial(t, &healthServer{err: status.Error(codes.PermissionDenied, "go away")})
+
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.Equal(t, "rpc error: code = PermissionDenied desc = go away", err.Error())
+	assert.Nil(t, grpcstacktrace.ToStatus(nil))
+	assert.Nil(t, grpcstacktrace.FromStatus(nil, "method"))
+}
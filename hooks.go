@@ -0,0 +1,48 @@
+package stacktrace
+
+import "sync"
+
+var (
+	hooksMu sync.RWMutex
+	hooks   []*func(*Stacktrace)
+)
+
+/*
+RegisterHook registers a function to be called with every error created by this
+package, from NewError, Propagate, NewMessageWithCode and the rest, so that an
+application can count, sample, enrich or forward errors centrally:
+
+	Stacktrace.RegisterHook(func(st *Stacktrace.Stacktrace) {
+		errorsCreated.WithLabelValues(Stacktrace.CodeName(st.Code)).Inc()
+	})
+
+Hooks run synchronously in the goroutine creating the error, in the order they
+were registered, after all fields have been set. A hook may modify the error,
+but must not create errors itself. The returned function unregisters the hook.
+*/
+func RegisterHook(hook func(st *Stacktrace)) (unregister func()) {
+	h := &hook
+	hooksMu.Lock()
+	hooks = append(hooks, h)
+	hooksMu.Unlock()
+
+	return func() {
+		hooksMu.Lock()
+		defer hooksMu.Unlock()
+		for i, registered := range hooks {
+			if registered == h {
+				hooks = append(hooks[:i:i], hooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func runHooks(st *Stacktrace) {
+	hooksMu.RLock()
+	registered := hooks
+	hooksMu.RUnlock()
+	for _, hook := range registered {
+		(*hook)(st)
+	}
+}
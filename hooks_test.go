@@ -0,0 +1,45 @@
+package stacktrace_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestRegisterHook(t *testing.T) {
+	var seen []*stacktrace.Stacktrace
+	unregister := stacktrace.RegisterHook(func(st *stacktrace.Stacktrace) {
+		seen = append(seen, st)
+	})
+	unregisterTag := stacktrace.RegisterHook(func(st *stacktrace.Stacktrace) {
+		st.Message += " [tagged]"
+	})
+
+	errs := []error{
+		stacktrace.NewError("new"),
+		stacktrace.Propagate(errors.New("plain"), "propagate"),
+		stacktrace.NewMessageWithCode(EcodeNoSuchPseudo, "message"),
+		stacktrace.NewErrorWithStringCode("RATE_LIMITED", "string code"),
+		stacktrace.PropagateCtx(context.Background(), errors.New("plain"), "ctx"),
+		stacktrace.AddSuppressed(errors.New("plain"), errors.New("suppressed")),
+	}
+	unregisterTag()
+	errs = append(errs, stacktrace.NewError("untagged"))
+	unregister()
+	stacktrace.NewError("unseen")
+
+	if assert.Len(t, seen, len(errs)) {
+		for i, err := range errs {
+			assert.True(t, seen[i] == err)
+		}
+	}
+	assert.Equal(t, "new [tagged]", seen[0].Message)
+	assert.Equal(t, "RATE_LIMITED", seen[3].StringCode)
+	assert.Len(t, seen[5].Suppressed, 1)
+	assert.Equal(t, "TestRegisterHook", seen[5].Function)
+	assert.Equal(t, "untagged", seen[6].Message)
+}
@@ -0,0 +1,151 @@
+/*
+Package html renders Stacktrace errors as HTML, for use in the error pages of
+local development servers:
+
+	func handler(w http.ResponseWriter, r *http.Request) {
+		if err := serve(w, r); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			html.RenderHTMLWithSource(w, err)
+		}
+	}
+
+Each error in the cause chain is shown as a collapsible section listing its
+frames. Like full stack traces, these pages are not designed to be seen by end
+users.
+*/
+package html
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/palantir/stacktrace"
+)
+
+// sourceContextLines is the number of lines shown before and after each frame
+// by RenderHTMLWithSource.
+const sourceContextLines = 2
+
+// section is one message of the cause chain along with the frames that
+// propagated it, as in the "Caused by:" sections of the full format.
+type section struct {
+	Message string
+	Code    stacktrace.ErrorCode
+	HasCode bool
+	Frames  []frame
+	Cause   *section
+}
+
+type frame struct {
+	stacktrace.Frame
+	Caller bool
+	Source []sourceLine
+}
+
+type sourceLine struct {
+	Number  int
+	Text    string
+	Current bool
+}
+
+var page = template.Must(template.New("page").Parse(`<div class="stacktrace">
+<style>
+.stacktrace { font-family: sans-serif; }
+.stacktrace details { margin: 0.5em 0 0.5em 1em; }
+.stacktrace summary { font-weight: bold; cursor: pointer; }
+.stacktrace .code { color: #a00; font-weight: normal; }
+.stacktrace ul { list-style: none; padding-left: 1em; }
+.stacktrace .caller { color: #777; }
+.stacktrace pre { background: #f6f6f6; margin: 0.25em 0; padding: 0.25em; }
+.stacktrace .current { background: #fdd; display: inline-block; width: 100%; }
+</style>
+{{template "section" .}}
+</div>
+{{define "section"}}<details open>
+<summary>{{if .Message}}{{.Message}}{{else}}(no message){{end}}{{if .HasCode}} <span class="code">[code {{.Code}}]</span>{{end}}</summary>
+{{- if .Frames}}
+<ul>
+{{- range .Frames}}
+<li{{if .Caller}} class="caller"{{end}}><code>{{.File}}:{{.Line}}</code>{{if .Function}} ({{.Function}}){{end}}
+{{- if .Source}}
+<pre>{{range .Source}}<span{{if .Current}} class="current"{{end}}>{{printf "%4d" .Number}} | {{.Text}}</span>
+{{end}}</pre>
+{{- end}}
+</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Cause}}
+{{template "section" .Cause}}
+{{- end}}
+</details>{{end}}`))
+
+// RenderHTML writes err to w as an HTML fragment showing its cause chain. It
+// writes nothing if err is nil.
+func RenderHTML(w io.Writer, err error) error {
+	return render(w, err, false)
+}
+
+// RenderHTMLWithSource is like RenderHTML, but also shows the source code
+// around each frame when the file can be found on disk.
+func RenderHTMLWithSource(w io.Writer, err error) error {
+	return render(w, err, true)
+}
+
+func render(w io.Writer, err error, withSource bool) error {
+	if err == nil {
+		return nil
+	}
+	return page.Execute(w, sections(err, withSource))
+}
+
+// sections converts the cause chain of err into nested sections.
+func sections(err error, withSource bool) *section {
+	var secs []*section
+	seen := make(map[*stacktrace.Stacktrace]bool)
+	for err != nil {
+		st, ok := err.(*stacktrace.Stacktrace)
+		if !ok {
+			secs = append(secs, &section{Message: err.Error()})
+			break
+		}
+		if seen[st] || (stacktrace.MaxChainDepth > 0 && len(seen) >= stacktrace.MaxChainDepth) {
+			secs = append(secs, &section{Message: "... truncated"})
+			break
+		}
+		seen[st] = true
+
+		// Like in the full format, an empty message adds frames to the
+		// current section instead of starting a new one.
+		if msg := stacktrace.GetMessage(st).Error(); msg != "" || len(secs) == 0 {
+			secs = append(secs, &section{Message: msg})
+		}
+		curr := secs[len(secs)-1]
+		if st.Code != stacktrace.NoCode && !curr.HasCode {
+			curr.Code, curr.HasCode = st.Code, true
+		}
+		if st.File != "" {
+			curr.Frames = append(curr.Frames, newFrame(stacktrace.Frame{File: st.File, Function: st.Function, Line: st.Line}, false, withSource))
+		}
+		for _, caller := range st.Stack {
+			curr.Frames = append(curr.Frames, newFrame(caller, true, withSource))
+		}
+		err = st.Cause
+	}
+
+	for i := 0; i < len(secs)-1; i++ {
+		secs[i].Cause = secs[i+1]
+	}
+	return secs[0]
+}
+
+func newFrame(f stacktrace.Frame, caller, withSource bool) frame {
+	fr := frame{Frame: f, Caller: caller}
+	if withSource && !caller {
+		first, lines := f.Source(sourceContextLines)
+		for i, text := range lines {
+			fr.Source = append(fr.Source, sourceLine{Number: first + i, Text: text, Current: first+i == f.Line})
+		}
+	}
+	return fr
+}
@@ -0,0 +1,48 @@
+package html_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+	"github.com/palantir/stacktrace/html"
+)
+
+func TestRenderHTML(t *testing.T) {
+	inner := stacktrace.NewErrorWithCode(3, "<inner>")
+	err := stacktrace.Propagate(stacktrace.Propagate(inner, ""), "outer")
+
+	var buf bytes.Buffer
+	assert.NoError(t, html.RenderHTML(&buf, err))
+	out := buf.String()
+	assert.Contains(t, out, `<summary>outer <span class="code">[code 3]</span></summary>`)
+	assert.Contains(t, out, `<summary>&lt;inner&gt; <span class="code">[code 3]</span></summary>`)
+	assert.Equal(t, 3, bytes.Count(buf.Bytes(), []byte("<li><code>github.com/palantir/Stacktrace/html/html_test.go:")))
+	assert.Equal(t, 2, bytes.Count(buf.Bytes(), []byte("<details open>")))
+	assert.NotContains(t, out, "<pre>")
+
+	buf.Reset()
+	assert.NoError(t, html.RenderHTML(&buf, errors.New("plain")))
+	assert.Contains(t, buf.String(), "<summary>plain</summary>")
+
+	buf.Reset()
+	assert.NoError(t, html.RenderHTML(&buf, nil))
+	assert.Empty(t, buf.String())
+}
+
+func TestRenderHTMLWithSource(t *testing.T) {
+	defer func(cleanPath func(string) string) { stacktrace.CleanPath = cleanPath }(stacktrace.CleanPath)
+	stacktrace.CleanPath = nil
+
+	err := stacktrace.Propagate(errors.New("plain"), "outer")
+	line := err.(*stacktrace.Stacktrace).Line
+
+	var buf bytes.Buffer
+	assert.NoError(t, html.RenderHTMLWithSource(&buf, err))
+	assert.Contains(t, buf.String(), fmt.Sprintf(`<span class="current">%4d | 	err := stacktrace.Propagate(errors.New(&#34;plain&#34;), &#34;outer&#34;)</span>`, line))
+	assert.Contains(t, buf.String(), "<summary>plain</summary>")
+}
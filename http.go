@@ -0,0 +1,161 @@
+package stacktrace
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+/*
+HTTPBodyExcerptLength is the maximum number of bytes of the response body that
+FromHTTPResponse includes in the Message. The excerpt goes through SanitizeArgs
+like a message argument. Set it to 0 to leave the body out.
+*/
+var HTTPBodyExcerptLength = 512
+
+var (
+	httpStatusesMu sync.RWMutex
+	httpStatuses   = map[int]ErrorCode{}
+)
+
+/*
+RegisterHTTPStatus makes FromHTTPResponse attach code to errors for responses
+with the given HTTP status:
+
+	func init() {
+		Stacktrace.RegisterHTTPStatus(http.StatusNotFound, EcodeNotFound)
+		Stacktrace.RegisterHTTPStatus(http.StatusServiceUnavailable, EcodeUnavailable)
+	}
+
+RegisterHTTPStatus returns an error if the status is already registered with a
+different Code.
+*/
+func RegisterHTTPStatus(status int, code ErrorCode) error {
+	httpStatusesMu.Lock()
+	defer httpStatusesMu.Unlock()
+	if other, ok := httpStatuses[status]; ok && other != code {
+		return fmt.Errorf("stacktrace: HTTP status %d is already registered with code %d", status, other)
+	}
+	httpStatuses[status] = code
+	return nil
+}
+
+/*
+FromHTTPResponse returns an error describing an unsuccessful HTTP response, or
+nil if resp is nil or its status is below 400:
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Stacktrace.Propagate(err, "Failed to call billing")
+	}
+	defer resp.Body.Close()
+	if err := Stacktrace.FromHTTPResponse(resp); err != nil {
+		return err
+	}
+
+The Message holds the method and URL of the request, without any password, the
+status, and up to HTTPBodyExcerptLength bytes of the body, for example
+
+	GET https://billing/invoices/7: 503 Service Unavailable: upstream timeout
+
+The error gets the Code registered for the status with RegisterHTTPStatus, if
+any, and the Retry-After header of the response as a hint for RetryAfter.
+FromHTTPResponse reads from the body but does not close it.
+*/
+func FromHTTPResponse(resp *http.Response) error {
+	return fromHTTPResponse(0, resp)
+}
+
+/*
+FromHTTPResponseSkip is like FromHTTPResponse, but attributes the error to a
+caller further up the stack, like PropagateSkip.
+*/
+func FromHTTPResponseSkip(skip int, resp *http.Response) error {
+	return fromHTTPResponse(skip, resp)
+}
+
+func fromHTTPResponse(skip int, resp *http.Response) error {
+	if resp == nil || resp.StatusCode < 400 {
+		return nil
+	}
+
+	httpStatusesMu.RLock()
+	code, ok := httpStatuses[resp.StatusCode]
+	httpStatusesMu.RUnlock()
+	if !ok {
+		code = NoCode
+	}
+
+	msg := httpStatus(resp)
+	if req := resp.Request; req != nil && req.URL != nil {
+		msg = req.Method + " " + req.URL.Redacted() + ": " + msg
+	}
+	if excerpt := httpBodyExcerpt(resp.Body); excerpt != "" {
+		msg += ": " + excerpt
+	}
+	retryAt := httpRetryAt(resp.Header.Get("Retry-After"))
+
+	// +1 for fromHTTPResponse itself
+	return createSkip(skip+1, nil, code, func(st *Stacktrace) {
+		literalMessage(msg)(st)
+		st.RetryAt = retryAt
+	}, "")
+}
+
+// httpStatus returns the status of resp like "503 Service Unavailable".
+func httpStatus(resp *http.Response) string {
+	if resp.Status != "" {
+		return resp.Status
+	}
+	return strconv.Itoa(resp.StatusCode) + " " + http.StatusText(resp.StatusCode)
+}
+
+// httpBodyExcerpt reads up to HTTPBodyExcerptLength bytes from body and
+// returns them as a single line after SanitizeArgs, marking whether there was
+// more.
+func httpBodyExcerpt(body io.Reader) string {
+	if body == nil || HTTPBodyExcerptLength <= 0 {
+		return ""
+	}
+	// Read one byte more to find out whether the body is longer
+	buf, _ := io.ReadAll(io.LimitReader(body, int64(HTTPBodyExcerptLength)+1))
+	more := len(buf) > HTTPBodyExcerptLength
+	if more {
+		buf = buf[:HTTPBodyExcerptLength]
+		// Do not cut a multi-byte character in half
+		for i := 1; i < utf8.UTFMax && i <= len(buf); i++ {
+			if utf8.RuneStart(buf[len(buf)-i]) {
+				if !utf8.FullRune(buf[len(buf)-i:]) {
+					buf = buf[:len(buf)-i]
+				}
+				break
+			}
+		}
+	}
+	text := sanitizeText(strings.ToValidUTF8(string(buf), string(utf8.RuneError)))
+	excerpt := strings.Join(strings.Fields(text), " ")
+	if more && excerpt != "" {
+		excerpt += "..."
+	}
+	return excerpt
+}
+
+// httpRetryAt parses a Retry-After header, which holds either a number of
+// seconds or an HTTP date. It returns the zero time if there is none.
+func httpRetryAt(header string) time.Time {
+	if header == "" {
+		return time.Time{}
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return at
+	}
+	return time.Time{}
+}
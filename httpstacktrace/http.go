@@ -0,0 +1,212 @@
+/*
+Package httpstacktrace carries Stacktrace errors across plain HTTP calls. A
+server that fails a request writes the error chain into a response header, and
+the client rebuilds it and propagates it from its call site, so the client sees
+a single trace with both the remote frames and its own:
+
+	// server
+	if err != nil {
+		httpstacktrace.WriteError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	// client
+	resp, err := client.Do(req)
+	if err != nil {
+		return stacktrace.Propagate(err, "Failed to call billing")
+	}
+	defer resp.Body.Close()
+	if err := httpstacktrace.FromResponse(resp); err != nil {
+		return err
+	}
+
+The chain is encoded as a stacktracepb.Error, compressed and base64-encoded. Like
+full stack traces, it reveals internals of the server, so only send it to
+clients that are trusted.
+*/
+package httpstacktrace
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/palantir/stacktrace"
+	"github.com/palantir/stacktrace/stacktracepb"
+)
+
+// Header is the name of the response header or trailer holding the error chain.
+var Header = "X-Stacktrace-Chain"
+
+/*
+MaxHeaderSize is the maximum length of the encoded error chain. Longer chains
+first lose their stacks, then their suppressed errors, and are not sent at all
+if they are still too long. Most servers and proxies reject responses whose
+headers are larger than 8 to 16 KB in total.
+*/
+var MaxHeaderSize = 4096
+
+/*
+WriteError replies to a request with the given HTTP status and the brief format
+of err as a plain text body, like http.Error, and with the chain of err in the
+Header. It must be called before anything else is written to w.
+*/
+func WriteError(w http.ResponseWriter, err error, status int) {
+	SetHeader(w.Header(), err)
+	http.Error(w, fmt.Sprintf("%#s", err), status)
+}
+
+/*
+SetHeader sets the Header to the encoded chain of err in h, or removes it if err
+is nil or its chain does not fit in MaxHeaderSize.
+*/
+func SetHeader(h http.Header, err error) {
+	if value := Encode(err); value != "" {
+		h.Set(Header, value)
+	} else {
+		h.Del(Header)
+	}
+}
+
+/*
+SetTrailer sends the chain of err in a trailer, for handlers that find out
+about an error after they started writing the body. Trailers are only sent with
+chunked responses, so the handler must have flushed part of the body or
+announced the trailer in its header before.
+*/
+func SetTrailer(w http.ResponseWriter, err error) {
+	if value := Encode(err); value != "" {
+		w.Header().Set(http.TrailerPrefix+Header, value)
+	}
+}
+
+/*
+Encode returns the chain of err as a header value, or "" if err is nil or the
+chain does not fit in MaxHeaderSize.
+*/
+func Encode(err error) string {
+	pb := stacktracepb.ToProto(err)
+	if pb == nil {
+		return ""
+	}
+	for _, reduce := range []func(*stacktracepb.Error){nil, dropStacks, dropSuppressed} {
+		if reduce != nil {
+			reduce(pb)
+		}
+		if value := encode(pb); value != "" && len(value) <= MaxHeaderSize {
+			return value
+		}
+	}
+	return ""
+}
+
+func encode(pb *stacktracepb.Error) string {
+	data, err := proto.Marshal(pb)
+	if err != nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return ""
+	}
+	if err := zw.Close(); err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(buf.Bytes())
+}
+
+// dropStacks removes the stacks from every level of the chain.
+func dropStacks(pb *stacktracepb.Error) {
+	walk(pb, func(level *stacktracepb.Level) { level.Stack = nil })
+}
+
+// dropSuppressed removes the suppressed errors from every level of the chain.
+func dropSuppressed(pb *stacktracepb.Error) {
+	walk(pb, func(level *stacktracepb.Level) { level.Suppressed = nil })
+}
+
+func walk(pb *stacktracepb.Error, fn func(*stacktracepb.Level)) {
+	if pb == nil {
+		return
+	}
+	for _, level := range pb.Levels {
+		fn(level)
+		for _, suppressed := range level.Suppressed {
+			walk(suppressed, fn)
+		}
+	}
+	walk(pb.Cause, fn)
+	for _, branch := range pb.Branches {
+		walk(branch, fn)
+	}
+}
+
+/*
+Decode reconstructs an error chain from a header value returned by Encode. It
+returns nil if the value is empty or malformed.
+*/
+func Decode(value string) error {
+	if value == "" {
+		return nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	// Guard against a small header expanding into a huge message
+	data, err = io.ReadAll(io.LimitReader(zr, 64*int64(MaxHeaderSize)))
+	if err != nil {
+		return nil
+	}
+	var pb stacktracepb.Error
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return nil
+	}
+	return stacktracepb.FromProto(&pb)
+}
+
+/*
+FromResponse returns an error describing an unsuccessful HTTP response, or nil
+if resp is nil or its status is below 400. If the server sent an error chain in
+the Header, or in a trailer that is available because the body has been read,
+the chain is merged with stacktrace.Graft into a new error at the caller of
+FromResponse with the Message
+
+	GET https://billing/invoices/7: 503 Service Unavailable
+
+Otherwise it returns stacktrace.FromHTTPResponse(resp).
+*/
+func FromResponse(resp *http.Response) error {
+	if resp == nil || resp.StatusCode < 400 {
+		return nil
+	}
+	remote := Decode(resp.Header.Get(Header))
+	if remote == nil && resp.Trailer != nil {
+		remote = Decode(resp.Trailer.Get(Header))
+	}
+	if remote == nil {
+		return stacktrace.FromHTTPResponseSkip(1, resp)
+	}
+
+	status := resp.Status
+	if status == "" {
+		status = http.StatusText(resp.StatusCode)
+	}
+	var local error
+	if req := resp.Request; req != nil && req.URL != nil {
+		local = stacktrace.NewErrorSkip(1, "%s %s: %s", req.Method, req.URL.Redacted(), status)
+	} else {
+		local = stacktrace.NewErrorSkip(1, "%s", status)
+	}
+	return stacktrace.Graft(local, remote)
+}
@@ -0,0 +1,103 @@
+package httpstacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+	"github.com/palantir/stacktrace/httpstacktrace"
+)
+
+// get requests from handler, reading the body before FromResponse if drain is
+// set, as trailers are only available after that.
+func get(t *testing.T, handler http.HandlerFunc, drain bool) (*http.Response, error) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL + "/invoices/7")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if drain {
+		io.Copy(io.Discard, resp.Body)
+	}
+	return resp, httpstacktrace.FromResponse(resp)
+}
+
+func TestRoundTrip(t *testing.T) {
+	remote := stacktrace.PropagateWithCode(errors.New("disk full"), 7, "Failed to write")
+	remote = stacktrace.Propagate(remote, "Failed to load invoice")
+
+	var hooked []string
+	defer stacktrace.RegisterHook(func(st *stacktrace.Stacktrace) { hooked = append(hooked, st.Function) })()
+	resp, err := get(t, func(w http.ResponseWriter, r *http.Request) {
+		httpstacktrace.WriteError(w, remote, http.StatusServiceUnavailable)
+	}, false)
+	require.Error(t, err)
+	assert.NotEmpty(t, resp.Header.Get(httpstacktrace.Header))
+	brief := fmt.Sprintf("%#s", err)
+	assert.True(t, strings.HasPrefix(brief, "GET http://127.0.0.1:"), brief)
+	assert.True(t, strings.HasSuffix(brief, "/invoices/7: 503 Service Unavailable: Failed to load invoice: Failed to write: disk full"), brief)
+	assert.Equal(t, stacktrace.ErrorCode(7), stacktrace.GetCode(err))
+	assert.Equal(t, "get", err.(*stacktrace.Stacktrace).Function)
+	assert.Equal(t, []string{"get"}, hooked)
+	assert.Contains(t, fmt.Sprintf("%+s", err), "(get) ---\n --- remote boundary ---\nCaused by: Failed to load invoice\n")
+	assert.Equal(t, "TestRoundTrip", stacktrace.GetCause(err).(*stacktrace.Stacktrace).Function)
+}
+
+func TestTrailer(t *testing.T) {
+	_, err := get(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, "partial output")
+		w.(http.Flusher).Flush()
+		httpstacktrace.SetTrailer(w, stacktrace.NewError("Failed halfway"))
+	}, true)
+	brief := fmt.Sprintf("%#s", err)
+	assert.True(t, strings.HasSuffix(brief, ": 500 Internal Server Error: Failed halfway"), brief)
+}
+
+func TestWithoutChain(t *testing.T) {
+	_, err := get(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not here", http.StatusNotFound)
+	}, false)
+	brief := fmt.Sprintf("%#s", err)
+	assert.True(t, strings.HasSuffix(brief, ": 404 Not Found: not here"), brief)
+	assert.Equal(t, "get", err.(*stacktrace.Stacktrace).Function)
+
+	_, err = get(t, func(w http.ResponseWriter, r *http.Request) {}, false)
+	assert.NoError(t, err)
+	assert.Nil(t, httpstacktrace.Decode("not base64!"))
+}
+
+func TestMaxHeaderSize(t *testing.T) {
+	defer func(capture bool) { stacktrace.CaptureStack = capture }(stacktrace.CaptureStack)
+	stacktrace.CaptureStack = true
+	defer func(size int) { httpstacktrace.MaxHeaderSize = size }(httpstacktrace.MaxHeaderSize)
+
+	err := stacktrace.NewError("Failed")
+	for i := 0; i < 20; i++ {
+		err = stacktrace.Propagate(err, "Level %d", i)
+	}
+	full := httpstacktrace.Encode(err)
+	require.NotEmpty(t, full)
+
+	// without stacks the chain still fits
+	httpstacktrace.MaxHeaderSize = len(full) - 1
+	reduced := httpstacktrace.Encode(err)
+	require.NotEmpty(t, reduced)
+	assert.Less(t, len(reduced), len(full))
+	decoded := httpstacktrace.Decode(reduced)
+	assert.Equal(t, fmt.Sprintf("%#s", err), fmt.Sprintf("%#s", decoded))
+	assert.Empty(t, decoded.(*stacktrace.Stacktrace).Stack)
+
+	httpstacktrace.MaxHeaderSize = 10
+	assert.Empty(t, httpstacktrace.Encode(err))
+	assert.Empty(t, httpstacktrace.Encode(nil))
+}
@@ -0,0 +1,82 @@
+package stacktrace
+
+import (
+	"crypto/rand"
+	"time"
+)
+
+/*
+AssignIDs controls whether errors are stamped with a unique ID when they are
+created, so that a short reference shown to a user can be matched with the full
+Stacktrace in the server logs:
+
+	Stacktrace.AssignIDs = true
+	...
+	http.Error(w, "Internal error, ref: "+Stacktrace.ID(err), http.StatusInternalServerError)
+
+IDs are ULIDs, like "01HF8Z3K6V9Q2W4E5R6T7Y8X9A". The ID is assigned by the
+first call to a constructor of this package in a chain and inherited when the
+error is propagated, like an error Code. Both formats show the ID as "[id=...]"
+after the Message of the level where it was assigned.
+*/
+var AssignIDs = false
+
+// ID returns the ID assigned to the nearest Stacktrace error in the chain of
+// err that has one, or "" if there is none. See AssignIDs.
+func ID(err error) string {
+	id := ""
+	Walk(err, func(err error) bool {
+		if st, ok := err.(*Stacktrace); ok {
+			id = st.ID
+		}
+		return id == ""
+	})
+	return id
+}
+
+// stampID returns the ID for a new error wrapping cause.
+func stampID(cause error) string {
+	if id := ID(cause); id != "" || !AssignIDs {
+		return id
+	}
+	return newID()
+}
+
+// idLabel returns "[id=...]" if st was assigned an ID, or "" if it has none or
+// inherited it from its Cause.
+func idLabel(st *Stacktrace) string {
+	if st.ID == "" || st.ID == ID(st.Cause) {
+		return ""
+	}
+	return "[id=" + st.ID + "]"
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newID returns a ULID: a 48-bit millisecond timestamp followed by 80 random
+// bits, in Crockford's base32.
+func newID() string {
+	var id [16]byte
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	for i := 5; i >= 0; i-- {
+		id[i] = byte(ms)
+		ms >>= 8
+	}
+	if _, err := rand.Read(id[6:]); err != nil {
+		return ""
+	}
+
+	// The 128 bits, padded with two leading zero bits, take 26 characters
+	var out [26]byte
+	for i := range out {
+		var v byte
+		for bit := 5*i - 2; bit < 5*i+3; bit++ {
+			v <<= 1
+			if bit >= 0 && id[bit/8]&(0x80>>uint(bit%8)) != 0 {
+				v |= 1
+			}
+		}
+		out[i] = crockford[v]
+	}
+	return string(out[:])
+}
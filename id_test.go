@@ -0,0 +1,39 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+var ulid = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+
+func TestAssignIDs(t *testing.T) {
+	assert.Equal(t, "", stacktrace.ID(stacktrace.NewError("msg")))
+
+	defer func(assign bool) { stacktrace.AssignIDs = assign }(stacktrace.AssignIDs)
+	stacktrace.AssignIDs = true
+
+	inner := stacktrace.Propagate(errors.New("plain"), "inner")
+	id := stacktrace.ID(inner)
+	assert.Regexp(t, ulid, id)
+
+	outer := fmt.Errorf("wrapped: %w", stacktrace.Propagate(inner, "outer"))
+	assert.Equal(t, id, stacktrace.ID(outer))
+	assert.NotEqual(t, id, stacktrace.ID(stacktrace.NewError("other")))
+	assert.Regexp(t, ulid, stacktrace.ID(stacktrace.NewMessageWithCode(EcodeNoSuchPseudo, "msg")))
+	assert.Equal(t, "", stacktrace.ID(errors.New("plain")))
+
+	st := stacktrace.Propagate(inner, "outer")
+	assert.Equal(t, fmt.Sprintf("outer: inner [id=%s]: plain", id), fmt.Sprintf("%#s", st))
+	assert.Equal(t, fmt.Sprintf(`outer
+ --- at github.com/palantir/Stacktrace/id_test.go:# (TestAssignIDs) ---
+Caused by: inner [id=%s]
+ --- at github.com/palantir/Stacktrace/id_test.go:# (TestAssignIDs) ---
+Caused by: plain`, id), normalizeLines(fmt.Sprintf("%+s", st)))
+}
@@ -0,0 +1,224 @@
+package stacktrace
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+/*
+KubernetesStatus is the status of a failed Kubernetes API call, with the same
+fields and JSON encoding as metav1.Status from k8s.io/apimachinery, so that it
+can be returned by admission webhooks and aggregated API servers, or converted
+with
+
+	&apierrors.StatusError{ErrStatus: metav1.Status{
+		Status:  s.Status,
+		Message: s.Message,
+		Reason:  metav1.StatusReason(s.Reason),
+		Code:    s.Code,
+	}}
+*/
+type KubernetesStatus struct {
+	Kind       string `json:"kind,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	Code       int32  `json:"code,omitempty"`
+}
+
+// kubernetesReason is a metav1.StatusReason with its HTTP status code.
+type kubernetesReason struct {
+	reason string
+	code   int32
+}
+
+var (
+	kubernetesReasonsMu sync.RWMutex
+	kubernetesReasons   = map[ErrorCode]kubernetesReason{}
+	kubernetesCodes     = map[string]ErrorCode{}
+)
+
+/*
+RegisterKubernetesReason makes ToKubernetesStatus report errors with the given
+Code with a metav1.StatusReason, such as "NotFound", and its HTTP status code,
+and makes KubernetesCode return the first Code registered for the reason:
+
+	func init() {
+		Stacktrace.RegisterKubernetesReason(EcodeQuotaExceeded, "Forbidden", http.StatusForbidden)
+	}
+
+RegisterKubernetesReason returns an error if the Code is already registered with
+a different reason. Registered codes take precedence over the defaults, which
+are NotFound for EcodeNotFound and EcodeNoRows, Forbidden for EcodePermission,
+AlreadyExists for EcodeUniqueViolation, Conflict for EcodeSerializationFailure
+and Timeout for EcodeDeadline.
+*/
+func RegisterKubernetesReason(code ErrorCode, reason string, status int32) error {
+	kubernetesReasonsMu.Lock()
+	defer kubernetesReasonsMu.Unlock()
+	if other, ok := kubernetesReasons[code]; ok && other.reason != reason {
+		return fmt.Errorf("stacktrace: code %d is already registered with Kubernetes reason %s", code, other.reason)
+	}
+	kubernetesReasons[code] = kubernetesReason{reason: reason, code: status}
+	if _, ok := kubernetesCodes[reason]; !ok {
+		kubernetesCodes[reason] = code
+	}
+	return nil
+}
+
+// defaultKubernetesReasons maps the codes of this package to Kubernetes
+// reasons. It is built on every call since the codes are variables.
+func defaultKubernetesReasons() map[ErrorCode]kubernetesReason {
+	return map[ErrorCode]kubernetesReason{
+		EcodeNoRows:               {"NotFound", http.StatusNotFound},
+		EcodeNotFound:             {"NotFound", http.StatusNotFound},
+		EcodePermission:           {"Forbidden", http.StatusForbidden},
+		EcodeUniqueViolation:      {"AlreadyExists", http.StatusConflict},
+		EcodeSerializationFailure: {"Conflict", http.StatusConflict},
+		EcodeDeadline:             {"Timeout", http.StatusGatewayTimeout},
+	}
+}
+
+// defaultKubernetesCodes maps Kubernetes reasons back to the codes of this
+// package, like defaultKubernetesReasons.
+func defaultKubernetesCodes() map[string]ErrorCode {
+	return map[string]ErrorCode{
+		"NotFound":      EcodeNotFound,
+		"Forbidden":     EcodePermission,
+		"AlreadyExists": EcodeUniqueViolation,
+		"Conflict":      EcodeSerializationFailure,
+		"Timeout":       EcodeDeadline,
+	}
+}
+
+/*
+ToKubernetesStatus returns the Kubernetes status for err, with the brief format
+of err as the Message. The reason and HTTP status code are the ones registered
+for the Code of err with RegisterKubernetesReason, or the default ones for the
+codes of this package, or those of a Kubernetes status error in the chain of
+err, such as an *apierrors.StatusError returned by a client. Other errors are
+reported as an InternalError. ToKubernetesStatus returns nil if err is nil.
+*/
+func ToKubernetesStatus(err error) *KubernetesStatus {
+	if err == nil {
+		return nil
+	}
+	status := &KubernetesStatus{
+		Kind:       "Status",
+		APIVersion: "v1",
+		Status:     "Failure",
+		Message:    err.Error(),
+		Reason:     "InternalError",
+		Code:       http.StatusInternalServerError,
+	}
+	if st, ok := err.(*Stacktrace); ok && st != nil {
+		status.Message = formatBrief(st)
+	}
+
+	code := GetCode(err)
+	kubernetesReasonsMu.RLock()
+	r, ok := kubernetesReasons[code]
+	kubernetesReasonsMu.RUnlock()
+	if !ok && code != NoCode {
+		r, ok = defaultKubernetesReasons()[code]
+	}
+	if ok {
+		status.Reason, status.Code = r.reason, r.code
+	} else if reason, statusCode, ok := KubernetesReason(err); ok {
+		status.Reason, status.Code = reason, statusCode
+	}
+	return status
+}
+
+/*
+PropagateKubernetes is like Propagate, but attaches the error Code of a
+Kubernetes status error in the chain of cause, see KubernetesCode, so that
+operators handle failed API calls like other coded errors:
+
+	if err := c.Get(ctx, key, &pod); err != nil {
+		return Stacktrace.PropagateKubernetes(err, "Failed to get pod %s", key)
+	}
+
+Conflicts, throttled requests and unavailable servers are also marked
+retryable, see IsRetryable. Other errors are propagated as by Propagate.
+*/
+func PropagateKubernetes(cause error, msg string, vals ...interface{}) error {
+	if cause == nil {
+		// Allow calling PropagateKubernetes without checking whether there is error
+		return nil
+	}
+	reason, _, _ := KubernetesReason(cause)
+	return createWith(cause, KubernetesCode(cause), func(st *Stacktrace) {
+		switch reason {
+		case "Conflict", "TooManyRequests", "ServerTimeout", "ServiceUnavailable":
+			retryable := true
+			st.Retryable = &retryable
+		}
+	}, msg, vals...)
+}
+
+/*
+KubernetesCode returns the error Code for the reason of a Kubernetes status
+error in the chain of err: the first Code registered for the reason with
+RegisterKubernetesReason, or else EcodeNotFound for NotFound, EcodePermission
+for Forbidden, EcodeUniqueViolation for AlreadyExists, EcodeSerializationFailure
+for Conflict and EcodeDeadline for Timeout. It returns NoCode for other errors.
+*/
+func KubernetesCode(err error) ErrorCode {
+	reason, _, ok := KubernetesReason(err)
+	if !ok {
+		return NoCode
+	}
+	kubernetesReasonsMu.RLock()
+	code, ok := kubernetesCodes[reason]
+	kubernetesReasonsMu.RUnlock()
+	if ok {
+		return code
+	}
+	if code, ok := defaultKubernetesCodes()[reason]; ok {
+		return code
+	}
+	return NoCode
+}
+
+/*
+KubernetesReason returns the reason and HTTP status code of the first
+Kubernetes status error in the chain of err. Such errors, like
+*apierrors.StatusError, are recognized without depending on k8s.io/apimachinery
+by their method
+
+	Status() metav1.Status
+*/
+func KubernetesReason(err error) (reason string, code int32, ok bool) {
+	Walk(err, func(err error) bool {
+		reason, code, ok = kubernetesStatus(err)
+		return !ok
+	})
+	return reason, code, ok
+}
+
+// kubernetesStatus calls the Status method of err if it returns a struct named
+// Status with Reason and Code fields, like metav1.Status.
+func kubernetesStatus(err error) (string, int32, bool) {
+	value := reflect.ValueOf(err)
+	if value.Kind() == reflect.Pointer && value.IsNil() {
+		// The Status method of a nil *StatusError would panic
+		return "", 0, false
+	}
+	method := value.MethodByName("Status")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return "", 0, false
+	}
+	if t := method.Type().Out(0); t.Kind() != reflect.Struct || t.Name() != "Status" {
+		return "", 0, false
+	}
+	status := method.Call(nil)[0]
+	reason, code := status.FieldByName("Reason"), status.FieldByName("Code")
+	if !reason.IsValid() || reason.Kind() != reflect.String || !code.IsValid() || code.Kind() != reflect.Int32 {
+		return "", 0, false
+	}
+	return reason.String(), int32(code.Int()), true
+}
@@ -0,0 +1,99 @@
+package stacktrace_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+)
+
+// Status and StatusError mimic metav1.Status and apierrors.StatusError from
+// k8s.io/apimachinery.
+type Status struct {
+	Message string
+	Reason  StatusReason
+	Code    int32
+}
+
+type StatusReason string
+
+type StatusError struct {
+	ErrStatus Status
+}
+
+func (e *StatusError) Error() string  { return e.ErrStatus.Message }
+func (e *StatusError) Status() Status { return e.ErrStatus }
+
+func TestToKubernetesStatus(t *testing.T) {
+	stacktrace.UseStandardCodes()
+	err := stacktrace.PropagateWithCode(errors.New("no such file"), stacktrace.EcodeNotFound, "Failed to load config")
+	assert.Equal(t, &stacktrace.KubernetesStatus{
+		Kind:       "Status",
+		APIVersion: "v1",
+		Status:     "Failure",
+		Message:    "Failed to load config: no such file",
+		Reason:     "NotFound",
+		Code:       http.StatusNotFound,
+	}, stacktrace.ToKubernetesStatus(err))
+
+	// a status error from a client keeps its reason
+	remote := &StatusError{Status{Message: "too many requests", Reason: "TooManyRequests", Code: http.StatusTooManyRequests}}
+	status := stacktrace.ToKubernetesStatus(stacktrace.Propagate(fmt.Errorf("get: %w", remote), "Failed to get pod"))
+	assert.Equal(t, "TooManyRequests", status.Reason)
+	assert.Equal(t, int32(http.StatusTooManyRequests), status.Code)
+
+	status = stacktrace.ToKubernetesStatus(stacktrace.NewError("Failed"))
+	assert.Equal(t, "InternalError", status.Reason)
+	assert.Equal(t, int32(http.StatusInternalServerError), status.Code)
+
+	data, jerr := json.Marshal(status)
+	require.NoError(t, jerr)
+	assert.Equal(t, `{"kind":"Status","apiVersion":"v1","status":"Failure","message":"Failed","reason":"InternalError","code":500}`, string(data))
+	assert.Nil(t, stacktrace.ToKubernetesStatus(nil))
+}
+
+func TestKubernetesReasonNilStatusError(t *testing.T) {
+	var remote *StatusError
+	_, _, ok := stacktrace.KubernetesReason(fmt.Errorf("get: %w", remote))
+	assert.False(t, ok)
+}
+
+func TestRegisterKubernetesReason(t *testing.T) {
+	require.NoError(t, stacktrace.RegisterKubernetesReason(EcodeNotImplemented, "MethodNotAllowed", http.StatusMethodNotAllowed))
+	require.NoError(t, stacktrace.RegisterKubernetesReason(EcodeNotImplemented, "MethodNotAllowed", http.StatusMethodNotAllowed))
+	assert.Error(t, stacktrace.RegisterKubernetesReason(EcodeNotImplemented, "BadRequest", http.StatusBadRequest))
+
+	status := stacktrace.ToKubernetesStatus(stacktrace.NewErrorWithCode(EcodeNotImplemented, "Not yet"))
+	assert.Equal(t, "MethodNotAllowed", status.Reason)
+	assert.Equal(t, int32(http.StatusMethodNotAllowed), status.Code)
+
+	remote := &StatusError{Status{Reason: "MethodNotAllowed", Code: http.StatusMethodNotAllowed}}
+	assert.Equal(t, EcodeNotImplemented, stacktrace.KubernetesCode(remote))
+}
+
+func TestPropagateKubernetes(t *testing.T) {
+	stacktrace.UseStandardCodes()
+	notFound := &StatusError{Status{Message: `pods "web" not found`, Reason: "NotFound", Code: http.StatusNotFound}}
+	err := stacktrace.PropagateKubernetes(notFound, "Failed to get pod %s", "web")
+	assert.Equal(t, stacktrace.EcodeNotFound, stacktrace.GetCode(err))
+	assert.False(t, stacktrace.IsRetryable(err))
+
+	conflict := &StatusError{Status{Reason: "Conflict", Code: http.StatusConflict}}
+	err = stacktrace.PropagateKubernetes(conflict, "Failed to update pod")
+	assert.Equal(t, stacktrace.EcodeSerializationFailure, stacktrace.GetCode(err))
+	assert.True(t, stacktrace.IsRetryable(err))
+
+	reason, code, ok := stacktrace.KubernetesReason(err)
+	assert.True(t, ok)
+	assert.Equal(t, "Conflict", reason)
+	assert.Equal(t, int32(http.StatusConflict), code)
+
+	assert.Equal(t, stacktrace.NoCode, stacktrace.GetCode(stacktrace.PropagateKubernetes(errors.New("other"), "Failed")))
+	assert.Nil(t, stacktrace.PropagateKubernetes(nil, "Failed"))
+}
@@ -0,0 +1,96 @@
+package stacktrace
+
+import (
+	"context"
+	"reflect"
+)
+
+/*
+LambdaError is an error in the shape AWS Lambda reports failed invocations in,
+which Step Functions match their Retry and Catch rules against:
+
+	{
+	  "errorType": "EcodeThrottled",
+	  "errorMessage": "Failed to charge card: rate limit exceeded",
+	  "stackTrace": [{"path": "github.com/palantir/billing/charge.go", "line": 42, "label": "Charge"}]
+	}
+*/
+type LambdaError struct {
+	ErrorType    string             `json:"errorType"`
+	ErrorMessage string             `json:"errorMessage"`
+	StackTrace   []LambdaStackFrame `json:"stackTrace,omitempty"`
+}
+
+// LambdaStackFrame is a stack frame of a LambdaError.
+type LambdaStackFrame struct {
+	Path  string `json:"path"`
+	Line  int    `json:"line"`
+	Label string `json:"label"`
+}
+
+// Error returns the ErrorMessage of e.
+func (e *LambdaError) Error() string {
+	return e.ErrorMessage
+}
+
+/*
+ToLambdaError converts err to a LambdaError. The ErrorType is the string Code
+of err, or the name of its Code registered with RegisterCode, so that a Step
+Functions rule like
+
+	"Retry": [{"ErrorEquals": ["EcodeThrottled"], "MaxAttempts": 3}]
+
+matches errors by Code. Errors with neither are of type "Stacktrace", and
+errors that are not Stacktraces are named after their type, as the Lambda
+runtime names them. The ErrorMessage is the brief format of err, and the
+StackTrace the innermost location of the chain followed by its Stack, most
+recent call first. ToLambdaError returns nil if err is nil.
+*/
+func ToLambdaError(err error) *LambdaError {
+	if err == nil {
+		return nil
+	}
+	st, ok := err.(*Stacktrace)
+	if !ok || st == nil {
+		t := reflect.TypeOf(err)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		return &LambdaError{ErrorType: t.Name(), ErrorMessage: err.Error()}
+	}
+
+	e := &LambdaError{ErrorType: "Stacktrace", ErrorMessage: formatBrief(st)}
+	if st.StringCode != "" || st.Code != NoCode {
+		e.ErrorType = exceptionClass(st)
+	}
+	for _, frame := range stackFrames(st) {
+		e.StackTrace = append(e.StackTrace, LambdaStackFrame{Path: frame.File, Line: frame.Line, Label: frame.Function})
+	}
+	return e
+}
+
+/*
+WrapLambdaHandler wraps a Lambda handler so that the errors it returns are
+converted by ToLambdaError and then by convert. The Lambda runtime for Go names
+errors after their Go type, except for its own InvokeResponse_Error, so convert
+should turn the LambdaError into that:
+
+	lambda.Start(Stacktrace.WrapLambdaHandler(handle, func(e *Stacktrace.LambdaError) error {
+		return messages.InvokeResponse_Error{Type: e.ErrorType, Message: e.ErrorMessage}
+	}))
+
+A nil convert returns the LambdaError as is, for custom runtimes that send it
+to the Lambda runtime API themselves.
+*/
+func WrapLambdaHandler[In, Out any](handler func(context.Context, In) (Out, error), convert func(*LambdaError) error) func(context.Context, In) (Out, error) {
+	return func(ctx context.Context, in In) (Out, error) {
+		out, err := handler(ctx, in)
+		if err == nil {
+			return out, nil
+		}
+		if convert == nil {
+			return out, ToLambdaError(err)
+		}
+		return out, convert(ToLambdaError(err))
+	}
+}
@@ -0,0 +1,57 @@
+package stacktrace_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestToLambdaError(t *testing.T) {
+	err := stacktrace.PropagateWithStringCode(startDoing(), "START_FAILED", "Failed to start")
+	lambdaErr := stacktrace.ToLambdaError(err)
+	assert.Equal(t, "START_FAILED", lambdaErr.ErrorType)
+	assert.Equal(t, "Failed to start: failed to start doing", lambdaErr.Error())
+	require.Len(t, lambdaErr.StackTrace, 2)
+	assert.Equal(t, "startDoing", lambdaErr.StackTrace[0].Label)
+	assert.Equal(t, "TestToLambdaError", lambdaErr.StackTrace[1].Label)
+
+	data, jerr := json.Marshal(stacktrace.ToLambdaError(stacktrace.NewErrorWithCode(EcodeNotImplemented, "Not yet")))
+	require.NoError(t, jerr)
+	assert.Regexp(t, `^\{"errorType":"4","errorMessage":"Not yet","stackTrace":\[\{"path":"github.com/palantir/Stacktrace/lambda_test.go","line":\d+,"label":"TestToLambdaError"\}\]\}$`, string(data))
+
+	assert.Equal(t, "Stacktrace", stacktrace.ToLambdaError(stacktrace.NewError("failed")).ErrorType)
+	_, statErr := os.Stat("/does/not/exist")
+	assert.Equal(t, &stacktrace.LambdaError{ErrorType: "PathError", ErrorMessage: statErr.Error()}, stacktrace.ToLambdaError(statErr))
+	assert.Nil(t, stacktrace.ToLambdaError(nil))
+}
+
+func TestWrapLambdaHandler(t *testing.T) {
+	handler := func(ctx context.Context, n int) (string, error) {
+		if n < 0 {
+			return "", stacktrace.NewErrorWithStringCode("NEGATIVE", "Negative input %d", n)
+		}
+		return "ok", nil
+	}
+
+	wrapped := stacktrace.WrapLambdaHandler(handler, nil)
+	out, err := wrapped(context.Background(), 1)
+	assert.Equal(t, "ok", out)
+	assert.NoError(t, err)
+	_, err = wrapped(context.Background(), -1)
+	var lambdaErr *stacktrace.LambdaError
+	require.True(t, errors.As(err, &lambdaErr))
+	assert.Equal(t, "NEGATIVE", lambdaErr.ErrorType)
+
+	converted := stacktrace.WrapLambdaHandler(handler, func(e *stacktrace.LambdaError) error {
+		return errors.New(e.ErrorType + ": " + e.ErrorMessage)
+	})
+	_, err = converted(context.Background(), -2)
+	assert.EqualError(t, err, "NEGATIVE: Negative input -2")
+}
@@ -0,0 +1,73 @@
+package stacktrace
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+)
+
+/*
+NewErrorLazy is like NewError, but only calls msg to produce the Message when it
+is first needed, such as when the error is formatted. This avoids the cost of
+expensive rendering, like marshaling a large payload, for errors that are
+handled without ever being logged:
+
+	return Stacktrace.NewErrorLazy(func() string {
+		body, _ := json.Marshal(req)
+		return fmt.Sprintf("Invalid request %s", body)
+	})
+
+The Message field of the error stays empty; use GetMessage or the formats to get
+the Message. msg is called at most once, and may be called from any goroutine.
+*/
+func NewErrorLazy(msg func() string) error {
+	return createWith(nil, NoCode, lazyMessage(msg), "")
+}
+
+/*
+PropagateLazy is like Propagate, but only calls msg to produce the Message when
+it is first needed, like NewErrorLazy. If Cause is nil, PropagateLazy returns nil
+without calling msg.
+*/
+func PropagateLazy(cause error, msg func() string) error {
+	if cause == nil {
+		// Allow calling PropagateLazy without checking whether there is error
+		return nil
+	}
+	return createWith(cause, NoCode, lazyMessage(msg), "")
+}
+
+type lazyText struct {
+	once sync.Once
+	fn   func() string
+	text string
+	// pc is the entry of fn, which identifies the message without producing it
+	pc uintptr
+}
+
+func lazyMessage(msg func() string) func(*Stacktrace) {
+	return func(st *Stacktrace) {
+		st.lazy = &lazyText{fn: msg, pc: reflect.ValueOf(msg).Pointer()}
+	}
+}
+
+// message returns the Message of st, producing it first if it is lazy.
+func (st *Stacktrace) message() string {
+	if st.lazy == nil {
+		return st.Message
+	}
+	st.lazy.once.Do(func() {
+		st.lazy.text = truncate(st.lazy.fn(), MaxMessageLength)
+		st.lazy.fn = nil
+	})
+	return st.lazy.text
+}
+
+// source returns the name of the function that produces the lazy message, such
+// as "main.handle.func1" for a function literal in main.handle.
+func (l *lazyText) source() string {
+	if fn := runtime.FuncForPC(l.pc); fn != nil {
+		return fn.Name()
+	}
+	return ""
+}
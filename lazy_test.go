@@ -0,0 +1,33 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestLazy(t *testing.T) {
+	calls := 0
+	inner := stacktrace.NewErrorLazy(func() string {
+		calls++
+		return "expensive"
+	})
+	outer := stacktrace.PropagateLazy(inner, func() string { return "outer" })
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, "TestLazy", inner.(*stacktrace.Stacktrace).Function)
+	assert.Equal(t, "", inner.(*stacktrace.Stacktrace).Message)
+
+	assert.Equal(t, "outer: expensive", fmt.Sprintf("%#s", outer))
+	assert.Equal(t, errors.New("expensive"), stacktrace.GetMessage(inner))
+	assert.Equal(t, errors.New("expensive"), stacktrace.RootCause(outer))
+	assert.Equal(t, 1, calls)
+
+	assert.Nil(t, stacktrace.PropagateLazy(nil, func() string {
+		t.Fatal("unexpected call for nil cause")
+		return ""
+	}))
+}
@@ -0,0 +1,30 @@
+package stacktrace
+
+/*
+NewErrorLiteral is like NewError, but uses msg as the Message as is, rather than
+as a format string. Use it for messages that come from elsewhere and may contain
+'%' characters:
+
+	return Stacktrace.NewErrorLiteral(resp.Status)
+*/
+func NewErrorLiteral(msg string) error {
+	return createWith(nil, NoCode, literalMessage(msg), "")
+}
+
+/*
+PropagateLiteral is like Propagate, but uses msg as the Message as is, rather
+than as a format string. If Cause is nil, PropagateLiteral returns nil.
+*/
+func PropagateLiteral(cause error, msg string) error {
+	if cause == nil {
+		// Allow calling PropagateLiteral without checking whether there is error
+		return nil
+	}
+	return createWith(cause, NoCode, literalMessage(msg), "")
+}
+
+func literalMessage(msg string) func(*Stacktrace) {
+	return func(st *Stacktrace) {
+		st.Message = truncate(msg, MaxMessageLength)
+	}
+}
@@ -0,0 +1,108 @@
+package stacktrace
+
+import (
+	"errors"
+	"maps"
+	"strings"
+)
+
+/*
+CaptureArgs controls whether errors keep the arguments formatted into their
+Message, along with the format string, for Localize and Template. It is off by
+default because the arguments may be large objects, such as requests or
+buffers, which would then live as long as the error. When it is off, levels
+whose Message was formatted with arguments keep their Message in Localize, and
+Template returns "" for them. Format strings without arguments are always kept.
+*/
+var CaptureArgs = false
+
+/*
+Localize renders err like the brief format, but with the Message of each level
+produced by catalog, so user-facing layers can show errors in the user's
+language while logs keep the English rendering. catalog is called with the
+message ID, which is the format string passed to NewError, Propagate and
+friends, and with the arguments formatted into it, after SanitizeArgs:
+
+	text := Stacktrace.Localize(err, func(id string, args []interface{}) (string, bool) {
+		tmpl, ok := catalogs[lang][id]
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf(tmpl, args...), true
+	})
+
+Levels for which catalog returns false, levels whose Message did not come from
+a format string, such as those of NewErrorLiteral, and levels with arguments
+created while CaptureArgs was disabled keep their Message.
+Causes that are not Stacktraces are rendered with their Error method.
+*/
+func Localize(err error, catalog func(id string, args []interface{}) (string, bool)) string {
+	if err == nil {
+		return ""
+	}
+	return localize(err, catalog, make(map[*Stacktrace]bool))
+}
+
+func localize(err error, catalog func(id string, args []interface{}) (string, bool), seen map[*Stacktrace]bool) string {
+	if st, ok := err.(*Stacktrace); ok && st != nil {
+		levels, truncated := chainFrom(st, seen)
+		if len(levels) == 0 {
+			return truncatedMarker
+		}
+		var parts []string
+		for _, curr := range levels {
+			if msg := localizedMessage(curr, catalog); msg != "" {
+				parts = append(parts, msg)
+			}
+		}
+		if last := levels[len(levels)-1]; truncated {
+			parts = append(parts, truncatedMarker)
+		} else if last.Cause != nil {
+			parts = append(parts, localize(last.Cause, catalog, seen))
+		}
+		return strings.Join(parts, ": ")
+	}
+	causes, ok := branchesOf(err)
+	if !ok {
+		return err.Error()
+	}
+	texts := make([]string, len(causes))
+	for i, cause := range causes {
+		texts[i] = localize(cause, catalog, maps.Clone(seen))
+	}
+	return "[" + strings.Join(texts, "; ") + "]"
+}
+
+// localizedMessage returns the Message of st as produced by catalog, or its
+// own Message if catalog has none for it.
+func localizedMessage(st *Stacktrace, catalog func(id string, args []interface{}) (string, bool)) string {
+	if st.format != "" && !st.argsDropped {
+		if msg, ok := catalog(st.format, append([]interface{}(nil), st.args...)); ok {
+			return msg
+		}
+	}
+	return st.message()
+}
+
+/*
+Template returns the format string and the arguments that the Message of err
+was rendered from, with SanitizeArgs applied to the arguments, so that
+downstream systems can re-render the Message, redact individual arguments, or
+group errors by template rather than by rendered Message:
+
+	format, args := Stacktrace.Template(err)
+	metrics.Count("errors", "template", format)
+
+Only the outermost Stacktrace error in err is considered, even if its Message
+is empty. Template returns "" and nil if err is not or does not wrap a
+Stacktrace error, if the Message was not rendered from a format string, as
+for NewErrorLiteral and NewErrorLazy, and if it had arguments but CaptureArgs
+was disabled. The returned slice is a copy.
+*/
+func Template(err error) (format string, args []interface{}) {
+	var st *Stacktrace
+	if !errors.As(err, &st) || st == nil || st.format == "" || st.argsDropped {
+		return "", nil
+	}
+	return st.format, append([]interface{}(nil), st.args...)
+}
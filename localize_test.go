@@ -0,0 +1,102 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestLocalize(t *testing.T) {
+	defer func(capture bool) { stacktrace.CaptureArgs = capture }(stacktrace.CaptureArgs)
+	stacktrace.CaptureArgs = true
+	catalog := map[string]string{
+		"Failed to load %s":       "Impossible de charger %s",
+		"Invalid villain %q":      "Méchant invalide %q",
+		"Token %s for user %d":    "Jeton %s pour l'utilisateur %d",
+		"There isn't enough time": "Il n'y a pas assez de temps",
+	}
+	translate := func(id string, args []interface{}) (string, bool) {
+		tmpl, ok := catalog[id]
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf(tmpl, args...), true
+	}
+
+	assert.Equal(t, "", stacktrace.Localize(nil, translate))
+	assert.Equal(t, "plain", stacktrace.Localize(errors.New("plain"), translate))
+
+	err := stacktrace.NewError("Invalid villain %q", "Joker")
+	err = stacktrace.Propagate(err, "")
+	err = stacktrace.PropagateLiteral(err, "Failed to load %s")
+	err = stacktrace.Propagate(err, "Not in the catalog")
+	err = stacktrace.Propagate(err, "Failed to load %s", "config")
+	assert.Equal(t, `Impossible de charger config: Not in the catalog: Failed to load %s: Méchant invalide "Joker"`, stacktrace.Localize(err, translate))
+	assert.Equal(t, `Failed to load config: Not in the catalog: Failed to load %s: Invalid villain "Joker"`, fmt.Sprintf("%#s", err))
+
+	err = stacktrace.PropagateAll([]error{
+		stacktrace.NewMessageWithCode(EcodeNotFastEnough, "There isn't enough time"),
+		errors.New("plain"),
+	}, "Failed to load %s", "everything")
+	assert.Equal(t, "Impossible de charger everything: [Il n'y a pas assez de temps; plain]", stacktrace.Localize(err, translate))
+
+	defer func(sanitize func([]interface{}) []interface{}) { stacktrace.SanitizeArgs = sanitize }(stacktrace.SanitizeArgs)
+	stacktrace.SanitizeArgs = func(vals []interface{}) []interface{} {
+		vals[0] = "<token>"
+		return vals
+	}
+	err = stacktrace.NewError("Token %s for user %d", "s3cr3t", 7)
+	assert.Equal(t, "Jeton <token> pour l'utilisateur 7", stacktrace.Localize(err, translate))
+}
+
+func TestTemplate(t *testing.T) {
+	defer func(capture bool) { stacktrace.CaptureArgs = capture }(stacktrace.CaptureArgs)
+	stacktrace.CaptureArgs = true
+	format, args := stacktrace.Template(nil)
+	assert.Equal(t, "", format)
+	assert.Nil(t, args)
+	format, args = stacktrace.Template(errors.New("plain"))
+	assert.Equal(t, "", format)
+	assert.Nil(t, args)
+	format, args = stacktrace.Template(stacktrace.NewErrorLiteral("100%"))
+	assert.Equal(t, "", format)
+	assert.Nil(t, args)
+
+	err := stacktrace.NewError("Invalid villain %q", "Joker")
+	err = stacktrace.Propagate(err, "Failed after %d attempts in %v", 3, "Gotham")
+	format, args = stacktrace.Template(fmt.Errorf("wrapped: %w", err))
+	assert.Equal(t, "Failed after %d attempts in %v", format)
+	assert.Equal(t, []interface{}{3, "Gotham"}, args)
+
+	// the returned arguments are a copy
+	args[0] = 4
+	_, args = stacktrace.Template(err)
+	assert.Equal(t, []interface{}{3, "Gotham"}, args)
+
+	format, args = stacktrace.Template(stacktrace.NewMessageWithCode(EcodeNotFastEnough, "No args"))
+	assert.Equal(t, "No args", format)
+	assert.Empty(t, args)
+}
+
+func TestCaptureArgsDisabled(t *testing.T) {
+	translate := func(id string, args []interface{}) (string, bool) {
+		return "Impossible de charger " + fmt.Sprint(args...), true
+	}
+
+	err := stacktrace.NewError("Failed to load %s", "config")
+	format, args := stacktrace.Template(err)
+	assert.Equal(t, "", format)
+	assert.Nil(t, args)
+	assert.Equal(t, "Failed to load config", stacktrace.Localize(err, translate))
+	// the format still groups errors without the arguments
+	assert.Equal(t, stacktrace.Fingerprint(err), stacktrace.Fingerprint(stacktrace.NewError("Failed to load %s", "secrets")))
+
+	err = stacktrace.NewError("Failed to load")
+	format, _ = stacktrace.Template(err)
+	assert.Equal(t, "Failed to load", format)
+	assert.Equal(t, "Impossible de charger ", stacktrace.Localize(err, translate))
+}
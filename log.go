@@ -0,0 +1,162 @@
+package stacktrace
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+// Level is the severity at which Log emits an error.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+/*
+LoggerFunc adapts any logging function to Log. It receives the brief error
+message and the structured fields that Log would emit. For example, with
+logrus:
+
+	logger := Stacktrace.LoggerFunc(func(level Stacktrace.Level, msg string, fields map[string]interface{}) {
+		logrus.WithFields(fields).Log(logrusLevels[level], msg)
+	})
+*/
+type LoggerFunc func(level Level, msg string, fields map[string]interface{})
+
+// slogLogger is implemented by *slog.Logger.
+type slogLogger interface {
+	Log(ctx context.Context, level slog.Level, msg string, args ...interface{})
+}
+
+// sugaredLogger is implemented by zap's *SugaredLogger.
+type sugaredLogger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+// printer is implemented by the standard library's *log.Logger.
+type printer interface {
+	Print(v ...interface{})
+}
+
+/*
+Log emits err to logger at the Level of its Severity, or at LevelError if it
+has none. See LogAt.
+
+	if err := handle(req); err != nil {
+		Stacktrace.Log(logger, err)
+	}
+*/
+func Log(logger interface{}, err error) {
+	LogAt(logger, GetSeverity(err).Level(), err)
+}
+
+/*
+LogAt emits err to logger as a single structured record. The record's message
+is the brief format of err, and its fields are:
+
+	code        the error Code, if there is one
+	string_code the string Code, if there is one
+	severity    the Severity, if there is one
+	trace_id    the trace ID attached by PropagateCtx, if there is one
+	span_id     the span ID attached by PropagateCtx, if there is one
+	error_time  when the error was created, if CaptureTimestamps is enabled
+	location    "File:Line" of the outermost call site, if known
+	stacktrace  the full format of err, only at LevelError
+
+logger can be a *slog.Logger, a zap *SugaredLogger, a LoggerFunc, or anything
+with a Print method like the standard library's *log.Logger, which receives the
+message followed by "key=value" for each field. Other values fall back to the standard
+library's default logger. If err is nil, LogAt does nothing.
+*/
+func LogAt(logger interface{}, level Level, err error) {
+	if err == nil {
+		return
+	}
+
+	msg := err.Error()
+	var keysAndValues []interface{}
+	if st, ok := err.(*Stacktrace); ok {
+		msg = formatBrief(st)
+		if st.Code != NoCode {
+			keysAndValues = append(keysAndValues, "code", int(st.Code))
+		}
+		if st.StringCode != "" {
+			keysAndValues = append(keysAndValues, "string_code", st.StringCode)
+		}
+		if st.Severity != SeverityUnset {
+			keysAndValues = append(keysAndValues, "severity", st.Severity.String())
+		}
+		if st.TraceID != "" {
+			keysAndValues = append(keysAndValues, "trace_id", st.TraceID, "span_id", st.SpanID)
+		}
+		if t := Timestamp(st); !t.IsZero() {
+			keysAndValues = append(keysAndValues, "error_time", t)
+		}
+		if st.File != "" {
+			keysAndValues = append(keysAndValues, "location", fmt.Sprintf("%s:%d", st.File, st.Line))
+		}
+		if level >= LevelError {
+			keysAndValues = append(keysAndValues, "stacktrace", formatFull(st))
+		}
+	}
+
+	switch logger := logger.(type) {
+	case LoggerFunc:
+		fields := make(map[string]interface{}, len(keysAndValues)/2)
+		for i := 0; i < len(keysAndValues); i += 2 {
+			fields[keysAndValues[i].(string)] = keysAndValues[i+1]
+		}
+		logger(level, msg, fields)
+	case slogLogger:
+		logger.Log(context.Background(), slogLevel(level), msg, keysAndValues...)
+	case sugaredLogger:
+		map[Level]func(string, ...interface{}){
+			LevelDebug: logger.Debugw,
+			LevelInfo:  logger.Infow,
+			LevelWarn:  logger.Warnw,
+			LevelError: logger.Errorw,
+		}[clampLevel(level)](msg, keysAndValues...)
+	case printer:
+		logger.Print(joinFields(msg, keysAndValues))
+	default:
+		log.Print(joinFields(msg, keysAndValues))
+	}
+}
+
+// joinFields renders msg followed by " key=value" for each field.
+func joinFields(msg string, keysAndValues []interface{}) string {
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", keysAndValues[i], keysAndValues[i+1])
+	}
+	return b.String()
+}
+
+func clampLevel(level Level) Level {
+	if level < LevelDebug {
+		return LevelDebug
+	}
+	if level > LevelError {
+		return LevelError
+	}
+	return level
+}
+
+func slogLevel(level Level) slog.Level {
+	return map[Level]slog.Level{
+		LevelDebug: slog.LevelDebug,
+		LevelInfo:  slog.LevelInfo,
+		LevelWarn:  slog.LevelWarn,
+		LevelError: slog.LevelError,
+	}[clampLevel(level)]
+}
@@ -0,0 +1,78 @@
+package stacktrace_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+type fakeSugaredLogger struct {
+	lines []string
+}
+
+func (l *fakeSugaredLogger) record(level, msg string, keysAndValues []interface{}) {
+	l.lines = append(l.lines, fmt.Sprint(level, " ", msg, " ", keysAndValues))
+}
+
+func (l *fakeSugaredLogger) Debugw(msg string, kv ...interface{}) { l.record("debug", msg, kv) }
+func (l *fakeSugaredLogger) Infow(msg string, kv ...interface{})  { l.record("info", msg, kv) }
+func (l *fakeSugaredLogger) Warnw(msg string, kv ...interface{})  { l.record("warn", msg, kv) }
+func (l *fakeSugaredLogger) Errorw(msg string, kv ...interface{}) { l.record("error", msg, kv) }
+
+func TestLog(t *testing.T) {
+	err := stacktrace.PropagateWithCode(errors.New("plain"), EcodeNotFastEnough, "decorated")
+	full := fmt.Sprintf("%+s", err)
+	location := fmt.Sprintf("%s:%d", err.(*stacktrace.Stacktrace).File, err.(*stacktrace.Stacktrace).Line)
+
+	var level stacktrace.Level
+	var msg string
+	var fields map[string]interface{}
+	stacktrace.Log(stacktrace.LoggerFunc(func(l stacktrace.Level, m string, f map[string]interface{}) {
+		level, msg, fields = l, m, f
+	}), err)
+	assert.Equal(t, stacktrace.LevelError, level)
+	assert.Equal(t, "decorated: plain", msg)
+	assert.Equal(t, map[string]interface{}{
+		"code":       int(EcodeNotFastEnough),
+		"location":   location,
+		"stacktrace": full,
+	}, fields)
+
+	sugared := &fakeSugaredLogger{}
+	stacktrace.LogAt(sugared, stacktrace.LevelWarn, err)
+	stacktrace.LogAt(sugared, stacktrace.LevelInfo, errors.New("plain"))
+	stacktrace.LogAt(sugared, stacktrace.LevelInfo, nil)
+	assert.Equal(t, []string{
+		fmt.Sprintf("warn decorated: plain [code %d location %s]", EcodeNotFastEnough, location),
+		"info plain []",
+	}, sugared.lines)
+
+	var buf bytes.Buffer
+	stacktrace.Log(log.New(&buf, "", 0), stacktrace.NewMessageWithCode(EcodeTimeIsIllusion, "msg"))
+	assert.Equal(t, fmt.Sprintf("msg code=%d stacktrace=msg\n", EcodeTimeIsIllusion), buf.String())
+}
+
+func TestLogSlog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	err := stacktrace.NewErrorWithCode(EcodeNoSuchPseudo, "no such pseudo")
+	stacktrace.LogAt(logger, stacktrace.LevelWarn, err)
+	expected := fmt.Sprintf(`level=WARN msg="no such pseudo" code=%d location=github.com/palantir/Stacktrace/log_test.go:#`, EcodeNoSuchPseudo)
+	assert.Equal(t, expected, normalizeLines(strings.TrimSpace(buf.String())))
+}
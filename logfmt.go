@@ -0,0 +1,67 @@
+package stacktrace
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// formatLogfmt renders st as logfmt, for example:
+//
+//	msg="Failed to load config" code=3 cause="open /etc/app.yaml: no such file or directory" file=app/config.go line=44
+//
+// The cause is the brief format of the rest of the chain. Empty values and
+// NoCode are omitted.
+func formatLogfmt(st *Stacktrace) string {
+	var b strings.Builder
+	field := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(value))
+	}
+
+	field("msg", st.message())
+	if st.Code != NoCode {
+		field("code", strconv.Itoa(int(st.Code)))
+	}
+	if st.StringCode != "" {
+		field("string_code", st.StringCode)
+	}
+	if st.Severity != SeverityUnset {
+		field("severity", st.Severity.String())
+	}
+	if cause, ok := st.Cause.(*Stacktrace); ok {
+		field("cause", formatBrief(cause))
+	} else if st.Cause != nil {
+		field("cause", st.Cause.Error())
+	}
+	if st.File != "" {
+		field("file", st.File)
+		field("line", strconv.Itoa(st.Line))
+	}
+	field("trace_id", st.TraceID)
+	field("span_id", st.SpanID)
+	if !st.Time.IsZero() {
+		field("time", st.Time.Format(time.RFC3339Nano))
+	}
+	return b.String()
+}
+
+// logfmtValue quotes value if it contains anything besides printable,
+// non-space characters other than '=' and '"'.
+func logfmtValue(value string) string {
+	needsQuotes := strings.IndexFunc(value, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || !unicode.IsPrint(r)
+	}) >= 0
+	if needsQuotes {
+		return strconv.Quote(value)
+	}
+	return value
+}
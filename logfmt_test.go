@@ -0,0 +1,60 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestFormatLogfmt(t *testing.T) {
+	defer func(format stacktrace.Format) { stacktrace.DefaultFormat = format }(stacktrace.DefaultFormat)
+	stacktrace.DefaultFormat = stacktrace.FormatLogfmt
+
+	for _, test := range []struct {
+		err      error
+		expected string
+	}{
+		{
+			err:      stacktrace.NewError("failed"),
+			expected: "msg=failed file=github.com/palantir/Stacktrace/logfmt_test.go line=#",
+		},
+		{
+			err:      stacktrace.PropagateWithCode(errors.New(`bad "quote"`), EcodeInvalidVillain, "Failed to load"),
+			expected: fmt.Sprintf(`msg="Failed to load" code=%d cause="bad \"quote\"" file=github.com/palantir/Stacktrace/logfmt_test.go line=#`, EcodeInvalidVillain),
+		},
+		{
+			err:      stacktrace.Propagate(stacktrace.Propagate(errors.New("root"), "middle"), "top=1"),
+			expected: `msg="top=1" cause="middle: root" file=github.com/palantir/Stacktrace/logfmt_test.go line=#`,
+		},
+		{
+			err:      stacktrace.Propagate(stacktrace.NewErrorWithStringCode("RATE_LIMITED", "slow down"), "failed"),
+			expected: `msg=failed string_code=RATE_LIMITED cause="slow down" file=github.com/palantir/Stacktrace/logfmt_test.go line=#`,
+		},
+		{
+			err:      stacktrace.Propagate(stacktrace.NewErrorWithSeverity(stacktrace.SeverityWarning, "degraded"), "failed"),
+			expected: `msg=failed severity=warning cause=degraded file=github.com/palantir/Stacktrace/logfmt_test.go line=#`,
+		},
+		{
+			err:      &stacktrace.Stacktrace{Message: "traced", Code: stacktrace.NoCode, TraceID: "4bf92f35", SpanID: "00f067aa"},
+			expected: "msg=traced trace_id=4bf92f35 span_id=00f067aa",
+		},
+		{
+			err:      &stacktrace.Stacktrace{Message: "timed", Code: stacktrace.NoCode, Time: time.Date(2016, 3, 1, 12, 30, 0, 500, time.UTC)},
+			expected: "msg=timed time=2016-03-01T12:30:00.0000005Z",
+		},
+		{
+			err:      stacktrace.NewMessageWithCode(EcodeNoSuchPseudo, "multi\nline"),
+			expected: fmt.Sprintf(`msg="multi\nline" code=%d`, EcodeNoSuchPseudo),
+		},
+	} {
+		assert.Equal(t, test.expected, logfmtLines.ReplaceAllString(test.err.Error(), "line=#"))
+	}
+}
+
+var logfmtLines = regexp.MustCompile(`line=\d+`)
@@ -0,0 +1,26 @@
+package stacktrace
+
+import "strconv"
+
+/*
+MarshalLog implements the Marshaler interface of github.com/go-logr/logr, so
+that loggers built on logr, like the one of controller-runtime, log errors
+passed as values as structured data rather than as the multi-line full format:
+
+	log.Info("Retrying reconcile", "err", err)
+
+The value holds the same fields as the groups of NewSlogHandler: the brief
+format as msg, the fields that LogAt emits, location and frames.
+*/
+func (st *Stacktrace) MarshalLog() interface{} {
+	if st == nil {
+		return nil
+	}
+	value := errorMetadata(st)
+	value["msg"] = formatBrief(st)
+	if frames := locations(st); len(frames) > 0 {
+		value["location"] = st.File + ":" + strconv.Itoa(st.Line)
+		value["frames"] = frames
+	}
+	return value
+}
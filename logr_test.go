@@ -0,0 +1,30 @@
+package stacktrace_test
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+)
+
+var _ logr.Marshaler = (*stacktrace.Stacktrace)(nil)
+
+func TestMarshalLog(t *testing.T) {
+	var logged []string
+	logger := funcr.NewJSON(func(obj string) { logged = append(logged, obj) }, funcr.Options{})
+
+	err := stacktrace.PropagateWithStringCode(startDoing(), "START_FAILED", "Failed to start")
+	logger.Info("Retrying", "err", err)
+
+	require.Len(t, logged, 1)
+	assert.Regexp(t, `"err":\{.*"msg":"Failed to start: failed to start doing"`, logged[0])
+	assert.Regexp(t, `"string_code":"START_FAILED"`, logged[0])
+	assert.Regexp(t, `"frames":\["github.com/palantir/Stacktrace/logr_test.go:\d+ \(TestMarshalLog\)","github.com/palantir/Stacktrace/functions_for_test.go:\d+ \(startDoing\)"\]`, logged[0])
+	assert.NotContains(t, logged[0], `\n`)
+
+	assert.Nil(t, (*stacktrace.Stacktrace)(nil).MarshalLog())
+}
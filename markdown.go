@@ -0,0 +1,44 @@
+package stacktrace
+
+import (
+	"fmt"
+	"strings"
+)
+
+// markdownEscaper escapes characters with special meaning in inline markdown.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`, "\n", " ",
+)
+
+/*
+FormatMarkdown renders err for pasting into a GitHub issue or a chat message:
+the brief format in bold, followed by the full Stacktrace in a fenced code
+block.
+
+	**Failed to register for villain discovery: Inverse tachyon pulse failed**
+
+	```
+	Failed to register for villain discovery
+	 --- at github.com/palantir/shield/agent/discovery.go:265 (ShieldAgent.reallyRegister) ---
+	...
+	```
+
+FormatMarkdown returns an empty string if err is nil.
+*/
+func FormatMarkdown(err error) string {
+	if err == nil {
+		return ""
+	}
+	summary, full := err.Error(), err.Error()
+	if st, ok := err.(*Stacktrace); ok {
+		summary, full = formatBrief(st), formatFull(st)
+	}
+
+	// The fence must be longer than any run of backticks in the content.
+	fence := "```"
+	for strings.Contains(full, fence) {
+		fence += "`"
+	}
+	return fmt.Sprintf("**%s**\n\n%s\n%s\n%s\n", markdownEscaper.Replace(summary), fence, full, fence)
+}
@@ -0,0 +1,21 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestFormatMarkdown(t *testing.T) {
+	err := stacktrace.Propagate(errors.New("plain"), "decorated *bold*")
+	expected := "**decorated \\*bold\\*: plain**\n\n```\n" +
+		"decorated *bold*\n --- at github.com/palantir/Stacktrace/markdown_test.go:# (TestFormatMarkdown) ---\nCaused by: plain\n" +
+		"```\n"
+	assert.Equal(t, expected, normalizeLines(stacktrace.FormatMarkdown(err)))
+
+	assert.Equal(t, "**uses \\`\\`\\`code\\`\\`\\`**\n\n````\nuses ```code```\n````\n", stacktrace.FormatMarkdown(errors.New("uses ```code```")))
+	assert.Equal(t, "", stacktrace.FormatMarkdown(nil))
+}
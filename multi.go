@@ -0,0 +1,43 @@
+package stacktrace
+
+// multiError is implemented by errors with several causes, such as the ones
+// returned by errors.Join and, in recent versions, go.uber.org/multierr.
+type multiError interface {
+	Unwrap() []error
+}
+
+// wrappedErrors is implemented by github.com/hashicorp/go-multierror.
+type wrappedErrors interface {
+	WrappedErrors() []error
+}
+
+// errorsLister is implemented by go.uber.org/multierr.
+type errorsLister interface {
+	Errors() []error
+}
+
+// branchesOf returns the non-nil causes of err if it is an error with several
+// causes, other than a *Stacktrace.
+func branchesOf(err error) ([]error, bool) {
+	var all []error
+	switch err := err.(type) {
+	case *Stacktrace:
+		return nil, false
+	case multiError:
+		all = err.Unwrap()
+	case wrappedErrors:
+		all = err.WrappedErrors()
+	case errorsLister:
+		all = err.Errors()
+	default:
+		return nil, false
+	}
+
+	var causes []error
+	for _, cause := range all {
+		if cause != nil {
+			causes = append(causes, cause)
+		}
+	}
+	return causes, true
+}
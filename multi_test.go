@@ -0,0 +1,55 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+// hashicorpMultiError mimics *multierror.Error from github.com/hashicorp/go-multierror.
+type hashicorpMultiError struct {
+	Errors []error
+}
+
+func (e *hashicorpMultiError) Error() string          { return fmt.Sprintf("%d errors occurred", len(e.Errors)) }
+func (e *hashicorpMultiError) WrappedErrors() []error { return e.Errors }
+
+// uberMultiError mimics the errors of older versions of go.uber.org/multierr.
+type uberMultiError struct {
+	errors []error
+}
+
+func (e *uberMultiError) Error() string   { return "multierr" }
+func (e *uberMultiError) Errors() []error { return e.errors }
+
+func TestMultiErrorInterop(t *testing.T) {
+	for _, multi := range []func(errs ...error) error{
+		errors.Join,
+		func(errs ...error) error { return &hashicorpMultiError{Errors: errs} },
+		func(errs ...error) error { return &uberMultiError{errors: errs} },
+	} {
+		plain := errors.New("plain")
+		coded := stacktrace.NewErrorWithCode(EcodeNotImplemented, "coded")
+		err := stacktrace.Propagate(multi(plain, nil, coded), "decorated")
+
+		assert.Equal(t, EcodeNotImplemented, stacktrace.GetCode(multi(plain, coded)))
+		assert.Equal(t, EcodeNotImplemented, stacktrace.GetCode(err))
+		assert.Equal(t, stacktrace.NoCode, stacktrace.GetCode(multi(plain)))
+		assert.Equal(t, plain, stacktrace.RootCause(err))
+		assert.Equal(t, errors.New("coded"), stacktrace.RootCause(multi(coded, plain)))
+
+		assert.Equal(t, "decorated: [plain; coded]", fmt.Sprintf("%#s", err))
+		assert.Equal(t, strings.Join([]string{
+			"decorated",
+			" --- at github.com/palantir/Stacktrace/multi_test.go:# (TestMultiErrorInterop) ---",
+			"Caused by (1 of 2): plain",
+			"Caused by (2 of 2): coded",
+			"     --- at github.com/palantir/Stacktrace/multi_test.go:# (TestMultiErrorInterop) ---",
+		}, "\n"), normalizeLines(fmt.Sprintf("%+s", err)))
+	}
+}
@@ -0,0 +1,22 @@
+/*
+Package otelstacktrace links Stacktrace errors to OpenTelemetry traces:
+
+	Stacktrace.TraceIDsFromContext = otelstacktrace.TraceIDs
+*/
+package otelstacktrace
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceIDs returns the IDs of the span in ctx as hex strings, or "" if ctx has
+// no valid span. It is meant for stacktrace.TraceIDsFromContext.
+func TraceIDs(ctx context.Context) (traceID, spanID string) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return "", ""
+	}
+	return sc.TraceID().String(), sc.SpanID().String()
+}
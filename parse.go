@@ -0,0 +1,305 @@
+package stacktrace
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ansiEscape   = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	atLine       = regexp.MustCompile(`^ --- at (.*) ---((?: \[(?:id|code)=[^\]\s]+\])*)(?: \(repeated (\d+) times\))?$`)
+	stackLine    = regexp.MustCompile(`^(?:     |   \* )at (.*)$`)
+	packageLine  = regexp.MustCompile(`^     in (.*):$`)
+	groupedLine  = regexp.MustCompile(`^(?:         |       \* )(.*)$`)
+	moreLine     = regexp.MustCompile(`^     \.\.\. (\d+) more$`)
+	omittedCount = regexp.MustCompile(`^     \(\+(\d+) frames? omitted\)$`)
+	sourceLine   = regexp.MustCompile(`^(?:   > |     ) *\d+ \| `)
+	branchLine   = regexp.MustCompile(`^Caused by \((\d+) of (\d+)\):( .*)?$`)
+	location     = regexp.MustCompile(`^(.*):(\d+)(?: \((.*)\))?$`)
+	messageLabel = regexp.MustCompile(`(?s)^(.*?)((?: \[id=[^\]\s]+\])?(?: \[code=[^\]\s]+\])?)$`)
+	label        = regexp.MustCompile(`\[(id|code)=([^\]\s]+)\]`)
+	omittedLine  = regexp.MustCompile(`^(?:     )?\(\+\d+ (?:frame|cause)s? omitted\)$`)
+)
+
+const (
+	causedBy   = "Caused by: "
+	suppressed = "    Suppressed:"
+)
+
+/*
+Parse reconstructs an error from its full format, as written to logs by "%+v"
+or "%+s", so that tools can work with the chain of an error that is only
+available as text:
+
+	st, err := Stacktrace.Parse(logEntry)
+	if err != nil {
+		return err
+	}
+	fmt.Println(st.File, st.Line, Stacktrace.GetCode(st))
+
+The result has the Message, File, Line, Function, Stack and OmittedFrames of
+every level of the chain, its suppressed errors and multiple causes, and the
+boundaries of chains merged by Graft. Codes and IDs are recovered if the text
+shows them, see ShowCodes. Causes that are not Stacktraces become plain errors
+with the same text. Source excerpts and colors are ignored, and the Build line
+and goroutine dump are dropped. Frames and levels omitted to fit MaxFormatSize
+are missing from the result, as is anything the full format does not show, like
+the Time of an error.
+
+Parse returns an error if s does not start with a level of a Stacktrace, or if
+its structure is damaged, for example because lines have lost their
+indentation. Output produced with a FrameTemplate cannot be parsed.
+*/
+func Parse(s string) (*Stacktrace, error) {
+	s = strings.ReplaceAll(ansiEscape.ReplaceAllString(s, ""), "\r\n", "\n")
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	// The dump of WithGoroutineDump comes after the trace. The lines of a dump
+	// are never equal to its header, so the last header followed by a
+	// goroutine is the one of the dump, and a Message with the same text as
+	// the header is kept.
+	for i := len(lines) - 2; i >= 0; i-- {
+		if lines[i] == goroutineDumpHeader && strings.HasPrefix(lines[i+1], "goroutine ") {
+			lines = lines[:i]
+			break
+		}
+	}
+	if n := len(lines); n > 0 && strings.HasPrefix(lines[n-1], "Build: ") {
+		lines = lines[:n-1]
+	}
+	if _, ok := messageLines(lines); !ok {
+		return nil, errors.New("stacktrace: text does not start with a Stacktrace")
+	}
+	return parseLevel(lines, nil)
+}
+
+// parseBlock parses lines holding the full format of any error in a chain,
+// which are a level of a Stacktrace, several causes or the text of another
+// error. enclosing is the Stack of the level they are the Cause of.
+func parseBlock(lines []string, enclosing []Frame) (error, error) {
+	if len(lines) == 0 {
+		return nil, errors.New("stacktrace: missing cause")
+	}
+	if strings.HasPrefix(lines[0], "Caused by (1 of ") {
+		return parseBranches(lines)
+	}
+	if _, ok := messageLines(lines); !ok {
+		return errors.New(strings.Join(lines, "\n")), nil
+	}
+	st, err := parseLevel(lines, enclosing)
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// messageLines returns the number of lines holding the Message at the start of
+// a level, and whether lines start with a level at all: the Message is
+// followed by a " --- at" line or, for levels without a location, by their
+// suppressed errors or Cause.
+func messageLines(lines []string) (int, bool) {
+	for i, line := range lines {
+		if strings.HasPrefix(line, " --- at ") {
+			return i, true
+		}
+		if i > 0 && (strings.HasPrefix(line, "Caused by") || strings.HasPrefix(line, suppressed) || line == remoteBoundary || omittedLine.MatchString(line)) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func parseLevel(lines []string, enclosing []Frame) (*Stacktrace, error) {
+	st := &Stacktrace{}
+	n, _ := messageLines(lines)
+	var labels string
+	if n > 0 {
+		m := messageLabel.FindStringSubmatch(strings.Join(lines[:n], "\n"))
+		st.Message, labels = m[1], m[2]
+	}
+	lines = lines[n:]
+
+	repeats := 1
+	if len(lines) > 0 {
+		if m := atLine.FindStringSubmatch(lines[0]); m != nil {
+			frame, err := parseLocation(m[1])
+			if err != nil {
+				return nil, err
+			}
+			st.File, st.Line, st.Function = frame.File, frame.Line, frame.Function
+			if m[2] != "" {
+				labels = m[2]
+			}
+			if m[3] != "" {
+				repeats, _ = strconv.Atoi(m[3])
+			}
+			lines = lines[1:]
+		} else if strings.HasPrefix(lines[0], " --- at ") {
+			// Nothing would be consumed, and the Cause parsed from the same lines
+			return nil, fmt.Errorf("stacktrace: malformed location line %q", lines[0])
+		}
+	}
+
+	var stack []Frame
+	var pkg string
+	var omitted int
+	for ; len(lines) > 0; lines = lines[1:] {
+		if m := omittedCount.FindStringSubmatch(lines[0]); m != nil {
+			// Only the marker after the last frame is for OmittedFrames, the
+			// ones between frames are left by MaxFormatSize
+			omitted, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if sourceLine.MatchString(lines[0]) || omittedLine.MatchString(lines[0]) {
+			continue
+		}
+		if m := moreLine.FindStringSubmatch(lines[0]); m != nil {
+			shared, _ := strconv.Atoi(m[1])
+			if shared > len(enclosing) {
+				return nil, fmt.Errorf("stacktrace: %d more frames than enclosing stack", shared)
+			}
+			stack = append(stack, enclosing[len(enclosing)-shared:]...)
+			continue
+		}
+		if m := packageLine.FindStringSubmatch(lines[0]); m != nil {
+			pkg = m[1]
+			continue
+		}
+		if m := groupedLine.FindStringSubmatch(lines[0]); m != nil && pkg != "" {
+			frame, err := parseLocation(m[1])
+			if err != nil {
+				return nil, err
+			}
+			frame.File = pkg + "/" + frame.File
+			stack = append(stack, frame)
+			omitted = 0
+			continue
+		}
+		m := stackLine.FindStringSubmatch(lines[0])
+		if m == nil {
+			break
+		}
+		frame, err := parseLocation(m[1])
+		if err != nil {
+			return nil, err
+		}
+		pkg = ""
+		stack = append(stack, frame)
+		omitted = 0
+	}
+	if stack != nil {
+		enclosing = stack
+	}
+
+	var suppressedErrs []error
+	for len(lines) > 0 && strings.HasPrefix(lines[0], suppressed) {
+		var block []string
+		if rest := strings.TrimPrefix(lines[0], suppressed); rest != "" {
+			block = append(block, strings.TrimPrefix(rest, " "))
+		}
+		lines = lines[1:]
+		for len(lines) > 0 && strings.HasPrefix(lines[0], "        ") {
+			block = append(block, lines[0][8:])
+			lines = lines[1:]
+		}
+		err, perr := parseBlock(block, nil)
+		if perr != nil {
+			return nil, perr
+		}
+		suppressedErrs = append(suppressedErrs, err)
+	}
+
+	if len(lines) > 0 && omittedLine.MatchString(lines[0]) {
+		lines = lines[1:]
+	}
+	remote := len(lines) > 0 && lines[0] == remoteBoundary
+	if remote {
+		lines = lines[1:]
+	}
+	var cause error
+	var err error
+	switch {
+	case len(lines) == 0:
+	case lines[0] == truncatedMarker:
+		cause = errors.New(truncatedMarker)
+	case strings.HasPrefix(lines[0], "Caused by ("):
+		cause, err = parseBranches(lines)
+	case strings.HasPrefix(lines[0], causedBy):
+		cause, err = parseBlock(append([]string{strings.TrimPrefix(lines[0], causedBy)}, lines[1:]...), enclosing)
+	case strings.HasPrefix(lines[0], " --- at "):
+		// The Cause is a level without a Message
+		cause, err = parseBlock(lines, enclosing)
+	default:
+		return nil, fmt.Errorf("stacktrace: unexpected line %q", lines[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Collapsed repetitions of the location are levels without a Message, the
+	// last of which has the Stack and suppressed errors
+	levels := []*Stacktrace{st}
+	for i := 1; i < repeats; i++ {
+		levels = append(levels, &Stacktrace{File: st.File, Line: st.Line, Function: st.Function})
+	}
+	last := levels[len(levels)-1]
+	last.Stack, last.OmittedFrames, last.Suppressed, last.Cause, last.remoteCause = stack, omitted, suppressedErrs, cause, remote
+	for i := len(levels) - 1; i >= 0; i-- {
+		if i < len(levels)-1 {
+			levels[i].Cause = levels[i+1]
+		}
+		// Codes and IDs are only shown where they change
+		levels[i].Code, levels[i].ID = GetCode(levels[i].Cause), ID(levels[i].Cause)
+	}
+	for _, m := range label.FindAllStringSubmatch(labels, -1) {
+		if m[1] == "id" {
+			st.ID = m[2]
+		} else if code, ok := CodeByName(m[2]); ok {
+			st.Code = code
+		} else if code, err := strconv.ParseUint(m[2], 10, 16); err == nil {
+			st.Code = ErrorCode(code)
+		}
+	}
+	return st, nil
+}
+
+// parseBranches parses the "Caused by (i of n):" blocks of several causes.
+func parseBranches(lines []string) (error, error) {
+	var causes []error
+	for len(lines) > 0 {
+		m := branchLine.FindStringSubmatch(lines[0])
+		if m == nil || m[1] != strconv.Itoa(len(causes)+1) {
+			return nil, fmt.Errorf("stacktrace: unexpected line %q", lines[0])
+		}
+		var block []string
+		if m[3] != "" {
+			block = append(block, m[3][1:])
+		}
+		lines = lines[1:]
+		for len(lines) > 0 && strings.HasPrefix(lines[0], "    ") {
+			block = append(block, lines[0][4:])
+			lines = lines[1:]
+		}
+		cause, err := parseBlock(block, nil)
+		if err != nil {
+			return nil, err
+		}
+		causes = append(causes, cause)
+	}
+	return errors.Join(causes...), nil
+}
+
+// parseLocation parses "file:line (function)" or "file:line".
+func parseLocation(s string) (Frame, error) {
+	m := location.FindStringSubmatch(s)
+	if m == nil {
+		return Frame{}, fmt.Errorf("stacktrace: malformed location %q", s)
+	}
+	line, err := strconv.Atoi(m[2])
+	if err != nil {
+		return Frame{}, fmt.Errorf("stacktrace: malformed location %q", s)
+	}
+	return Frame{File: m[1], Line: line, Function: m[3]}, nil
+}
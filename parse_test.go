@@ -0,0 +1,93 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestParse(t *testing.T) {
+	defer func(capture bool) { stacktrace.CaptureStack = capture }(stacktrace.CaptureStack)
+	defer func(show bool) { stacktrace.ShowCodes = show }(stacktrace.ShowCodes)
+	defer func(assign bool) { stacktrace.AssignIDs = assign }(stacktrace.AssignIDs)
+	stacktrace.CaptureStack = true
+	stacktrace.ShowCodes = true
+	stacktrace.AssignIDs = true
+
+	err := stacktrace.PropagateWithCode(errors.New("disk\nfull"), EcodeInvalidVillain, "Failed to write\nthe file")
+	err = stacktrace.AddSuppressed(err, stacktrace.NewError("cleanup failed"))
+	err = stacktrace.Propagate(err, "")
+	err = stacktrace.PropagateAll([]error{err, errors.New("plain"), errors.Join(errors.New("a"), stacktrace.NewError("b"))}, "middle")
+	err = stacktrace.PropagateWithCode(err, EcodeNoSuchPseudo, "outer %d%%", 100)
+	text := fmt.Sprintf("%+s", err)
+
+	st, perr := stacktrace.Parse(text)
+	require.NoError(t, perr)
+	assert.Equal(t, text, fmt.Sprintf("%+s", st))
+	assert.Equal(t, "outer 100%", st.Message)
+	assert.Equal(t, "TestParse", st.Function)
+	assert.Equal(t, err.(*stacktrace.Stacktrace).Stack, st.Stack)
+	assert.Equal(t, stacktrace.Codes(err), stacktrace.Codes(st))
+	assert.Equal(t, stacktrace.ID(err), stacktrace.ID(st))
+	assert.Equal(t, fmt.Sprintf("%#s", err), fmt.Sprintf("%#s", st))
+
+	// colors and the build line are ignored
+	defer func(format stacktrace.Format) { stacktrace.DefaultFormat = format }(stacktrace.DefaultFormat)
+	stacktrace.DefaultFormat = stacktrace.FormatColor
+	colored, perr := stacktrace.Parse(fmt.Sprint(err) + "\nBuild: v1.2.3")
+	require.NoError(t, perr)
+	assert.Equal(t, text, fmt.Sprintf("%+s", colored))
+}
+
+func TestParseCollapsed(t *testing.T) {
+	defer func(collapse bool) { stacktrace.CollapseDuplicateFrames = collapse }(stacktrace.CollapseDuplicateFrames)
+	stacktrace.CollapseDuplicateFrames = true
+
+	var err error = stacktrace.NewError("root")
+	for i := 0; i < 3; i++ {
+		err = stacktrace.Propagate(err, "")
+	}
+	text := fmt.Sprintf("%+s", err)
+
+	st, perr := stacktrace.Parse(text)
+	require.NoError(t, perr)
+	assert.Equal(t, text, fmt.Sprintf("%+s", st))
+	stacktrace.CollapseDuplicateFrames = false
+	assert.Equal(t, fmt.Sprintf("%+s", err), fmt.Sprintf("%+s", st))
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"just some text",
+		"msg\n --- at file.go (func) ---",
+		"msg\n --- at file.go:12 (func) ---\nunexpected",
+		"msg\n --- at file.go:12 (func) ---\nCaused by (2 of 2): a",
+		"msg\n --- at file.go:12 (func) ---\n     ... 3 more",
+		// a location line cut short, as in a truncated log line
+		"Failed to sync\n --- at github.com/palantir/shield/sync.go",
+		"Failed to sync\n --- at github.com/palantir/shield/sync.go:12 (sync) ---\n --- at github.com/palantir/shield/sync.go",
+	} {
+		_, err := stacktrace.Parse(text)
+		assert.Error(t, err, text)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	err := stacktrace.PropagateAll([]error{startDoing(), errors.New("plain")}, "middle")
+	err = stacktrace.AddSuppressed(stacktrace.Propagate(err, "outer"), stacktrace.NewError("cleanup failed"))
+	f.Add(fmt.Sprintf("%+s", err))
+	f.Add(fmt.Sprintf("%+s", stacktrace.Propagate(PublicObj{}.DoPublic(startDoing()), "")))
+	f.Add("Failed to sync\n --- at github.com/palantir/shield/sync.go")
+	f.Fuzz(func(t *testing.T, text string) {
+		st, err := stacktrace.Parse(text)
+		if (st == nil) == (err == nil) {
+			t.Fatalf("Parse(%q) = %v, %v", text, st, err)
+		}
+	})
+}
@@ -0,0 +1,31 @@
+package stacktrace
+
+/*
+WithFormat makes err render in the given Format instead of DefaultFormat, in
+err.Error() and the formatting specifiers that follow DefaultFormat, so that a
+process can show some errors to users briefly while logging others in full:
+
+	if !valid(name) {
+		return Stacktrace.WithFormat(Stacktrace.NewError("Invalid name %q", name), Stacktrace.FormatBrief)
+	}
+
+The Format is added as a new Stacktrace level with an empty Message, like the
+mark of WithRetryable, and is preserved by Propagate. A Format closer to the
+top of the chain overrides one further down. "%+s" and "%#s" still force the
+full and brief output. WithFormat returns nil if err is nil.
+*/
+func WithFormat(err error, format Format) error {
+	if err == nil {
+		return nil
+	}
+	return createWith(err, NoCode, func(st *Stacktrace) { st.preferred = &format }, "")
+}
+
+// preferredFormatOf returns the Format set with WithFormat for a Stacktrace
+// cause, or nil if there is none.
+func preferredFormatOf(cause error) *Format {
+	if st, ok := cause.(*Stacktrace); ok && st != nil {
+		return st.preferred
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package stacktrace_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestWithFormat(t *testing.T) {
+	defer func(format stacktrace.Format) { stacktrace.DefaultFormat = format }(stacktrace.DefaultFormat)
+	stacktrace.DefaultFormat = stacktrace.FormatFull
+
+	userFacing := stacktrace.WithFormat(stacktrace.NewErrorWithCode(EcodeInvalidVillain, "Invalid name %q", "loki"), stacktrace.FormatBrief)
+	assert.Equal(t, `Invalid name "loki"`, userFacing.Error())
+	assert.Equal(t, `Invalid name "loki"`, fmt.Sprintf("%v", userFacing))
+	assert.Equal(t, `Invalid name "loki" [code=0]`, fmt.Sprintf("% v", userFacing))
+	assert.Equal(t, EcodeInvalidVillain, stacktrace.GetCode(userFacing))
+	assert.Equal(t, strings.Join([]string{
+		" --- at github.com/palantir/Stacktrace/preferred_test.go:# (TestWithFormat) ---",
+		`Caused by: Invalid name "loki"`,
+		" --- at github.com/palantir/Stacktrace/preferred_test.go:# (TestWithFormat) ---",
+	}, "\n"), normalizeLines(fmt.Sprintf("%+s", userFacing)))
+
+	// preserved by Propagate, and overridden further up
+	propagated := stacktrace.Propagate(userFacing, "Failed to register")
+	assert.Equal(t, `Failed to register: Invalid name "loki"`, propagated.Error())
+	operational := stacktrace.WithFormat(propagated, stacktrace.FormatFull)
+	assert.Contains(t, operational.Error(), "\n --- at ")
+
+	// other errors keep following DefaultFormat
+	assert.Contains(t, stacktrace.NewError("other").Error(), "\n --- at ")
+	assert.Nil(t, stacktrace.WithFormat(nil, stacktrace.FormatBrief))
+}
@@ -0,0 +1,198 @@
+package stacktrace
+
+import (
+	"io"
+	"os"
+)
+
+/*
+PrintOption selects how Fprint, Print and Sprint render an error. Options are
+applied in order, so a later option overrides an earlier one.
+*/
+type PrintOption func(*printOptions)
+
+type printOptions struct {
+	brief     bool
+	color     bool
+	source    bool
+	codes     bool
+	depth     int
+	width     int
+	size      int
+	locations *bool
+	module    string
+	only      bool
+	group     bool
+}
+
+// PrintFull renders the full format, with the "--- at" line of every level.
+func PrintFull() PrintOption {
+	return func(o *printOptions) { o.brief = false }
+}
+
+// PrintBrief renders the brief format, on a single Line.
+func PrintBrief() PrintOption {
+	return func(o *printOptions) { o.brief = true }
+}
+
+// PrintColor controls whether the full format includes ANSI color escape
+// sequences, as with FormatColor. The brief format is never colored.
+func PrintColor(color bool) PrintOption {
+	return func(o *printOptions) { o.color = color }
+}
+
+// PrintCodes controls whether error codes are shown, as with ShowCodes.
+func PrintCodes(codes bool) PrintOption {
+	return func(o *printOptions) { o.codes = codes }
+}
+
+/*
+PrintMaxDepth limits the number of levels of the chain that are shown to depth,
+followed by "... truncated" if there are more. Zero or a negative depth removes
+the limit, which is the default. MaxChainDepth still applies.
+*/
+func PrintMaxDepth(depth int) PrintOption {
+	return func(o *printOptions) { o.depth = depth }
+}
+
+// PrintWrap makes the full format wrap messages at the given column, as with
+// WrapWidth. Zero or a negative width turns wrapping off.
+func PrintWrap(width int) PrintOption {
+	return func(o *printOptions) { o.width = width }
+}
+
+// PrintMaxSize limits the full format to size bytes, as with MaxFormatSize.
+// Zero or a negative size removes the limit.
+func PrintMaxSize(size int) PrintOption {
+	return func(o *printOptions) { o.size = size }
+}
+
+/*
+PrintHighlightModule marks the frames of stacks (see CaptureStack) whose File
+starts with prefix, such as the import path of the application module, the way
+IDE debuggers gray out library frames. With colors, the other frames are dimmed,
+and otherwise the frames of the module start with "*":
+
+	Stacktrace.Fprint(os.Stderr, err, Stacktrace.PrintHighlightModule("github.com/palantir/shield/"))
+
+	Failed to handle request
+	 --- at github.com/palantir/shield/server.go:61 (Server.handle) ---
+	   * at github.com/palantir/shield/server.go:61 (Server.handle)
+	     at net/http/server.go:2166 (HandlerFunc.ServeHTTP)
+	   * at github.com/palantir/shield/middleware.go:25 (withAuth.func1)
+	     at net/http/server.go:2166 (HandlerFunc.ServeHTTP)
+*/
+func PrintHighlightModule(prefix string) PrintOption {
+	return func(o *printOptions) { o.module, o.only = prefix, false }
+}
+
+/*
+PrintOnlyModule is like PrintHighlightModule, but leaves the frames of stacks
+outside the module out, replacing each run of them with "(+N frames omitted)".
+The "--- at" lines are always shown.
+*/
+func PrintOnlyModule(prefix string) PrintOption {
+	return func(o *printOptions) { o.module, o.only = prefix, true }
+}
+
+// PrintGroupByPackage controls whether frames of stacks in the same package are
+// grouped, as with GroupFramesByPackage.
+func PrintGroupByPackage(group bool) PrintOption {
+	return func(o *printOptions) { o.group = group }
+}
+
+/*
+PrintLocations controls whether locations are shown. In the full format, which
+shows them by default, turning them off leaves only the messages of the chain.
+In the brief format, which does not, turning them on appends the File and Line
+of the deepest level, as with FormatBriefWithLocation.
+*/
+func PrintLocations(locations bool) PrintOption {
+	return func(o *printOptions) { o.locations = &locations }
+}
+
+/*
+Sprint renders err according to opts, a single entry point for the variations
+that the formatting specifiers and package settings offer:
+
+	Stacktrace.Sprint(err, Stacktrace.PrintBrief(), Stacktrace.PrintCodes(true))
+
+Options that are not given default to the Format preferred by err (see
+WithFormat), or else to DefaultFormat, and to the settings of this package, like
+ShowCodes, WrapWidth, MaxFormatSize and GroupFramesByPackage. Errors whose
+Format is custom (see NewFormat) default to the full format, and errors that are
+not a Stacktrace are rendered by their Error method. Sprint returns "" if err is
+nil.
+*/
+func Sprint(err error, opts ...PrintOption) string {
+	if err == nil {
+		return ""
+	}
+	st, ok := err.(*Stacktrace)
+	if !ok || st == nil {
+		return err.Error()
+	}
+
+	format := DefaultFormat
+	if st.preferred != nil {
+		format = *st.preferred
+	}
+	o := printOptions{
+		brief:  format == FormatBrief || format == FormatBriefWithLocation,
+		color:  format == FormatColor,
+		source: format == FormatFullWithSource,
+		codes:  ShowCodes,
+		width:  WrapWidth,
+		size:   MaxFormatSize,
+		group:  GroupFramesByPackage,
+	}
+	if format == FormatBriefWithLocation {
+		locations := true
+		o.locations = &locations
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o.render(st)
+}
+
+func (o printOptions) render(st *Stacktrace) (text string) {
+	defer recoverFormat(&text)
+	render := renderOptions{codes: o.codes, depth: o.depth}
+	if o.brief {
+		brief := renderBrief(st, render)
+		if o.locations != nil && *o.locations {
+			brief = appendLocation(brief, st)
+		}
+		return brief
+	}
+	render.color, render.source, render.width, render.size = o.color, o.source, o.width, o.size
+	render.module, render.onlyModule, render.group = o.module, o.only, o.group
+	render.hideLocations = o.locations != nil && !*o.locations
+	return renderFull(st, render)
+}
+
+/*
+Fprint writes err to w according to opts, followed by a newline. See Sprint for
+the options and their defaults:
+
+	if err := run(); err != nil {
+		Stacktrace.Fprint(os.Stderr, err, Stacktrace.PrintMaxDepth(5), Stacktrace.PrintLocations(false))
+		os.Exit(1)
+	}
+
+Fprint writes nothing if err is nil.
+*/
+func Fprint(w io.Writer, err error, opts ...PrintOption) error {
+	if err == nil {
+		return nil
+	}
+	_, werr := io.WriteString(w, Sprint(err, opts...)+"\n")
+	return werr
+}
+
+// Print is like Fprint, writing to os.Stderr.
+func Print(err error, opts ...PrintOption) error {
+	return Fprint(os.Stderr, err, opts...)
+}
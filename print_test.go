@@ -0,0 +1,99 @@
+package stacktrace_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestSprint(t *testing.T) {
+	defer func(format stacktrace.Format) { stacktrace.DefaultFormat = format }(stacktrace.DefaultFormat)
+	stacktrace.DefaultFormat = stacktrace.FormatFull
+
+	err := stacktrace.Propagate(stacktrace.NewErrorWithCode(EcodeInvalidVillain, "no such villain"), "failed to plan")
+	err = stacktrace.Propagate(err, "failed to save the world")
+
+	assert.Equal(t, normalizeLines(err.Error()), normalizeLines(stacktrace.Sprint(err)))
+	assert.Equal(t, "failed to save the world: failed to plan: no such villain", stacktrace.Sprint(err, stacktrace.PrintBrief()))
+	assert.Equal(t, "failed to save the world: failed to plan: no such villain [code=0]", stacktrace.Sprint(err, stacktrace.PrintBrief(), stacktrace.PrintCodes(true)))
+	assert.Equal(t, "failed to save the world: failed to plan: ... truncated", stacktrace.Sprint(err, stacktrace.PrintBrief(), stacktrace.PrintMaxDepth(2)))
+	assert.Equal(t, "failed to save the world: failed to plan: no such villain (github.com/palantir/Stacktrace/print_test.go:#)",
+		normalizeLines(stacktrace.Sprint(err, stacktrace.PrintBrief(), stacktrace.PrintLocations(true))))
+
+	assert.Equal(t, strings.Join([]string{
+		"failed to save the world",
+		"Caused by: failed to plan",
+		"Caused by: no such villain",
+	}, "\n"), stacktrace.Sprint(err, stacktrace.PrintLocations(false)))
+	assert.Equal(t, strings.Join([]string{
+		"failed to save the world",
+		" --- at github.com/palantir/Stacktrace/print_test.go:# (TestSprint) ---",
+		"... truncated",
+	}, "\n"), normalizeLines(stacktrace.Sprint(err, stacktrace.PrintFull(), stacktrace.PrintMaxDepth(1))))
+	assert.Contains(t, stacktrace.Sprint(err, stacktrace.PrintColor(true)), "\x1b[")
+	assert.Contains(t, stacktrace.Sprint(err, stacktrace.PrintWrap(20)), "failed to save the\n    world\n")
+
+	// options override the preferred Format of the error
+	brief := stacktrace.WithFormat(err, stacktrace.FormatBrief)
+	assert.Equal(t, "failed to save the world: failed to plan: no such villain", stacktrace.Sprint(brief))
+	assert.Contains(t, stacktrace.Sprint(brief, stacktrace.PrintFull()), " --- at ")
+
+	assert.Equal(t, "plain", stacktrace.Sprint(errors.New("plain"), stacktrace.PrintFull()))
+	assert.Equal(t, "", stacktrace.Sprint(nil))
+}
+
+func TestFprint(t *testing.T) {
+	var buf bytes.Buffer
+	err := stacktrace.NewError("no such villain")
+	assert.NoError(t, stacktrace.Fprint(&buf, err, stacktrace.PrintBrief()))
+	assert.NoError(t, stacktrace.Fprint(&buf, nil))
+	assert.Equal(t, "no such villain\n", buf.String())
+}
+
+func TestPrintModule(t *testing.T) {
+	err := &stacktrace.Stacktrace{
+		Message:  "failed to handle request",
+		File:     "github.com/palantir/shield/server.go",
+		Line:     61,
+		Function: "Server.handle",
+		Stack: []stacktrace.Frame{
+			{File: "github.com/palantir/shield/server.go", Line: 61, Function: "Server.handle"},
+			{File: "net/http/server.go", Line: 2166, Function: "HandlerFunc.ServeHTTP"},
+			{File: "github.com/palantir/shield/middleware.go", Line: 25, Function: "withAuth.func1"},
+			{File: "net/http/server.go", Line: 2166, Function: "HandlerFunc.ServeHTTP"},
+			{File: "net/http/server.go", Line: 3210, Function: "serverHandler.ServeHTTP"},
+		},
+	}
+
+	highlighted := stacktrace.Sprint(err, stacktrace.PrintFull(), stacktrace.PrintHighlightModule("github.com/palantir/shield/"))
+	assert.Equal(t, strings.Join([]string{
+		"failed to handle request",
+		" --- at github.com/palantir/shield/server.go:61 (Server.handle) ---",
+		"   * at github.com/palantir/shield/server.go:61 (Server.handle)",
+		"     at net/http/server.go:2166 (HandlerFunc.ServeHTTP)",
+		"   * at github.com/palantir/shield/middleware.go:25 (withAuth.func1)",
+		"     at net/http/server.go:2166 (HandlerFunc.ServeHTTP)",
+		"     at net/http/server.go:3210 (serverHandler.ServeHTTP)",
+	}, "\n"), highlighted)
+	parsed, perr := stacktrace.Parse(highlighted)
+	assert.NoError(t, perr)
+	assert.Equal(t, err.Stack, parsed.Stack)
+
+	colored := stacktrace.Sprint(err, stacktrace.PrintFull(), stacktrace.PrintColor(true), stacktrace.PrintHighlightModule("github.com/palantir/shield/"))
+	assert.Contains(t, colored, "\n     at github.com/palantir/shield/middleware.go:25 (withAuth.func1)")
+	assert.Contains(t, colored, "\x1b[2m     at net/http/server.go:2166")
+
+	assert.Equal(t, strings.Join([]string{
+		"failed to handle request",
+		" --- at github.com/palantir/shield/server.go:61 (Server.handle) ---",
+		"     at github.com/palantir/shield/server.go:61 (Server.handle)",
+		"     (+1 frame omitted)",
+		"     at github.com/palantir/shield/middleware.go:25 (withAuth.func1)",
+		"     (+2 frames omitted)",
+	}, "\n"), stacktrace.Sprint(err, stacktrace.PrintFull(), stacktrace.PrintOnlyModule("github.com/palantir/shield/")))
+}
@@ -0,0 +1,67 @@
+package stacktrace
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+/*
+CaptureProcessInfo controls whether errors record the host, process and
+goroutine they were created in, for aggregating errors from a large fleet where
+the metadata of the log lines carrying them gets lost. The information is
+returned by GetProcessInfo and included in MarshalYAML.
+*/
+var CaptureProcessInfo = false
+
+// ProcessInfo identifies where an error was created. See CaptureProcessInfo.
+type ProcessInfo struct {
+	Hostname    string `yaml:"hostname,omitempty"`
+	PID         int    `yaml:"pid"`
+	GoroutineID uint64 `yaml:"goroutine"`
+}
+
+// GetProcessInfo returns the ProcessInfo of the innermost Stacktrace error in
+// the chain of err that recorded one. The second result is false if there is
+// none.
+func GetProcessInfo(err error) (ProcessInfo, bool) {
+	st := deepest(err, func(st *Stacktrace) bool { return st.Process != nil })
+	if st == nil {
+		return ProcessInfo{}, false
+	}
+	return *st.Process, true
+}
+
+var (
+	processOnce sync.Once
+	hostname    string
+	pid         int
+)
+
+// processInfo returns the ProcessInfo of a new error, or nil if
+// CaptureProcessInfo is disabled.
+func processInfo() *ProcessInfo {
+	if !CaptureProcessInfo {
+		return nil
+	}
+	processOnce.Do(func() {
+		hostname, _ = os.Hostname()
+		pid = os.Getpid()
+	})
+	return &ProcessInfo{Hostname: hostname, PID: pid, GoroutineID: goroutineID()}
+}
+
+// goroutineID parses the ID of the current goroutine from the first Line of
+// its stack, "goroutine 42 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	line := buf[:runtime.Stack(buf[:], false)]
+	line = bytes.TrimPrefix(line, []byte("goroutine "))
+	if i := bytes.IndexByte(line, ' '); i > 0 {
+		line = line[:i]
+	}
+	id, _ := strconv.ParseUint(string(line), 10, 64)
+	return id
+}
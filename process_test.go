@@ -0,0 +1,42 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestProcessInfo(t *testing.T) {
+	_, ok := stacktrace.GetProcessInfo(stacktrace.NewError("msg"))
+	assert.False(t, ok)
+
+	defer func(capture bool) { stacktrace.CaptureProcessInfo = capture }(stacktrace.CaptureProcessInfo)
+	stacktrace.CaptureProcessInfo = true
+
+	hostname, _ := os.Hostname()
+	inner := make(chan error)
+	go func() { inner <- stacktrace.NewError("inner") }()
+	err := fmt.Errorf("wrapped: %w", stacktrace.Propagate(<-inner, "outer"))
+
+	info, ok := stacktrace.GetProcessInfo(err)
+	assert.True(t, ok)
+	assert.Equal(t, hostname, info.Hostname)
+	assert.Equal(t, os.Getpid(), info.PID)
+	assert.NotZero(t, info.GoroutineID)
+
+	outer := stacktrace.GetCause(err).(*stacktrace.Stacktrace)
+	assert.NotEqual(t, info.GoroutineID, outer.Process.GoroutineID)
+
+	_, ok = stacktrace.GetProcessInfo(errors.New("plain"))
+	assert.False(t, ok)
+
+	out, marshalErr := yaml.Marshal(stacktrace.NewMessageWithCode(EcodeNoSuchPseudo, "msg"))
+	assert.NoError(t, marshalErr)
+	assert.Contains(t, string(out), fmt.Sprintf("process:\n    hostname: %s\n    pid: %d\n    goroutine: ", hostname, os.Getpid()))
+}
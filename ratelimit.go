@@ -0,0 +1,70 @@
+package stacktrace
+
+import (
+	"sync"
+	"time"
+)
+
+// maxRateLimitKeys bounds the number of keys RateLimitHook remembers before
+// it forgets the ones whose interval has passed.
+const maxRateLimitKeys = 1024
+
+/*
+RateLimitHook wraps a hook for RegisterHook so that it is called at most limit
+times per interval for each key, and skipped for the other errors. This keeps a
+hook that forwards errors to an external system from being flooded when a hot
+loop produces the same error thousands of times per second:
+
+	Stacktrace.RegisterHook(Stacktrace.RateLimitHook(forward, 10, time.Minute, Stacktrace.FingerprintKey))
+
+The key groups errors that count against the same limit; see FingerprintKey and
+CodeKey. A nil key puts all errors in one group.
+*/
+func RateLimitHook(hook func(st *Stacktrace), limit int, interval time.Duration, key func(st *Stacktrace) string) func(st *Stacktrace) {
+	type window struct {
+		start time.Time
+		count int
+	}
+	var mu sync.Mutex
+	windows := make(map[string]*window)
+
+	allow := func(k string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		now := time.Now()
+		w, ok := windows[k]
+		if !ok || now.Sub(w.start) >= interval {
+			if !ok && len(windows) >= maxRateLimitKeys {
+				for other, w := range windows {
+					if now.Sub(w.start) >= interval {
+						delete(windows, other)
+					}
+				}
+			}
+			w = &window{start: now}
+			windows[k] = w
+		}
+		w.count++
+		return w.count <= limit
+	}
+
+	return func(st *Stacktrace) {
+		k := ""
+		if key != nil {
+			k = key(st)
+		}
+		if allow(k) {
+			hook(st)
+		}
+	}
+}
+
+// FingerprintKey groups errors by Fingerprint for RateLimitHook.
+func FingerprintKey(st *Stacktrace) string {
+	return Fingerprint(st)
+}
+
+// CodeKey groups errors by error Code for RateLimitHook.
+func CodeKey(st *Stacktrace) string {
+	return CodeName(st.Code)
+}
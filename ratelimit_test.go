@@ -0,0 +1,40 @@
+package stacktrace_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestRateLimitHook(t *testing.T) {
+	counts := make(map[stacktrace.ErrorCode]int)
+	hook := stacktrace.RateLimitHook(func(st *stacktrace.Stacktrace) {
+		counts[st.Code]++
+	}, 2, time.Hour, stacktrace.CodeKey)
+
+	for i := 0; i < 5; i++ {
+		hook(stacktrace.NewErrorWithCode(EcodeNoSuchPseudo, "msg").(*stacktrace.Stacktrace))
+		hook(stacktrace.NewErrorWithCode(EcodeTimeIsIllusion, "msg").(*stacktrace.Stacktrace))
+	}
+	assert.Equal(t, map[stacktrace.ErrorCode]int{EcodeNoSuchPseudo: 2, EcodeTimeIsIllusion: 2}, counts)
+
+	calls := 0
+	hook = stacktrace.RateLimitHook(func(*stacktrace.Stacktrace) { calls++ }, 1, 10*time.Millisecond, nil)
+	hook(stacktrace.NewError("a").(*stacktrace.Stacktrace))
+	hook(stacktrace.NewError("b").(*stacktrace.Stacktrace))
+	assert.Equal(t, 1, calls)
+	time.Sleep(20 * time.Millisecond)
+	hook(stacktrace.NewError("c").(*stacktrace.Stacktrace))
+	assert.Equal(t, 2, calls)
+}
+
+func TestFingerprintKey(t *testing.T) {
+	newErr := func(id int) *stacktrace.Stacktrace {
+		return stacktrace.NewError("No user %d", id).(*stacktrace.Stacktrace)
+	}
+	assert.Equal(t, stacktrace.FingerprintKey(newErr(1)), stacktrace.FingerprintKey(newErr(2)))
+	assert.NotEqual(t, stacktrace.FingerprintKey(newErr(1)), stacktrace.FingerprintKey(stacktrace.NewError("other").(*stacktrace.Stacktrace)))
+}
@@ -0,0 +1,78 @@
+package stacktrace
+
+import "fmt"
+
+/*
+SanitizeArgs, if not nil, is applied to the arguments of every message before
+they are formatted into it, so that tokens and personal data passed as format
+arguments never end up in stack traces or logs:
+
+	Stacktrace.SanitizeArgs = func(vals []interface{}) []interface{} {
+		for i, v := range vals {
+			if _, ok := v.(*http.Request); ok {
+				vals[i] = "<request>"
+			}
+		}
+		return vals
+	}
+
+SanitizeArgs receives a copy of the arguments, which it may modify in place.
+*/
+var SanitizeArgs func(vals []interface{}) []interface{}
+
+// redactedText is what a Secret renders as.
+const redactedText = "[REDACTED]"
+
+/*
+Secret wraps a value so that it renders as "[REDACTED]" whatever the formatting
+verb, for passing sensitive values to NewError, Propagate and friends:
+
+	return Stacktrace.Propagate(err, "Failed to log in as %s with token %s", user, Stacktrace.Secret(token))
+*/
+func Secret(v interface{}) fmt.Formatter {
+	return secret{v}
+}
+
+type secret struct {
+	value interface{}
+}
+
+func (secret) Format(f fmt.State, c rune) {
+	f.Write([]byte(redactedText))
+}
+
+func (secret) String() string {
+	return redactedText
+}
+
+func (secret) MarshalText() ([]byte, error) {
+	return []byte(redactedText), nil
+}
+
+// sanitizeText returns text after SanitizeArgs, for text that goes into a
+// message without being one of its arguments, such as an HTTP body excerpt.
+func sanitizeText(text string) string {
+	if SanitizeArgs == nil {
+		return text
+	}
+	vals := SanitizeArgs([]interface{}{text})
+	if len(vals) == 0 {
+		return ""
+	}
+	return fmt.Sprint(vals[0])
+}
+
+// render formats a message from msg and vals after applying SanitizeArgs, and
+// truncates it to MaxMessageLength. It also returns the sanitized vals to keep
+// for Localize and Template, or dropped if there were some but CaptureArgs is
+// disabled.
+func render(msg string, vals []interface{}) (message string, args []interface{}, dropped bool) {
+	if SanitizeArgs != nil && len(vals) > 0 {
+		vals = SanitizeArgs(append([]interface{}(nil), vals...))
+	}
+	message = truncate(fmt.Sprintf(msg, vals...), MaxMessageLength)
+	if len(vals) > 0 && !CaptureArgs {
+		return message, nil, true
+	}
+	return message, vals, false
+}
@@ -0,0 +1,43 @@
+package stacktrace_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestSecret(t *testing.T) {
+	token := stacktrace.Secret("hunter2")
+	err := stacktrace.NewError("Failed to log in with %s (%q, %v, %d, %x)", token, token, token, token, token)
+	assert.Equal(t, "Failed to log in with [REDACTED] ([REDACTED], [REDACTED], [REDACTED], [REDACTED])", fmt.Sprintf("%#s", err))
+	assert.Equal(t, "[REDACTED]", fmt.Sprint(token))
+
+	out, jsonErr := json.Marshal(map[string]interface{}{"token": token})
+	assert.NoError(t, jsonErr)
+	assert.Equal(t, `{"token":"[REDACTED]"}`, string(out))
+}
+
+func TestSanitizeArgs(t *testing.T) {
+	defer func(f func([]interface{}) []interface{}) { stacktrace.SanitizeArgs = f }(stacktrace.SanitizeArgs)
+	stacktrace.SanitizeArgs = func(vals []interface{}) []interface{} {
+		for i, v := range vals {
+			if s, ok := v.(string); ok && len(s) > 4 {
+				vals[i] = s[:4] + "..."
+			}
+		}
+		return vals
+	}
+
+	args := []interface{}{"user@example.com", 42}
+	err := stacktrace.NewError("No user %s with id %d", args...)
+	assert.Equal(t, "No user user... with id 42", fmt.Sprintf("%#s", err))
+	assert.Equal(t, "user@example.com", args[0])
+
+	err = stacktrace.NewMessageWithCode(EcodeNoSuchPseudo, "No user %s", "user@example.com")
+	assert.Equal(t, "No user user...", err.Error())
+	assert.Equal(t, "100% done", fmt.Sprintf("%#s", stacktrace.NewError("100%% done")))
+}
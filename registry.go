@@ -0,0 +1,106 @@
+package stacktrace
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+type codeInfo struct {
+	name        string
+	description string
+}
+
+var (
+	registryMu  sync.RWMutex
+	codeInfos   = map[ErrorCode]codeInfo{}
+	codesByName = map[string]ErrorCode{}
+)
+
+/*
+RegisterCode gives an error Code a symbolic name and a description, for use in
+formatted output, serialized errors and metrics labels:
+
+	const (
+		EcodeManifestNotFound = Stacktrace.ErrorCode(iota)
+		EcodeBadInput
+	)
+
+	func init() {
+		Stacktrace.RegisterCode(EcodeManifestNotFound, "EcodeManifestNotFound", "The manifest File does not exist")
+		Stacktrace.RegisterCode(EcodeBadInput, "EcodeBadInput", "The request is malformed")
+	}
+
+RegisterCode returns an error if the Code or the name is already registered
+differently, or if the Code is NoCode. Registering the same Code with the same
+name and description again has no effect.
+*/
+func RegisterCode(code ErrorCode, name, description string) error {
+	if code == NoCode {
+		return fmt.Errorf("stacktrace: cannot register NoCode as %q", name)
+	}
+	if name == "" {
+		return fmt.Errorf("stacktrace: cannot register code %d without a name", code)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if info, ok := codeInfos[code]; ok {
+		if info.name == name && info.description == description {
+			return nil
+		}
+		return fmt.Errorf("stacktrace: code %d is already registered as %q", code, info.name)
+	}
+	if other, ok := codesByName[name]; ok {
+		return fmt.Errorf("stacktrace: name %q is already registered for code %d", name, other)
+	}
+	codeInfos[code] = codeInfo{name: name, description: description}
+	codesByName[name] = code
+	return nil
+}
+
+/*
+CodeName returns the name registered for code with RegisterCode. It returns
+"NoCode" for NoCode and the decimal value of code if it is not registered.
+*/
+func CodeName(code ErrorCode) string {
+	if code == NoCode {
+		return "NoCode"
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	if info, ok := codeInfos[code]; ok {
+		return info.name
+	}
+	return strconv.Itoa(int(code))
+}
+
+// CodeDescription returns the description registered for code with
+// RegisterCode, or "" if it is not registered.
+func CodeDescription(code ErrorCode) string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return codeInfos[code].description
+}
+
+// CodeByName returns the Code registered with the given name.
+func CodeByName(name string) (ErrorCode, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	code, ok := codesByName[name]
+	return code, ok
+}
+
+/*
+String returns the name registered for code with RegisterCode, or its decimal
+value if it is not registered, so that printing codes with %v only changes
+for registered ones. Unlike CodeName, it returns "65535" for NoCode.
+*/
+func (code ErrorCode) String() string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	if info, ok := codeInfos[code]; ok {
+		return info.name
+	}
+	return strconv.Itoa(int(code))
+}
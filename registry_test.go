@@ -0,0 +1,45 @@
+package stacktrace_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	EcodeRegistered = stacktrace.ErrorCode(1000 + iota)
+	EcodeConflicting
+	EcodeUnregistered
+)
+
+func TestRegisterCode(t *testing.T) {
+	assert.NoError(t, stacktrace.RegisterCode(EcodeRegistered, "EcodeRegistered", "Registered for tests"))
+	assert.NoError(t, stacktrace.RegisterCode(EcodeRegistered, "EcodeRegistered", "Registered for tests"))
+
+	assert.Error(t, stacktrace.RegisterCode(EcodeRegistered, "EcodeOther", ""))
+	assert.Error(t, stacktrace.RegisterCode(EcodeRegistered, "EcodeRegistered", "Different description"))
+	assert.Error(t, stacktrace.RegisterCode(EcodeConflicting, "EcodeRegistered", ""))
+	assert.Error(t, stacktrace.RegisterCode(stacktrace.NoCode, "EcodeNone", ""))
+	assert.Error(t, stacktrace.RegisterCode(EcodeConflicting, "", ""))
+
+	assert.Equal(t, "EcodeRegistered", stacktrace.CodeName(EcodeRegistered))
+	assert.Equal(t, "Registered for tests", stacktrace.CodeDescription(EcodeRegistered))
+	assert.Equal(t, "1002", stacktrace.CodeName(EcodeUnregistered))
+	assert.Equal(t, "", stacktrace.CodeDescription(EcodeUnregistered))
+	assert.Equal(t, "NoCode", stacktrace.CodeName(stacktrace.NoCode))
+	assert.Equal(t, "EcodeRegistered", EcodeRegistered.String())
+	assert.Equal(t, "1002", fmt.Sprint(EcodeUnregistered))
+	assert.Equal(t, "65535", fmt.Sprintf("%v", stacktrace.NoCode))
+
+	code, ok := stacktrace.CodeByName("EcodeRegistered")
+	assert.True(t, ok)
+	assert.Equal(t, EcodeRegistered, code)
+	_, ok = stacktrace.CodeByName("EcodeConflicting")
+	assert.False(t, ok)
+
+	err := stacktrace.NewErrorWithCode(EcodeRegistered, "msg")
+	assert.Equal(t, "msg [code=EcodeRegistered]", fmt.Sprintf("% #s", err))
+}
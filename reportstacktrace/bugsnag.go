@@ -0,0 +1,125 @@
+package reportstacktrace
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/palantir/stacktrace"
+)
+
+/*
+BugsnagEvent is the part of a Bugsnag event that describes an error: its
+exceptions and metadata. Marshal it to JSON, or copy the fields into the event
+of a Bugsnag notifier.
+*/
+type BugsnagEvent struct {
+	Exceptions []BugsnagException                `json:"exceptions"`
+	MetaData   map[string]map[string]interface{} `json:"metaData,omitempty"`
+}
+
+// BugsnagException is a single error of a Bugsnag event.
+type BugsnagException struct {
+	ErrorClass string         `json:"errorClass"`
+	Message    string         `json:"message,omitempty"`
+	Type       string         `json:"type"`
+	Stacktrace []BugsnagFrame `json:"stacktrace"`
+}
+
+// BugsnagFrame is a stack frame, as Bugsnag expects it.
+type BugsnagFrame struct {
+	File       string `json:"file"`
+	LineNumber int    `json:"lineNumber"`
+	Method     string `json:"method,omitempty"`
+}
+
+/*
+ToBugsnagEvent converts err to the exceptions and metadata of a Bugsnag event.
+There is an exception for each level of the chain with a Message, outermost
+first, made like the traces of ToRollbar, but with the most recent call first as
+Bugsnag expects. The metadata of err is in the "stacktrace" tab:
+
+	event := reportstacktrace.ToBugsnagEvent(err)
+	payload := map[string]interface{}{
+		"apiKey":   apiKey,
+		"notifier": notifier,
+		"events": []interface{}{map[string]interface{}{
+			"exceptions": event.Exceptions,
+			"metaData":   event.MetaData,
+			"severity":   "error",
+		}},
+	}
+
+ToBugsnagEvent returns nil if err is nil.
+*/
+func ToBugsnagEvent(err error) *BugsnagEvent {
+	if err == nil {
+		return nil
+	}
+	tracker := stacktrace.ToTrackerError(err)
+	event := &BugsnagEvent{}
+	if len(tracker.Metadata) > 0 {
+		event.MetaData = map[string]map[string]interface{}{"stacktrace": tracker.Metadata}
+	}
+	for _, e := range tracker.Exceptions {
+		exception := BugsnagException{
+			ErrorClass: e.Class,
+			Message:    e.Message,
+			Type:       "go",
+			Stacktrace: make([]BugsnagFrame, len(e.Frames)),
+		}
+		for i, frame := range e.Frames {
+			exception.Stacktrace[i] = BugsnagFrame{File: frame.File, LineNumber: frame.Line, Method: frame.Function}
+		}
+		event.Exceptions = append(event.Exceptions, exception)
+	}
+	return event
+}
+
+// BugsnagEndpoint is the URL BugsnagReporter sends events to.
+var BugsnagEndpoint = "https://notify.bugsnag.com"
+
+/*
+BugsnagReporter returns a Reporter that sends the event of ToBugsnagEvent for
+each error to Bugsnag, in the given release stage, with the severity "info",
+"warning" or "error" closest to the Severity of the error. A nil client uses
+http.DefaultClient.
+*/
+func BugsnagReporter(client *http.Client, apiKey, releaseStage string) Reporter {
+	return ReporterFunc(func(ctx context.Context, err error) error {
+		if err == nil {
+			return nil
+		}
+		event := ToBugsnagEvent(err)
+		severity := "error"
+		switch stacktrace.GetSeverity(err) {
+		case stacktrace.SeverityDebug, stacktrace.SeverityInfo:
+			severity = "info"
+		case stacktrace.SeverityWarning:
+			severity = "warning"
+		}
+		payload := map[string]interface{}{
+			"apiKey":         apiKey,
+			"payloadVersion": "5",
+			"notifier": map[string]string{
+				"name":    "stacktrace",
+				"version": "1",
+				"url":     "https://github.com/palantir/stacktrace",
+			},
+			"events": []interface{}{map[string]interface{}{
+				"exceptions":     event.Exceptions,
+				"metaData":       event.MetaData,
+				"severity":       severity,
+				"unhandled":      false,
+				"severityReason": map[string]string{"type": "handledError"},
+				"app":            map[string]string{"releaseStage": releaseStage},
+			}},
+		}
+		headers := map[string]string{
+			"Bugsnag-Api-Key":         apiKey,
+			"Bugsnag-Payload-Version": "5",
+			"Bugsnag-Sent-At":         time.Now().UTC().Format(time.RFC3339),
+		}
+		return postJSON(ctx, client, BugsnagEndpoint, headers, payload)
+	})
+}
@@ -0,0 +1,68 @@
+package reportstacktrace_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+	"github.com/palantir/stacktrace/reportstacktrace"
+)
+
+func TestToBugsnagEvent(t *testing.T) {
+	err := stacktrace.PropagateWithStringCode(errors.New("timed out"), "PULSE_FAILED", "Failed to pulse")
+	err = PublicObj{}.DoPublic(err)
+	err = stacktrace.Propagate(err, "Failed to register")
+
+	event := reportstacktrace.ToBugsnagEvent(err)
+	require.NotNil(t, event)
+	require.Len(t, event.Exceptions, 3)
+
+	assert.Equal(t, "PULSE_FAILED", event.Exceptions[0].ErrorClass)
+	assert.Equal(t, "Failed to register", event.Exceptions[0].Message)
+	assert.Equal(t, "go", event.Exceptions[0].Type)
+
+	// most recent call first
+	require.Len(t, event.Exceptions[0].Stacktrace, 2)
+	assert.Equal(t, "PublicObj.DoPublic", event.Exceptions[0].Stacktrace[0].Method)
+	assert.Equal(t, "TestToBugsnagEvent", event.Exceptions[0].Stacktrace[1].Method)
+
+	assert.Equal(t, reportstacktrace.BugsnagException{
+		ErrorClass: "*errors.errorString",
+		Message:    "timed out",
+		Type:       "go",
+		Stacktrace: []reportstacktrace.BugsnagFrame{},
+	}, event.Exceptions[2])
+
+	assert.Equal(t, map[string]map[string]interface{}{"stacktrace": {"string_code": "PULSE_FAILED"}}, event.MetaData)
+
+	assert.Nil(t, reportstacktrace.ToBugsnagEvent(nil))
+	assert.Nil(t, reportstacktrace.ToBugsnagEvent(errors.New("plain")).MetaData)
+}
+
+func TestBugsnagReporter(t *testing.T) {
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.Header.Get("Bugsnag-Api-Key"))
+		assert.Equal(t, "5", r.Header.Get("Bugsnag-Payload-Version"))
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &payload))
+	}))
+	defer server.Close()
+	defer func(endpoint string) { reportstacktrace.BugsnagEndpoint = endpoint }(reportstacktrace.BugsnagEndpoint)
+	reportstacktrace.BugsnagEndpoint = server.URL
+
+	err := stacktrace.NewErrorWithSeverity(stacktrace.SeverityInfo, "Failed")
+	require.NoError(t, reportstacktrace.BugsnagReporter(nil, "key", "staging").Report(context.Background(), err))
+	event := payload["events"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "info", event["severity"])
+	assert.Equal(t, map[string]interface{}{"releaseStage": "staging"}, event["app"])
+	assert.Equal(t, "Failed", event["exceptions"].([]interface{})[0].(map[string]interface{})["message"])
+}
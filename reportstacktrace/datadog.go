@@ -0,0 +1,67 @@
+package reportstacktrace
+
+import (
+	"fmt"
+
+	"github.com/palantir/stacktrace"
+)
+
+/*
+DatadogSpanTags returns the tags that mark a span as failed with err for
+Datadog Error Tracking:
+
+	error.message  the brief format of err
+	error.type     the string Code of err, or the name of its Code, or its type
+	error.stack    the stack of err in the format of runtime.Stack
+
+Error Tracking groups errors by their type and stack. With dd-trace-go:
+
+	for key, value := range reportstacktrace.DatadogSpanTags(err) {
+		span.SetTag(key, value)
+	}
+
+The stack is the one the Message of ToGCPErrorEvent ends with. It is empty for
+errors that are not Stacktraces. DatadogSpanTags returns nil if err is nil.
+*/
+func DatadogSpanTags(err error) map[string]string {
+	if err == nil {
+		return nil
+	}
+	kind, message, stack := datadogError(err)
+	return map[string]string{
+		"error.message": message,
+		"error.type":    kind,
+		"error.stack":   stack,
+	}
+}
+
+/*
+DatadogLogAttributes returns the attributes that make Datadog Error Tracking
+pick up a log record of err. They are the tags of DatadogSpanTags, except that
+logs name the type "error.kind":
+
+	attrs := reportstacktrace.DatadogLogAttributes(err)
+	logger.Error(attrs["error.message"], "error.kind", attrs["error.kind"], "error.stack", attrs["error.stack"])
+
+DatadogLogAttributes returns nil if err is nil.
+*/
+func DatadogLogAttributes(err error) map[string]string {
+	if err == nil {
+		return nil
+	}
+	kind, message, stack := datadogError(err)
+	return map[string]string{
+		"error.message": message,
+		"error.kind":    kind,
+		"error.stack":   stack,
+	}
+}
+
+func datadogError(err error) (kind, message, stack string) {
+	tracker := stacktrace.ToTrackerError(err)
+	st, ok := err.(*stacktrace.Stacktrace)
+	if !ok || st == nil {
+		return tracker.Class, err.Error(), ""
+	}
+	return tracker.Class, fmt.Sprintf("%#s", st), goroutineStack(tracker.Stack)
+}
@@ -0,0 +1,44 @@
+package reportstacktrace_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+	"github.com/palantir/stacktrace/reportstacktrace"
+)
+
+func TestDatadogSpanTags(t *testing.T) {
+	err := stacktrace.PropagateWithStringCode(startDoing(), "START_FAILED", "Failed to start")
+
+	tags := reportstacktrace.DatadogSpanTags(err)
+	assert.Equal(t, "Failed to start: failed to start doing", tags["error.message"])
+	assert.Equal(t, "START_FAILED", tags["error.type"])
+	assert.Equal(t, strings.Join([]string{
+		"goroutine 1 [running]:",
+		"github.com/palantir/Stacktrace/reportstacktrace.startDoing()",
+		"\tgithub.com/palantir/Stacktrace/reportstacktrace/functions_for_test.go:#",
+		"github.com/palantir/Stacktrace/reportstacktrace.TestDatadogSpanTags()",
+		"\tgithub.com/palantir/Stacktrace/reportstacktrace/datadog_test.go:#",
+	}, "\n"), normalizeLines(tags["error.stack"]))
+
+	assert.Equal(t, map[string]string{
+		"error.message": "plain",
+		"error.type":    "*errors.errorString",
+		"error.stack":   "",
+	}, reportstacktrace.DatadogSpanTags(errors.New("plain")))
+	assert.Nil(t, reportstacktrace.DatadogSpanTags(nil))
+}
+
+func TestDatadogLogAttributes(t *testing.T) {
+	err := stacktrace.NewErrorWithCode(stacktrace.ErrorCode(3), "Out of time")
+
+	attrs := reportstacktrace.DatadogLogAttributes(err)
+	assert.Equal(t, reportstacktrace.DatadogSpanTags(err)["error.stack"], attrs["error.stack"])
+	assert.Equal(t, "3", attrs["error.kind"])
+	assert.NotContains(t, attrs, "error.type")
+	assert.Nil(t, reportstacktrace.DatadogLogAttributes(nil))
+}
@@ -0,0 +1,25 @@
+package reportstacktrace_test
+
+import (
+	"regexp"
+
+	"github.com/palantir/stacktrace"
+)
+
+type PublicObj struct{}
+
+func startDoing() error {
+	return stacktrace.NewError("%s %s %s %s", "failed", "to", "start", "doing")
+}
+
+func (PublicObj) DoPublic(err error) error {
+	return stacktrace.Propagate(err, "")
+}
+
+var lineNumbers = regexp.MustCompile(`\.go:\d+`)
+
+// normalizeLines replaces line numbers in formatted output with "#" so tests
+// don't break whenever code moves around.
+func normalizeLines(s string) string {
+	return lineNumbers.ReplaceAllString(s, ".go:#")
+}
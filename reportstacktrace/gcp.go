@@ -0,0 +1,170 @@
+package reportstacktrace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palantir/stacktrace"
+)
+
+/*
+GCPService and GCPServiceVersion identify the service in the events returned by
+ToGCPErrorEvent, which Error Reporting groups errors by. If GCPService is empty,
+the name of the executable is used. If GCPServiceVersion is empty, the version
+of the binary is used if stacktrace.CaptureBuildInfo is enabled.
+*/
+var (
+	GCPService        = ""
+	GCPServiceVersion = ""
+)
+
+/*
+GCPErrorEvent is the ReportedErrorEvent structure of Google Cloud Error
+Reporting. Marshaled to JSON, it can be sent to the errors:report API or written
+as a structured log entry, which GKE and Cloud Run forward to Error Reporting.
+*/
+type GCPErrorEvent struct {
+	EventTime      string            `json:"eventTime,omitempty"`
+	ServiceContext GCPServiceContext `json:"serviceContext"`
+	Message        string            `json:"message"`
+	Context        *GCPErrorContext  `json:"context,omitempty"`
+}
+
+// GCPServiceContext identifies the service that reported an error.
+type GCPServiceContext struct {
+	Service string `json:"service"`
+	Version string `json:"version,omitempty"`
+}
+
+// GCPErrorContext describes the circumstances of an error.
+type GCPErrorContext struct {
+	User           string             `json:"user,omitempty"`
+	ReportLocation *GCPSourceLocation `json:"reportLocation,omitempty"`
+}
+
+// GCPSourceLocation is a location in the source code.
+type GCPSourceLocation struct {
+	FilePath     string `json:"filePath"`
+	LineNumber   int    `json:"lineNumber"`
+	FunctionName string `json:"functionName,omitempty"`
+}
+
+/*
+ToGCPErrorEvent returns the Error Reporting event for err:
+
+	event := reportstacktrace.ToGCPErrorEvent(err)
+	json.NewEncoder(os.Stderr).Encode(event)
+
+The Message is the brief format of err followed by a stack in the format of
+runtime.Stack, which Error Reporting parses to group errors:
+
+	Failed to register for villain discovery: Inverse tachyon pulse failed
+
+	goroutine 1 [running]:
+	github.com/palantir/shield/metaphysic.TryPulse()
+		github.com/palantir/shield/metaphysic/tachyon.go:72
+	github.com/palantir/shield/agent.ShieldAgent.reallyRegister()
+		github.com/palantir/shield/agent/discovery.go:265
+
+The stack starts at the innermost Stacktrace in the chain, continuing with its
+Stack if stacktrace.CaptureStack was enabled, or otherwise with the locations
+of the levels wrapping it. The report location is the location of the
+outermost level, and the event time its Time, or the current time if it was
+not captured. ToGCPErrorEvent returns nil if err is nil.
+*/
+func ToGCPErrorEvent(err error) *GCPErrorEvent {
+	if err == nil {
+		return nil
+	}
+	event := &GCPErrorEvent{
+		EventTime:      time.Now().UTC().Format(time.RFC3339Nano),
+		ServiceContext: GCPServiceContext{Service: GCPService, Version: GCPServiceVersion},
+	}
+	if event.ServiceContext.Service == "" {
+		event.ServiceContext.Service = strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	}
+
+	st, ok := err.(*stacktrace.Stacktrace)
+	if !ok || st == nil {
+		event.Message = err.Error()
+		return event
+	}
+	if st.Build != nil && event.ServiceContext.Version == "" {
+		event.ServiceContext.Version = st.Build.Version
+	}
+	if !st.Time.IsZero() {
+		event.EventTime = st.Time.UTC().Format(time.RFC3339Nano)
+	}
+	if st.File != "" {
+		event.Context = &GCPErrorContext{ReportLocation: &GCPSourceLocation{
+			FilePath:     st.File,
+			LineNumber:   st.Line,
+			FunctionName: st.Function,
+		}}
+	}
+
+	event.Message = fmt.Sprintf("%#s", st) + "\n\n" + goroutineStack(stacktrace.ToTrackerError(st).Stack)
+	return event
+}
+
+// goroutineStack renders frames, most recent call first, in the format of
+// runtime.Stack.
+func goroutineStack(frames []stacktrace.Frame) string {
+	var b strings.Builder
+	b.WriteString("goroutine 1 [running]:")
+	for _, frame := range frames {
+		b.WriteByte('\n')
+		b.WriteString(qualifiedFunction(frame))
+		b.WriteString("()\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+	}
+	return b.String()
+}
+
+// qualifiedFunction returns the Function of frame prefixed with the import
+// path of its package, which is taken from the directory of its File.
+func qualifiedFunction(frame stacktrace.Frame) string {
+	function := frame.Function
+	if function == "" {
+		function = "unknown"
+	}
+	dir := path.Dir(filepath.ToSlash(frame.File))
+	if dir == "." || dir == "/" {
+		return "main." + function
+	}
+	return dir + "." + function
+}
+
+/*
+GCPReporter returns a Reporter that writes the event of ToGCPErrorEvent for each
+error to w as a line of JSON. On GKE and Cloud Run, where lines written to
+standard output become log entries, pass os.Stdout and Error Reporting picks up
+the errors from the logs.
+*/
+func GCPReporter(w io.Writer) Reporter {
+	var mu sync.Mutex
+	return ReporterFunc(func(ctx context.Context, err error) error {
+		if err == nil {
+			return nil
+		}
+		line, jerr := json.Marshal(ToGCPErrorEvent(err))
+		if jerr != nil {
+			return jerr
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, werr := w.Write(append(line, '\n'))
+		return werr
+	})
+}
@@ -0,0 +1,81 @@
+package reportstacktrace_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+	"github.com/palantir/stacktrace/reportstacktrace"
+)
+
+func TestToGCPErrorEvent(t *testing.T) {
+	defer func(service string) { reportstacktrace.GCPService = service }(reportstacktrace.GCPService)
+	defer func(capture bool) { stacktrace.CaptureTimestamps = capture }(stacktrace.CaptureTimestamps)
+	reportstacktrace.GCPService = "shield"
+	stacktrace.CaptureTimestamps = true
+
+	err := stacktrace.Propagate(PublicObj{}.DoPublic(startDoing()), "Failed to start")
+	event := reportstacktrace.ToGCPErrorEvent(err)
+	require.NotNil(t, event)
+
+	assert.Equal(t, strings.Join([]string{
+		"Failed to start: failed to start doing",
+		"",
+		"goroutine 1 [running]:",
+		"github.com/palantir/Stacktrace/reportstacktrace.startDoing()",
+		"\tgithub.com/palantir/Stacktrace/reportstacktrace/functions_for_test.go:#",
+		"github.com/palantir/Stacktrace/reportstacktrace.PublicObj.DoPublic()",
+		"\tgithub.com/palantir/Stacktrace/reportstacktrace/functions_for_test.go:#",
+		"github.com/palantir/Stacktrace/reportstacktrace.TestToGCPErrorEvent()",
+		"\tgithub.com/palantir/Stacktrace/reportstacktrace/gcp_test.go:#",
+	}, "\n"), normalizeLines(event.Message))
+	assert.Equal(t, "TestToGCPErrorEvent", event.Context.ReportLocation.FunctionName)
+	assert.Equal(t, err.(*stacktrace.Stacktrace).Time.UTC().Format(time.RFC3339Nano), event.EventTime)
+
+	data, jerr := json.Marshal(event)
+	require.NoError(t, jerr)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, map[string]interface{}{"service": "shield"}, decoded["serviceContext"])
+	assert.Contains(t, decoded["context"].(map[string]interface{})["reportLocation"], "lineNumber")
+}
+
+func TestToGCPErrorEventStack(t *testing.T) {
+	defer func(capture bool) { stacktrace.CaptureStack = capture }(stacktrace.CaptureStack)
+	stacktrace.CaptureStack = true
+
+	event := reportstacktrace.ToGCPErrorEvent(stacktrace.Propagate(startDoing(), "outer"))
+	lines := strings.Split(normalizeLines(event.Message), "\n")
+	assert.Equal(t, []string{
+		"goroutine 1 [running]:",
+		"github.com/palantir/Stacktrace/reportstacktrace.startDoing()",
+		"\tgithub.com/palantir/Stacktrace/reportstacktrace/functions_for_test.go:#",
+		"github.com/palantir/Stacktrace/reportstacktrace.TestToGCPErrorEventStack()",
+		"\tgithub.com/palantir/Stacktrace/reportstacktrace/gcp_test.go:#",
+		"testing.tRunner()",
+	}, lines[2:8])
+
+	assert.Nil(t, reportstacktrace.ToGCPErrorEvent(nil))
+	assert.Equal(t, "plain", reportstacktrace.ToGCPErrorEvent(errors.New("plain")).Message)
+}
+
+func TestGCPReporter(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := reportstacktrace.GCPReporter(&buf)
+	require.NoError(t, reporter.Report(context.Background(), stacktrace.NewError("first")))
+	require.NoError(t, reporter.Report(context.Background(), errors.New("second")))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	var event reportstacktrace.GCPErrorEvent
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+	assert.Equal(t, "second", event.Message)
+}
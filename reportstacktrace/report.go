@@ -0,0 +1,294 @@
+/*
+Package reportstacktrace delivers stacktrace errors to error trackers, such as
+Google Cloud Error Reporting, Rollbar, Bugsnag and Datadog. It is separate from
+package stacktrace so that programs which do not report errors do not depend
+on net/http.
+*/
+package reportstacktrace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/palantir/stacktrace"
+)
+
+/*
+Reporter delivers errors to an error tracker, such as GCPReporter,
+RollbarReporter and BugsnagReporter. Applications register their reporters
+with RegisterReporter and call Report in their top-level handlers:
+
+	func init() {
+		reportstacktrace.RegisterReporter(reportstacktrace.NewAsyncReporter(
+			reportstacktrace.DedupReporter(reportstacktrace.RollbarReporter(nil, token, "production"), time.Minute),
+			100,
+		))
+	}
+
+	if err := handle(ctx, req); err != nil {
+		reportstacktrace.Report(ctx, err)
+	}
+*/
+type Reporter interface {
+	Report(ctx context.Context, err error) error
+}
+
+// ReporterFunc adapts a function to the Reporter interface.
+type ReporterFunc func(ctx context.Context, err error) error
+
+// Report calls f(ctx, err).
+func (f ReporterFunc) Report(ctx context.Context, err error) error {
+	return f(ctx, err)
+}
+
+// maxDedupKeys bounds the number of fingerprints DedupReporter remembers before
+// it forgets the ones whose window has passed.
+const maxDedupKeys = 1024
+
+var (
+	reportersMu sync.RWMutex
+	reporters   []*Reporter
+)
+
+/*
+RegisterReporter adds r to the reporters that Report delivers errors to. The
+returned function unregisters it.
+*/
+func RegisterReporter(r Reporter) (unregister func()) {
+	p := &r
+	reportersMu.Lock()
+	reporters = append(reporters, p)
+	reportersMu.Unlock()
+
+	return func() {
+		reportersMu.Lock()
+		defer reportersMu.Unlock()
+		for i, registered := range reporters {
+			if registered == p {
+				reporters = append(reporters[:i:i], reporters[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+/*
+Report delivers err to every reporter registered with RegisterReporter, in the
+order they were registered, and returns the errors of those that failed joined
+together. Report does nothing if err is nil.
+*/
+func Report(ctx context.Context, err error) error {
+	reportersMu.RLock()
+	registered := make([]Reporter, len(reporters))
+	for i, r := range reporters {
+		registered[i] = *r
+	}
+	reportersMu.RUnlock()
+	return MultiReporter(registered...).Report(ctx, err)
+}
+
+/*
+MultiReporter returns a Reporter that delivers each error to all of reporters,
+and returns the errors of those that failed joined together.
+*/
+func MultiReporter(reporters ...Reporter) Reporter {
+	return ReporterFunc(func(ctx context.Context, err error) error {
+		if err == nil {
+			return nil
+		}
+		var failures []error
+		for _, r := range reporters {
+			if rerr := r.Report(ctx, err); rerr != nil {
+				failures = append(failures, rerr)
+			}
+		}
+		return errors.Join(failures...)
+	})
+}
+
+/*
+DedupReporter wraps r so that only the first error with a given Fingerprint is
+delivered within each window, and the repeats are dropped. This keeps an error
+tracker from being flooded when every request fails the same way.
+*/
+func DedupReporter(r Reporter, window time.Duration) Reporter {
+	var mu sync.Mutex
+	reported := make(map[string]time.Time)
+
+	first := func(fingerprint string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		now := time.Now()
+		if at, ok := reported[fingerprint]; ok && now.Sub(at) < window {
+			return false
+		}
+		if len(reported) >= maxDedupKeys {
+			for other, at := range reported {
+				if now.Sub(at) >= window {
+					delete(reported, other)
+				}
+			}
+		}
+		reported[fingerprint] = now
+		return true
+	}
+
+	return ReporterFunc(func(ctx context.Context, err error) error {
+		if err == nil || !first(stacktrace.Fingerprint(err)) {
+			return nil
+		}
+		return r.Report(ctx, err)
+	})
+}
+
+// ErrReportQueueFull is returned by AsyncReporter.Report for errors that are
+// dropped because the buffer is full.
+var ErrReportQueueFull = errors.New("stacktrace: report queue is full")
+
+type asyncReport struct {
+	ctx  context.Context
+	err  error
+	done chan struct{}
+}
+
+/*
+AsyncReporter delivers errors to another Reporter from a background goroutine,
+so that handlers do not wait for an error tracker. Create it with
+NewAsyncReporter.
+*/
+type AsyncReporter struct {
+	queue   chan asyncReport
+	mu      sync.RWMutex
+	closed  bool
+	stopped chan struct{}
+}
+
+/*
+NewAsyncReporter returns an AsyncReporter that delivers to r, buffering up to
+buffer errors. Errors reported while the buffer is full are dropped. The
+errors r returns are discarded, so r should log its failures if they matter.
+*/
+func NewAsyncReporter(r Reporter, buffer int) *AsyncReporter {
+	a := &AsyncReporter{
+		queue:   make(chan asyncReport, buffer),
+		stopped: make(chan struct{}),
+	}
+	go func() {
+		defer close(a.stopped)
+		for report := range a.queue {
+			if report.err != nil {
+				r.Report(report.ctx, report.err)
+			}
+			if report.done != nil {
+				close(report.done)
+			}
+		}
+	}()
+	return a
+}
+
+/*
+Report queues err for delivery and returns immediately. The values of ctx are
+kept, but its cancellation is not, since delivery happens after the caller has
+moved on. Report returns ErrReportQueueFull if err was dropped, and does nothing
+after Close.
+*/
+func (a *AsyncReporter) Report(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return nil
+	}
+	select {
+	case a.queue <- asyncReport{ctx: context.WithoutCancel(ctx), err: err}:
+		return nil
+	default:
+		return ErrReportQueueFull
+	}
+}
+
+/*
+Flush waits until the errors queued before the call have been delivered, or
+until ctx is done, in which case it returns the error of ctx.
+*/
+func (a *AsyncReporter) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		return nil
+	}
+	select {
+	case a.queue <- asyncReport{done: done}:
+	case <-ctx.Done():
+		a.mu.RUnlock()
+		return ctx.Err()
+	}
+	a.mu.RUnlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+Close stops accepting errors and waits until the queued ones have been
+delivered, or until ctx is done, in which case it returns the error of ctx.
+Call it before the process exits.
+*/
+func (a *AsyncReporter) Close(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-a.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+postJSON sends payload as JSON to url with the given headers, and returns an
+error for failed requests and unsuccessful responses. The errors are located
+at the caller of postJSON, which is the Reporter that failed, and carry the
+Code that FromHTTPResponse maps the status of the response to.
+*/
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return stacktrace.PropagateSkip(1, err, "Failed to encode report")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return stacktrace.PropagateSkip(1, err, "Failed to create report request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return stacktrace.PropagateSkip(1, err, "Failed to send report")
+	}
+	defer resp.Body.Close()
+	return stacktrace.FromHTTPResponseSkip(1, resp)
+}
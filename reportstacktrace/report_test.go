@@ -0,0 +1,103 @@
+package reportstacktrace_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+	"github.com/palantir/stacktrace/reportstacktrace"
+)
+
+// recorder is a Reporter that remembers the errors it received.
+type recorder struct {
+	mu       sync.Mutex
+	reported []error
+}
+
+func (r *recorder) Report(ctx context.Context, err error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reported = append(r.reported, err)
+	return nil
+}
+
+func (r *recorder) errors() []error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]error(nil), r.reported...)
+}
+
+func TestReport(t *testing.T) {
+	first, second := &recorder{}, &recorder{}
+	defer reportstacktrace.RegisterReporter(first)()
+	unregister := reportstacktrace.RegisterReporter(second)
+	failing := reportstacktrace.RegisterReporter(reportstacktrace.ReporterFunc(func(ctx context.Context, err error) error {
+		return errors.New("tracker is down")
+	}))
+
+	err := stacktrace.NewError("failed")
+	assert.EqualError(t, reportstacktrace.Report(context.Background(), err), "tracker is down")
+	failing()
+	assert.NoError(t, reportstacktrace.Report(context.Background(), nil))
+	unregister()
+	assert.NoError(t, reportstacktrace.Report(context.Background(), err))
+
+	assert.Equal(t, []error{err, err}, first.errors())
+	assert.Equal(t, []error{err}, second.errors())
+}
+
+func TestDedupReporter(t *testing.T) {
+	r := &recorder{}
+	dedup := reportstacktrace.DedupReporter(r, time.Hour)
+
+	var errs []error
+	for i := 0; i < 3; i++ {
+		errs = append(errs, stacktrace.NewError("failed %d", i))
+	}
+	other := stacktrace.NewError("other")
+	for _, err := range append(errs, other) {
+		require.NoError(t, dedup.Report(context.Background(), err))
+	}
+	assert.Equal(t, []error{errs[0], other}, r.errors())
+
+	again := reportstacktrace.DedupReporter(r, 0)
+	again.Report(context.Background(), other)
+	again.Report(context.Background(), other)
+	assert.Len(t, r.errors(), 4)
+}
+
+func TestAsyncReporter(t *testing.T) {
+	release := make(chan struct{})
+	r := &recorder{}
+	blocking := reportstacktrace.ReporterFunc(func(ctx context.Context, err error) error {
+		<-release
+		return r.Report(ctx, err)
+	})
+	async := reportstacktrace.NewAsyncReporter(blocking, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first, second := stacktrace.NewError("first"), stacktrace.NewError("second")
+	require.NoError(t, async.Report(ctx, first))
+	cancel()
+	// the first error is being delivered, the second waits in the buffer
+	require.Eventually(t, func() bool { return async.Report(ctx, second) == nil }, time.Second, time.Millisecond)
+	assert.Equal(t, reportstacktrace.ErrReportQueueFull, async.Report(ctx, stacktrace.NewError("third")))
+
+	timeout, stop := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer stop()
+	assert.Equal(t, context.DeadlineExceeded, async.Flush(timeout))
+
+	close(release)
+	require.NoError(t, async.Flush(context.Background()))
+	assert.Equal(t, []error{first, second}, r.errors())
+
+	require.NoError(t, async.Close(context.Background()))
+	assert.NoError(t, async.Report(context.Background(), stacktrace.NewError("late")))
+	assert.Len(t, r.errors(), 2)
+}
@@ -0,0 +1,114 @@
+package reportstacktrace
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/palantir/stacktrace"
+)
+
+/*
+RollbarData is the part of the data of a Rollbar item that describes an error:
+its body with a trace chain, and custom metadata. Marshal it to JSON, or copy
+the fields into the payload of a Rollbar client.
+*/
+type RollbarData struct {
+	Body   RollbarBody            `json:"body"`
+	Custom map[string]interface{} `json:"custom,omitempty"`
+}
+
+// RollbarBody is the body of a Rollbar item for an error with causes.
+type RollbarBody struct {
+	TraceChain []RollbarTrace `json:"trace_chain"`
+}
+
+// RollbarTrace is a single error in a Rollbar trace chain.
+type RollbarTrace struct {
+	Frames    []RollbarFrame   `json:"frames"`
+	Exception RollbarException `json:"exception"`
+}
+
+// RollbarFrame is a stack frame, as Rollbar expects it.
+type RollbarFrame struct {
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno,omitempty"`
+	Method   string `json:"method,omitempty"`
+}
+
+// RollbarException describes a single error in a Rollbar trace chain.
+type RollbarException struct {
+	Class   string `json:"class"`
+	Message string `json:"message,omitempty"`
+}
+
+/*
+ToRollbar converts err to the body and custom metadata of a Rollbar item:
+
+	data := reportstacktrace.ToRollbar(err)
+	item := map[string]interface{}{"data": map[string]interface{}{
+		"environment": "production",
+		"body":        data.Body,
+		"custom":      data.Custom,
+	}}
+
+The trace chain has a trace for each level of the chain with a Message,
+outermost first, with the locations of the levels without a Message that it
+wraps and, if stacktrace.CaptureStack was enabled, the Stack of the innermost
+of them as its frames, most recent call last as Rollbar expects. A Cause that
+is not a Stacktrace gets a trace without frames. The class of a trace is the
+string Code of the error, or the name of its Code, or its type.
+
+The custom metadata holds the Code, Severity, IDs and other fields attached to
+err, with the same names as the fields of stacktrace.LogAt. ToRollbar returns
+nil if err is nil.
+*/
+func ToRollbar(err error) *RollbarData {
+	if err == nil {
+		return nil
+	}
+	tracker := stacktrace.ToTrackerError(err)
+	data := &RollbarData{Custom: tracker.Metadata}
+	for _, e := range tracker.Exceptions {
+		trace := RollbarTrace{
+			Frames:    make([]RollbarFrame, len(e.Frames)),
+			Exception: RollbarException{Class: e.Class, Message: e.Message},
+		}
+		for i, frame := range e.Frames {
+			trace.Frames[len(e.Frames)-1-i] = RollbarFrame{Filename: frame.File, Lineno: frame.Line, Method: frame.Function}
+		}
+		data.Body.TraceChain = append(data.Body.TraceChain, trace)
+	}
+	return data
+}
+
+// RollbarEndpoint is the URL RollbarReporter sends items to.
+var RollbarEndpoint = "https://api.rollbar.com/api/1/item/"
+
+/*
+RollbarReporter returns a Reporter that sends the data of ToRollbar for each
+error to Rollbar as an item of the given environment, at the level of the
+Severity of the error, or "error" if it has none. A nil client uses
+http.DefaultClient.
+*/
+func RollbarReporter(client *http.Client, accessToken, environment string) Reporter {
+	return ReporterFunc(func(ctx context.Context, err error) error {
+		if err == nil {
+			return nil
+		}
+		data := ToRollbar(err)
+		level := "error"
+		if severity := stacktrace.GetSeverity(err); severity != stacktrace.SeverityUnset {
+			level = severity.String()
+		}
+		item := map[string]interface{}{"data": map[string]interface{}{
+			"environment": environment,
+			"level":       level,
+			"language":    "go",
+			"timestamp":   time.Now().Unix(),
+			"body":        data.Body,
+			"custom":      data.Custom,
+		}}
+		return postJSON(ctx, client, RollbarEndpoint, map[string]string{"X-Rollbar-Access-Token": accessToken}, item)
+	})
+}
@@ -0,0 +1,79 @@
+package reportstacktrace_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+	"github.com/palantir/stacktrace/reportstacktrace"
+)
+
+func TestToRollbar(t *testing.T) {
+	err := stacktrace.PropagateWithCode(errors.New("timed out"), stacktrace.ErrorCode(2), "Failed to pulse")
+	err = PublicObj{}.DoPublic(err)
+	err = stacktrace.PropagateWithSeverity(err, stacktrace.SeverityWarning, "Failed to register")
+
+	data := reportstacktrace.ToRollbar(err)
+	require.NotNil(t, data)
+	chain := data.Body.TraceChain
+	require.Len(t, chain, 3)
+
+	assert.Equal(t, reportstacktrace.RollbarException{Class: "2", Message: "Failed to register"}, chain[0].Exception)
+	// the level without a Message is the most recent call of the one wrapping it
+	require.Len(t, chain[0].Frames, 2)
+	assert.Equal(t, "TestToRollbar", chain[0].Frames[0].Method)
+	assert.Equal(t, "PublicObj.DoPublic", chain[0].Frames[1].Method)
+
+	assert.Equal(t, "Failed to pulse", chain[1].Exception.Message)
+	require.Len(t, chain[1].Frames, 1)
+	assert.Equal(t, "TestToRollbar", chain[1].Frames[0].Method)
+
+	assert.Equal(t, reportstacktrace.RollbarTrace{
+		Frames:    []reportstacktrace.RollbarFrame{},
+		Exception: reportstacktrace.RollbarException{Class: "*errors.errorString", Message: "timed out"},
+	}, chain[2])
+
+	assert.Equal(t, map[string]interface{}{"code": 2, "severity": "warning"}, data.Custom)
+
+	encoded, jerr := json.Marshal(data)
+	require.NoError(t, jerr)
+	assert.Contains(t, string(encoded), `"trace_chain":[{"frames":[{"filename":"github.com/palantir/Stacktrace/reportstacktrace/rollbar_test.go","lineno":`)
+
+	assert.Nil(t, reportstacktrace.ToRollbar(nil))
+}
+
+func TestRollbarReporter(t *testing.T) {
+	var item map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get("X-Rollbar-Access-Token"))
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &item))
+		if item["data"].(map[string]interface{})["environment"] == "broken" {
+			http.Error(w, "invalid environment", http.StatusUnprocessableEntity)
+		}
+	}))
+	defer server.Close()
+	defer func(endpoint string) { reportstacktrace.RollbarEndpoint = endpoint }(reportstacktrace.RollbarEndpoint)
+	reportstacktrace.RollbarEndpoint = server.URL
+
+	err := stacktrace.NewErrorWithSeverity(stacktrace.SeverityWarning, "Failed")
+	require.NoError(t, reportstacktrace.RollbarReporter(nil, "token", "production").Report(context.Background(), err))
+	data := item["data"].(map[string]interface{})
+	assert.Equal(t, "warning", data["level"])
+	assert.Equal(t, "production", data["environment"])
+	assert.Len(t, data["body"].(map[string]interface{})["trace_chain"], 1)
+
+	failed := reportstacktrace.RollbarReporter(server.Client(), "token", "broken").Report(context.Background(), err)
+	assert.Contains(t, failed.Error(), "422 Unprocessable Entity: invalid environment")
+	// the failure is located at the reporter, not at the code posting the item
+	assert.Equal(t, "rollbar.go", path.Base(failed.(*stacktrace.Stacktrace).File))
+}
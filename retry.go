@@ -0,0 +1,141 @@
+package stacktrace
+
+import (
+	"errors"
+	"time"
+)
+
+/*
+WithRetryable marks err as retryable or not, so that retry loops can decide
+whether to try again without enumerating error codes:
+
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		return Stacktrace.MarkRetryable(Stacktrace.NewError("Service unavailable"))
+	}
+
+The mark is added as a new Stacktrace level with an empty Message, so the
+location where the decision was made is recorded, and like a Code it is
+preserved by Propagate. A mark closer to the top of the chain overrides one
+further down. WithRetryable returns nil if err is nil.
+*/
+func WithRetryable(err error, retryable bool) error {
+	if err == nil {
+		return nil
+	}
+	return createWith(err, NoCode, func(st *Stacktrace) { st.Retryable = &retryable }, "")
+}
+
+// MarkRetryable is shorthand for WithRetryable(err, true).
+func MarkRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	retryable := true
+	return createWith(err, NoCode, func(st *Stacktrace) { st.Retryable = &retryable }, "")
+}
+
+/*
+IsRetryable reports whether err was marked retryable by WithRetryable or
+MarkRetryable. Errors that are not Stacktraces can mark themselves by
+implementing
+
+	Retryable() bool
+
+Errors without any mark are not retryable.
+*/
+func IsRetryable(err error) bool {
+	retryable := retryableOf(err)
+	return retryable != nil && *retryable
+}
+
+// retryableOf returns the retryable mark of err, looking through wrappers and
+// several causes like GetCode does, or nil if there is none.
+func retryableOf(err error) *bool {
+	if st, ok := err.(*Stacktrace); ok && st != nil {
+		return st.Retryable
+	}
+	if r, ok := err.(interface{ Retryable() bool }); ok {
+		retryable := r.Retryable()
+		return &retryable
+	}
+	if causes, ok := branchesOf(err); ok {
+		for _, cause := range causes {
+			if retryable := retryableOf(cause); retryable != nil {
+				return retryable
+			}
+		}
+		return nil
+	}
+	if cause := errors.Unwrap(err); cause != nil {
+		return retryableOf(cause)
+	}
+	return nil
+}
+
+/*
+WithRetryAfter attaches a backoff hint to err, such as the Retry-After header of
+an HTTP 429 response, for retry helpers to consume with RetryAfter:
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		err := Stacktrace.NewError("Rate limited by %v", host)
+		return Stacktrace.WithRetryAfter(err, 30*time.Second)
+	}
+
+Like WithRetryable, it adds a Stacktrace level with an empty Message, and the
+hint is preserved by Propagate. WithRetryAfter returns nil if err is nil.
+*/
+func WithRetryAfter(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	at := time.Now().Add(d)
+	return createWith(err, NoCode, func(st *Stacktrace) { st.RetryAt = at }, "")
+}
+
+// WithRetryAt is like WithRetryAfter, but takes the absolute time after which
+// to retry, such as a Retry-After header holding an HTTP date.
+func WithRetryAt(err error, at time.Time) error {
+	if err == nil {
+		return nil
+	}
+	return createWith(err, NoCode, func(st *Stacktrace) { st.RetryAt = at }, "")
+}
+
+/*
+RetryAfter returns how long to wait before retrying the operation that failed
+with err, according to the hint attached by WithRetryAfter or WithRetryAt. The
+duration is counted from the call to RetryAfter, so it shrinks as time passes,
+and it is 0 once the hint is in the past. The second result is false if err has
+no hint.
+*/
+func RetryAfter(err error) (time.Duration, bool) {
+	at := retryAtOf(err)
+	if at.IsZero() {
+		return 0, false
+	}
+	if d := time.Until(at); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
+
+// retryAtOf returns the time attached by WithRetryAfter or WithRetryAt,
+// looking through wrappers and several causes like GetCode does, or the zero
+// time if there is none.
+func retryAtOf(err error) time.Time {
+	if st, ok := err.(*Stacktrace); ok && st != nil {
+		return st.RetryAt
+	}
+	if causes, ok := branchesOf(err); ok {
+		for _, cause := range causes {
+			if at := retryAtOf(cause); !at.IsZero() {
+				return at
+			}
+		}
+		return time.Time{}
+	}
+	if cause := errors.Unwrap(err); cause != nil {
+		return retryAtOf(cause)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,66 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+type retryableError bool
+
+func (e retryableError) Error() string   { return "retryable error" }
+func (e retryableError) Retryable() bool { return bool(e) }
+
+func TestRetryable(t *testing.T) {
+	assert.Nil(t, stacktrace.WithRetryable(nil, true))
+	assert.Nil(t, stacktrace.MarkRetryable(nil))
+	assert.False(t, stacktrace.IsRetryable(nil))
+	assert.False(t, stacktrace.IsRetryable(errors.New("plain")))
+	assert.False(t, stacktrace.IsRetryable(stacktrace.NewError("msg")))
+
+	err := stacktrace.MarkRetryable(stacktrace.NewError("inner"))
+	assert.True(t, stacktrace.IsRetryable(err))
+	assert.Equal(t, "inner", fmt.Sprintf("%#s", err))
+	assert.Equal(t, "TestRetryable", err.(*stacktrace.Stacktrace).Function)
+
+	err = stacktrace.Propagate(err, "outer")
+	assert.True(t, stacktrace.IsRetryable(err))
+	assert.True(t, stacktrace.IsRetryable(fmt.Errorf("wrapped: %w", err)))
+
+	err = stacktrace.WithRetryable(err, false)
+	assert.Equal(t, "TestRetryable", err.(*stacktrace.Stacktrace).Function)
+	assert.False(t, stacktrace.IsRetryable(err))
+	assert.False(t, stacktrace.IsRetryable(stacktrace.Propagate(err, "")))
+
+	assert.True(t, stacktrace.IsRetryable(stacktrace.Propagate(retryableError(true), "msg")))
+	assert.False(t, stacktrace.IsRetryable(stacktrace.Propagate(retryableError(false), "msg")))
+	assert.False(t, stacktrace.IsRetryable(stacktrace.WithRetryable(retryableError(true), false)))
+	assert.True(t, stacktrace.IsRetryable(stacktrace.PropagateAll([]error{errors.New("plain"), retryableError(true)}, "msg")))
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert.Nil(t, stacktrace.WithRetryAfter(nil, time.Second))
+	assert.Nil(t, stacktrace.WithRetryAt(nil, time.Now()))
+
+	_, ok := stacktrace.RetryAfter(stacktrace.NewError("msg"))
+	assert.False(t, ok)
+	_, ok = stacktrace.RetryAfter(errors.New("plain"))
+	assert.False(t, ok)
+
+	err := stacktrace.WithRetryAfter(stacktrace.NewError("inner"), time.Minute)
+	err = stacktrace.Propagate(err, "outer")
+	d, ok := stacktrace.RetryAfter(fmt.Errorf("wrapped: %w", err))
+	assert.True(t, ok)
+	assert.True(t, d > 59*time.Second && d <= time.Minute, "retry after %v", d)
+	assert.Equal(t, "outer: inner", fmt.Sprintf("%#s", err))
+
+	err = stacktrace.WithRetryAt(err, time.Now().Add(-time.Second))
+	d, ok = stacktrace.RetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), d)
+}
@@ -0,0 +1,50 @@
+package stacktrace
+
+import (
+	"strings"
+)
+
+// formatFullReversed renders st like the full format, but with the root cause
+// first and the outermost message last, so that it reads bottom-up like a
+// Python traceback:
+//
+//	Inverse tachyon pulse failed
+//	 --- at github.com/palantir/shield/metaphysic/tachyon.go:72 (TryPulse) ---
+//	Resulting in: Failed to register for villain discovery
+//	 --- at github.com/palantir/shield/agent/discovery.go:265 (ShieldAgent.reallyRegister) ---
+//	 --- at github.com/palantir/shield/connector/impl.go:89 (Connector.Register) ---
+func formatFullReversed(st *Stacktrace) string {
+	levels, truncated := chain(st)
+
+	var sections []string
+	last := levels[len(levels)-1]
+	if truncated {
+		sections = append(sections, truncatedMarker)
+	} else if _, ok := branchesOf(last.Cause); !ok && last.Cause != nil {
+		sections = append(sections, last.Cause.Error())
+	}
+
+	// Each message starts a new section, made of the levels up to the next
+	// message. Render each section on its own from copies of its levels.
+	for end := len(levels); end > 0; {
+		start := end - 1
+		for start > 0 && levels[start].message() == "" {
+			start--
+		}
+		copies := make([]Stacktrace, end-start)
+		for i := range copies {
+			copies[i] = *levels[start+i]
+			copies[i].Cause = nil
+			if i > 0 {
+				copies[i-1].Cause = &copies[i]
+			}
+		}
+		if _, ok := branchesOf(last.Cause); ok && end == len(levels) {
+			copies[len(copies)-1].Cause = last.Cause
+		}
+		sections = append(sections, formatFull(&copies[0]))
+		end = start
+	}
+
+	return strings.Join(sections, "\nResulting in: ")
+}
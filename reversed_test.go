@@ -0,0 +1,42 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestFormatFullReversed(t *testing.T) {
+	defer func(format stacktrace.Format) { stacktrace.DefaultFormat = format }(stacktrace.DefaultFormat)
+	stacktrace.DefaultFormat = stacktrace.FormatFullReversed
+
+	err := stacktrace.Propagate(errors.New("plain"), "inner")
+	err = stacktrace.Propagate(err, "")
+	err = stacktrace.Propagate(err, "outer")
+	assert.Equal(t, strings.Join([]string{
+		"plain",
+		"Resulting in: inner",
+		" --- at github.com/palantir/Stacktrace/reversed_test.go:# (TestFormatFullReversed) ---",
+		"Resulting in: outer",
+		" --- at github.com/palantir/Stacktrace/reversed_test.go:# (TestFormatFullReversed) ---",
+		" --- at github.com/palantir/Stacktrace/reversed_test.go:# (TestFormatFullReversed) ---",
+	}, "\n"), normalizeLines(err.Error()))
+
+	// the original error is left untouched
+	assert.Equal(t, "outer", err.(*stacktrace.Stacktrace).Message)
+	assert.NotNil(t, err.(*stacktrace.Stacktrace).Cause)
+
+	err = stacktrace.PropagateAll([]error{errors.New("first"), errors.New("second")}, "joined")
+	assert.Equal(t, strings.Join([]string{
+		"joined",
+		" --- at github.com/palantir/Stacktrace/reversed_test.go:# (TestFormatFullReversed) ---",
+		"Caused by (1 of 2): first",
+		"Caused by (2 of 2): second",
+	}, "\n"), normalizeLines(err.Error()))
+
+	assert.Equal(t, "msg", stacktrace.NewMessageWithCode(EcodeNoSuchPseudo, "msg").Error())
+}
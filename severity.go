@@ -0,0 +1,96 @@
+package stacktrace
+
+import "errors"
+
+// Severity is how serious an error is, see NewErrorWithSeverity.
+type Severity int
+
+const (
+	// SeverityUnset is the Severity of errors that were not given one.
+	SeverityUnset Severity = iota
+	SeverityDebug
+	SeverityInfo
+	SeverityWarning
+	SeverityError
+	SeverityCritical
+)
+
+var severityNames = map[Severity]string{
+	SeverityUnset:    "unset",
+	SeverityDebug:    "debug",
+	SeverityInfo:     "info",
+	SeverityWarning:  "warning",
+	SeverityError:    "error",
+	SeverityCritical: "critical",
+}
+
+// String returns the lower-case name of s, such as "warning".
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Level returns the Level to Log an error of Severity s at. SeverityUnset and
+// SeverityCritical map to LevelError.
+func (s Severity) Level() Level {
+	switch s {
+	case SeverityDebug:
+		return LevelDebug
+	case SeverityInfo:
+		return LevelInfo
+	case SeverityWarning:
+		return LevelWarn
+	default:
+		return LevelError
+	}
+}
+
+/*
+NewErrorWithSeverity is similar to NewError but also attaches a Severity, so
+that logging layers can pick a level for the error without keeping tables of
+error codes:
+
+	if len(batch) == 0 {
+		return Stacktrace.NewErrorWithSeverity(Stacktrace.SeverityInfo, "Nothing to do")
+	}
+
+Like a Code, the Severity is preserved by Propagate. Log uses it as the Level.
+*/
+func NewErrorWithSeverity(severity Severity, msg string, vals ...interface{}) error {
+	return createWith(nil, NoCode, func(st *Stacktrace) { st.Severity = severity }, msg, vals...)
+}
+
+// PropagateWithSeverity is similar to Propagate but also attaches a Severity,
+// overriding the one of the Cause.
+func PropagateWithSeverity(cause error, severity Severity, msg string, vals ...interface{}) error {
+	if cause == nil {
+		// Allow calling PropagateWithSeverity without checking whether there is error
+		return nil
+	}
+	return createWith(cause, NoCode, func(st *Stacktrace) { st.Severity = severity }, msg, vals...)
+}
+
+/*
+GetSeverity extracts the Severity from an error, looking through wrappers and
+several causes like GetCode does for codes. It returns SeverityUnset if err is
+nil or has no Severity.
+*/
+func GetSeverity(err error) Severity {
+	if st, ok := err.(*Stacktrace); ok && st != nil {
+		return st.Severity
+	}
+	if causes, ok := branchesOf(err); ok {
+		for _, cause := range causes {
+			if severity := GetSeverity(cause); severity != SeverityUnset {
+				return severity
+			}
+		}
+		return SeverityUnset
+	}
+	if cause := errors.Unwrap(err); cause != nil {
+		return GetSeverity(cause)
+	}
+	return SeverityUnset
+}
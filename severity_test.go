@@ -0,0 +1,73 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestSeverity(t *testing.T) {
+	assert.Equal(t, stacktrace.SeverityUnset, stacktrace.GetSeverity(nil))
+	assert.Equal(t, stacktrace.SeverityUnset, stacktrace.GetSeverity(errors.New("plain")))
+	assert.Equal(t, stacktrace.SeverityUnset, stacktrace.GetSeverity(stacktrace.NewError("msg")))
+	assert.Nil(t, stacktrace.PropagateWithSeverity(nil, stacktrace.SeverityDebug, "msg"))
+
+	err := stacktrace.NewErrorWithSeverity(stacktrace.SeverityInfo, "inner")
+	assert.Equal(t, stacktrace.SeverityInfo, stacktrace.GetSeverity(err))
+	err = stacktrace.PropagateWithCode(err, EcodeNotFastEnough, "middle")
+	assert.Equal(t, stacktrace.SeverityInfo, stacktrace.GetSeverity(err))
+	assert.Equal(t, stacktrace.SeverityInfo, stacktrace.GetSeverity(fmt.Errorf("wrapped: %w", err)))
+	err = stacktrace.PropagateWithSeverity(err, stacktrace.SeverityCritical, "outer")
+	assert.Equal(t, stacktrace.SeverityCritical, stacktrace.GetSeverity(err))
+	assert.Equal(t, EcodeNotFastEnough, stacktrace.GetCode(err))
+	assert.Equal(t, "outer: middle: inner", fmt.Sprintf("%#s", err))
+}
+
+func TestSeverityString(t *testing.T) {
+	for severity, expected := range map[stacktrace.Severity]string{
+		stacktrace.SeverityUnset:    "unset",
+		stacktrace.SeverityDebug:    "debug",
+		stacktrace.SeverityInfo:     "info",
+		stacktrace.SeverityWarning:  "warning",
+		stacktrace.SeverityError:    "error",
+		stacktrace.SeverityCritical: "critical",
+		stacktrace.Severity(42):     "unknown",
+	} {
+		assert.Equal(t, expected, severity.String())
+	}
+}
+
+func TestLogSeverity(t *testing.T) {
+	for severity, expected := range map[stacktrace.Severity]stacktrace.Level{
+		stacktrace.SeverityUnset:    stacktrace.LevelError,
+		stacktrace.SeverityDebug:    stacktrace.LevelDebug,
+		stacktrace.SeverityInfo:     stacktrace.LevelInfo,
+		stacktrace.SeverityWarning:  stacktrace.LevelWarn,
+		stacktrace.SeverityError:    stacktrace.LevelError,
+		stacktrace.SeverityCritical: stacktrace.LevelError,
+	} {
+		var level stacktrace.Level
+		var fields map[string]interface{}
+		stacktrace.Log(stacktrace.LoggerFunc(func(l stacktrace.Level, msg string, f map[string]interface{}) {
+			level, fields = l, f
+		}), stacktrace.PropagateWithSeverity(errors.New("plain"), severity, "msg"))
+		assert.Equal(t, expected, level, "severity: %v", severity)
+		if severity == stacktrace.SeverityUnset {
+			assert.NotContains(t, fields, "severity")
+		} else {
+			assert.Equal(t, severity.String(), fields["severity"])
+		}
+	}
+}
+
+func TestMarshalYAMLSeverity(t *testing.T) {
+	err := stacktrace.NewErrorWithSeverity(stacktrace.SeverityWarning, "msg")
+	out, yerr := yaml.Marshal(err)
+	assert.NoError(t, yerr)
+	assert.Contains(t, string(out), "message: msg\nseverity: warning\n")
+}
@@ -0,0 +1,123 @@
+package stacktrace
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+/*
+NewSlogHandler returns a slog.Handler that passes records on to next, with every
+attribute holding a Stacktrace error replaced by a group describing it, so that
+existing call sites like
+
+	logger.Error("Failed to handle request", "err", err)
+
+produce structured errors:
+
+	{"msg":"Failed to handle request","err":{"msg":"Failed to load user: no rows","code":65532,"code_name":"stacktrace.NoRows","location":"github.com/palantir/shield/users.go:42","frames":["github.com/palantir/shield/users.go:42 (Load)","github.com/palantir/shield/handler.go:17 (Handle)"]}}
+
+The group holds the brief format of the error as msg, the fields that LogAt
+emits, the location of the outermost call site and the locations of all levels
+of the chain as frames, outermost first. Errors that merely wrap a Stacktrace
+error are expanded too, with their own Error() as msg.
+*/
+func NewSlogHandler(next slog.Handler) slog.Handler {
+	return &slogHandler{next: next}
+}
+
+type slogHandler struct {
+	next slog.Handler
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	expanded := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		expanded.AddAttrs(expandAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, expanded)
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	expanded := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		expanded[i] = expandAttr(a)
+	}
+	return &slogHandler{next: h.next.WithAttrs(expanded)}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	return &slogHandler{next: h.next.WithGroup(name)}
+}
+
+// expandAttr replaces a Stacktrace error in a by a group, looking into groups.
+func expandAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		expanded := make([]slog.Attr, len(attrs))
+		for i, attr := range attrs {
+			expanded[i] = expandAttr(attr)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(expanded...)}
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			var st *Stacktrace
+			if errors.As(err, &st) && st != nil {
+				return slog.Attr{Key: a.Key, Value: slog.GroupValue(errorAttrs(err, st)...)}
+			}
+		}
+	}
+	return a
+}
+
+// errorAttrs describes err, whose chain contains st, as slog attributes.
+func errorAttrs(err error, st *Stacktrace) []slog.Attr {
+	msg := err.Error()
+	if err == error(st) {
+		msg = formatBrief(st)
+	}
+	attrs := []slog.Attr{slog.String("msg", msg)}
+
+	fields := errorMetadata(st)
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		attrs = append(attrs, slog.Any(key, fields[key]))
+	}
+
+	frames := locations(st)
+	if len(frames) > 0 {
+		var b strings.Builder
+		writeLocation(&b, st.File, st.Line, "")
+		attrs = append(attrs, slog.String("location", b.String()), slog.Any("frames", frames))
+	}
+	return attrs
+}
+
+// locations returns "File:Line (Function)" for each level of the chain of st
+// that has a location, outermost first.
+func locations(st *Stacktrace) []string {
+	levels, _ := chain(st)
+	var frames []string
+	for _, level := range levels {
+		if level.File == "" {
+			continue
+		}
+		var b strings.Builder
+		writeLocation(&b, level.File, level.Line, level.Function)
+		frames = append(frames, b.String())
+	}
+	return frames
+}
@@ -0,0 +1,56 @@
+package stacktrace_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(stacktrace.NewSlogHandler(slog.NewJSONHandler(&buf, nil)))
+
+	err := stacktrace.PropagateWithCode(startDoing(), EcodeNotFastEnough, "Failed to start")
+	logger.Error("Request failed", "err", err, "user", "fury")
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "Request failed", record["msg"])
+	assert.Equal(t, "fury", record["user"])
+	expanded := record["err"].(map[string]interface{})
+	assert.Equal(t, "Failed to start: failed to start doing", expanded["msg"])
+	assert.Equal(t, float64(EcodeNotFastEnough), expanded["code"])
+	assert.Regexp(t, `^github.com/palantir/Stacktrace/slog_test.go:\d+$`, expanded["location"])
+	frames := expanded["frames"].([]interface{})
+	require.Len(t, frames, 2)
+	assert.Regexp(t, `^github.com/palantir/Stacktrace/slog_test.go:\d+ \(TestSlogHandler\)$`, frames[0])
+	assert.Regexp(t, `^github.com/palantir/Stacktrace/functions_for_test.go:\d+ \(startDoing\)$`, frames[1])
+}
+
+func TestSlogHandlerGroups(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(stacktrace.NewSlogHandler(slog.NewJSONHandler(&buf, nil)))
+
+	err := stacktrace.NewError("Failed")
+	logger.With("cause", err).WithGroup("req").Error("Request failed",
+		slog.Group("details", "err", fmt.Errorf("handling: %w", err)),
+		"plain", errors.New("plain"),
+	)
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "Failed", record["cause"].(map[string]interface{})["msg"])
+	req := record["req"].(map[string]interface{})
+	assert.Equal(t, "plain", req["plain"])
+	wrapped := req["details"].(map[string]interface{})["err"].(map[string]interface{})
+	assert.Equal(t, "handling: "+err.Error(), wrapped["msg"])
+	assert.Contains(t, wrapped, "frames")
+}
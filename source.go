@@ -0,0 +1,94 @@
+package stacktrace
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sourceContextLines is the number of lines shown before and after the
+// offending line by FormatFullWithSource.
+const sourceContextLines = 2
+
+/*
+Source returns the lines of source code from the Frame's Line minus context to
+its Line plus context, along with the number of the first returned Line. It
+returns no lines if the File cannot be found on disk or is shorter than Line.
+*/
+func (f Frame) Source(context int) (first int, lines []string) {
+	first = f.Line - context
+	if first < 1 {
+		first = 1
+	}
+	lines = readLines(locateSource(f.File), first, f.Line+context)
+	if first+len(lines)-1 < f.Line {
+		return first, nil
+	}
+	return first, lines
+}
+
+// writeSource writes the lines of file surrounding line, one per output line,
+// with line marked by ">". Nothing is written if the file cannot be read.
+func writeSource(b *strings.Builder, file string, line int) {
+	first, lines := Frame{File: file, Line: line}.Source(sourceContextLines)
+	if len(lines) == 0 {
+		return
+	}
+
+	width := len(strconv.Itoa(first + len(lines) - 1))
+	for i, text := range lines {
+		num := strconv.Itoa(first + i)
+		b.WriteByte('\n')
+		if first+i == line {
+			b.WriteString("   > ")
+		} else {
+			b.WriteString("     ")
+		}
+		b.WriteString(strings.Repeat(" ", width-len(num)))
+		b.WriteString(num)
+		b.WriteString(" | ")
+		b.WriteString(text)
+	}
+}
+
+// locateSource finds file on disk. The file name has usually been shortened by
+// CleanPath, so besides file itself, look for it relative to the src directory
+// of each $GOPATH entry. Returns "" if the file can't be found.
+func locateSource(file string) string {
+	candidates := []string{file}
+	if !filepath.IsAbs(file) {
+		for _, dir := range filepath.SplitList(os.Getenv("GOPATH")) {
+			candidates = append(candidates, filepath.Join(dir, "src", file))
+		}
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// readLines returns lines first through last (1-based, inclusive) of the named
+// file, or fewer if the file is shorter. Returns nil if the file can't be read.
+func readLines(name string, first, last int) []string {
+	if name == "" {
+		return nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for n := 1; n <= last && scanner.Scan(); n++ {
+		if n >= first {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	return lines
+}
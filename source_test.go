@@ -0,0 +1,44 @@
+package stacktrace_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func snippet() error {
+	// the line before
+	return stacktrace.NewError("snippet")
+	// the line after
+}
+
+func TestFormatFullWithSource(t *testing.T) {
+	defer func(format stacktrace.Format) { stacktrace.DefaultFormat = format }(stacktrace.DefaultFormat)
+	defer func(cleanPath func(string) string) { stacktrace.CleanPath = cleanPath }(stacktrace.CleanPath)
+	stacktrace.DefaultFormat = stacktrace.FormatFullWithSource
+	stacktrace.CleanPath = nil
+
+	err := snippet()
+	st := err.(*stacktrace.Stacktrace)
+	expected := strings.Join([]string{
+		"snippet",
+		fmt.Sprintf(" --- at %s:%d (snippet) ---", st.File, st.Line),
+		fmt.Sprintf("     %d | func snippet() error {", st.Line-2),
+		fmt.Sprintf("     %d | \t// the line before", st.Line-1),
+		fmt.Sprintf("   > %d | \treturn stacktrace.NewError(\"snippet\")", st.Line),
+		fmt.Sprintf("     %d | \t// the line after", st.Line+1),
+		fmt.Sprintf("     %d | }", st.Line+2),
+	}, "\n")
+	assert.Equal(t, expected, err.Error())
+
+	// "%+s" is the plain full format
+	assert.Equal(t, fmt.Sprintf("snippet\n --- at %s:%d (snippet) ---", st.File, st.Line), fmt.Sprintf("%+s", err))
+
+	// no source available
+	st.File = "nonexistent/file.go"
+	assert.Equal(t, fmt.Sprintf("snippet\n --- at nonexistent/file.go:%d (snippet) ---", st.Line), err.Error())
+}
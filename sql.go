@@ -0,0 +1,130 @@
+package stacktrace
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+/*
+Error codes attached by PropagateSQL to errors from database/sql and the common
+drivers (lib/pq, pgx and go-sql-driver/mysql). They are NoCode, which leaves
+the corresponding errors uncoded, until UseStandardCodes allocates them, or they
+can be set to an application's own codes:
+
+	Stacktrace.EcodeNoRows = EcodeNotFound
+*/
+var (
+	EcodeNoRows               = NoCode
+	EcodeUniqueViolation      = NoCode
+	EcodeForeignKeyViolation  = NoCode
+	EcodeNotNullViolation     = NoCode
+	EcodeCheckViolation       = NoCode
+	EcodeSerializationFailure = NoCode
+	EcodeDeadlock             = NoCode
+)
+
+/*
+PropagateSQL is like Propagate, but attaches an error Code to errors returned by
+database/sql, so that repository layers produce consistently coded errors:
+
+	err := db.QueryRowContext(ctx, q, id).Scan(&user.Name)
+	if err != nil {
+		return Stacktrace.PropagateSQL(err, "Failed to load user %d", id)
+	}
+
+See SQLCode for the errors that are recognized. Serialization failures and
+deadlocks are also marked retryable, see IsRetryable. Other errors are
+propagated as by Propagate.
+*/
+func PropagateSQL(cause error, msg string, vals ...interface{}) error {
+	if cause == nil {
+		// Allow calling PropagateSQL without checking whether there is error
+		return nil
+	}
+	code := SQLCode(cause)
+	return createWith(cause, code, func(st *Stacktrace) {
+		if code != NoCode && (code == EcodeSerializationFailure || code == EcodeDeadlock) {
+			retryable := true
+			st.Retryable = &retryable
+		}
+	}, msg, vals...)
+}
+
+/*
+SQLCode returns the error Code for err, looking through wrappers: EcodeNoRows for
+sql.ErrNoRows, and for constraint violations, serialization failures and
+deadlocks reported by the database, the corresponding Code. It returns NoCode
+for other errors.
+
+Postgres errors are recognized by their SQLSTATE, from drivers whose errors have
+a method
+
+	SQLState() string
+
+such as lib/pq and pgx. MySQL errors are recognized by their error number.
+*/
+func SQLCode(err error) ErrorCode {
+	if errors.Is(err, sql.ErrNoRows) {
+		return EcodeNoRows
+	}
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, ok := err.(interface{ SQLState() string }); ok {
+			if code, ok := sqlStateCodes()[e.SQLState()]; ok {
+				return code
+			}
+			return NoCode
+		}
+		if number, ok := mysqlNumber(err); ok {
+			if code, ok := mysqlCodes()[number]; ok {
+				return code
+			}
+			return NoCode
+		}
+	}
+	return NoCode
+}
+
+// sqlStateCodes maps Postgres SQLSTATE values to error codes. It is built on
+// every call since the codes are variables.
+func sqlStateCodes() map[string]ErrorCode {
+	return map[string]ErrorCode{
+		"23505": EcodeUniqueViolation,
+		"23503": EcodeForeignKeyViolation,
+		"23502": EcodeNotNullViolation,
+		"23514": EcodeCheckViolation,
+		"40001": EcodeSerializationFailure,
+		"40P01": EcodeDeadlock,
+	}
+}
+
+// mysqlCodes maps MySQL error numbers to error codes like sqlStateCodes.
+func mysqlCodes() map[uint16]ErrorCode {
+	return map[uint16]ErrorCode{
+		1062: EcodeUniqueViolation,     // ER_DUP_ENTRY
+		1451: EcodeForeignKeyViolation, // ER_ROW_IS_REFERENCED_2
+		1452: EcodeForeignKeyViolation, // ER_NO_REFERENCED_ROW_2
+		1048: EcodeNotNullViolation,    // ER_BAD_NULL_ERROR
+		3819: EcodeCheckViolation,      // ER_CHECK_CONSTRAINT_VIOLATED
+		1213: EcodeDeadlock,            // ER_LOCK_DEADLOCK
+	}
+}
+
+// mysqlNumber returns the Number field of a *mysql.MySQLError from
+// go-sql-driver/mysql, without depending on that package.
+func mysqlNumber(err error) (uint16, bool) {
+	v := reflect.ValueOf(err)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return 0, false
+	}
+	t := v.Elem().Type()
+	if t.Name() != "MySQLError" || !strings.HasSuffix(t.PkgPath(), "/mysql") {
+		return 0, false
+	}
+	number := v.Elem().FieldByName("Number")
+	if !number.IsValid() || number.Kind() != reflect.Uint16 {
+		return 0, false
+	}
+	return uint16(number.Uint()), true
+}
@@ -0,0 +1,66 @@
+package stacktrace_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+type pgError struct{ state string }
+
+func (e *pgError) Error() string    { return "pq: " + e.state }
+func (e *pgError) SQLState() string { return e.state }
+
+func TestSQLCode(t *testing.T) {
+	stacktrace.UseStandardCodes()
+	for _, testcase := range []struct {
+		err      error
+		expected stacktrace.ErrorCode
+	}{
+		{err: nil, expected: stacktrace.NoCode},
+		{err: errors.New("plain"), expected: stacktrace.NoCode},
+		{err: sql.ErrNoRows, expected: stacktrace.EcodeNoRows},
+		{err: fmt.Errorf("scan: %w", sql.ErrNoRows), expected: stacktrace.EcodeNoRows},
+		{err: &pgError{"23505"}, expected: stacktrace.EcodeUniqueViolation},
+		{err: &pgError{"23503"}, expected: stacktrace.EcodeForeignKeyViolation},
+		{err: &pgError{"23502"}, expected: stacktrace.EcodeNotNullViolation},
+		{err: &pgError{"23514"}, expected: stacktrace.EcodeCheckViolation},
+		{err: fmt.Errorf("commit: %w", &pgError{"40001"}), expected: stacktrace.EcodeSerializationFailure},
+		{err: &pgError{"40P01"}, expected: stacktrace.EcodeDeadlock},
+		{err: &pgError{"42601"}, expected: stacktrace.NoCode},
+	} {
+		assert.Equal(t, testcase.expected, stacktrace.SQLCode(testcase.err), "error: %v", testcase.err)
+	}
+	assert.Equal(t, "stacktrace.NoRows", stacktrace.CodeName(stacktrace.EcodeNoRows))
+}
+
+func TestPropagateSQL(t *testing.T) {
+	stacktrace.UseStandardCodes()
+	assert.Nil(t, stacktrace.PropagateSQL(nil, "msg"))
+
+	err := stacktrace.PropagateSQL(sql.ErrNoRows, "Failed to load user %d", 7)
+	assert.Equal(t, stacktrace.EcodeNoRows, stacktrace.GetCode(err))
+	assert.False(t, stacktrace.IsRetryable(err))
+	assert.Equal(t, "Failed to load user 7: sql: no rows in result set", fmt.Sprintf("%#s", err))
+	assert.Equal(t, "TestPropagateSQL", err.(*stacktrace.Stacktrace).Function)
+
+	err = stacktrace.PropagateSQL(&pgError{"40001"}, "Failed to commit")
+	assert.Equal(t, stacktrace.EcodeSerializationFailure, stacktrace.GetCode(err))
+	assert.True(t, stacktrace.IsRetryable(err))
+
+	// unrecognized errors keep the Code of the cause
+	err = stacktrace.PropagateSQL(stacktrace.NewErrorWithCode(EcodeNotFastEnough, "inner"), "outer")
+	assert.Equal(t, EcodeNotFastEnough, stacktrace.GetCode(err))
+	assert.False(t, stacktrace.IsRetryable(err))
+
+	defer func(code stacktrace.ErrorCode) { stacktrace.EcodeDeadlock = code }(stacktrace.EcodeDeadlock)
+	stacktrace.EcodeDeadlock = stacktrace.NoCode
+	err = stacktrace.PropagateSQL(&pgError{"40P01"}, "Failed to commit")
+	assert.Equal(t, stacktrace.NoCode, stacktrace.GetCode(err))
+	assert.False(t, stacktrace.IsRetryable(err))
+}
@@ -15,28 +15,33 @@
 package stacktrace
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/palantir/stacktrace/cleanpath"
 )
 
 /*
 CleanPath Function is applied to File paths before adding them to a Stacktrace.
-By default, it makes the path relative to the $GOPATH environment variable.
+By default, it makes the path relative to the $GOPATH environment variable,
+the module cache or $GOROOT, see cleanpath.Default.
 
 To remove some additional prefix like "github.com" from File paths in
 stacktraces, use something like:
 
-	Stacktrace.CleanPath = func(path string) string {
-		path = cleanpath.RemoveGoPath(path)
-		path = strings.TrimPrefix(path, "github.com/")
-		return path
-	}
+	Stacktrace.CleanPath = cleanpath.Chain(
+		cleanpath.Default,
+		cleanpath.TrimPrefixes("github.com/"),
+	)
+
+The default is memoized per File with cleanpath.Memoize, since errors are mostly
+created in the same few files; consider doing the same for custom functions.
 */
-var CleanPath = cleanpath.RemoveGoPath
+var CleanPath = cleanpath.Memoize(cleanpath.Default, 1024)
 
 /*
 NewError is a drop-in replacement for fmt.Errorf that includes Line number
@@ -89,6 +94,70 @@ func Propagate(cause error, msg string, vals ...interface{}) error {
 	return create(cause, NoCode, msg, vals...)
 }
 
+/*
+PropagateSkip is like Propagate, but attributes the new level to a caller
+further up the stack, for helpers that propagate errors on behalf of their
+callers. skip is the number of additional frames to ascend: with 0, it is
+equivalent to Propagate, and with 1 the File, Line, Function and stack are the
+ones of the caller of the Function that called PropagateSkip. Hooks see the
+adjusted location.
+*/
+func PropagateSkip(skip int, cause error, msg string, vals ...interface{}) error {
+	if cause == nil {
+		return nil
+	}
+	return createSkip(skip, cause, NoCode, nil, msg, vals...)
+}
+
+/*
+NewErrorSkip is like NewError, but attributes the error to a caller further up
+the stack, like PropagateSkip.
+*/
+func NewErrorSkip(skip int, msg string, vals ...interface{}) error {
+	return createSkip(skip, nil, NoCode, nil, msg, vals...)
+}
+
+/*
+PropagateAll is like Propagate for an operation that failed for several reasons
+at once, such as a loop over independent items. The causes are combined with
+errors.Join and nil causes are ignored:
+
+	var errs []error
+	for _, replica := range replicas {
+		errs = append(errs, replica.Sync())
+	}
+	return Stacktrace.PropagateAll(errs, "Failed to sync replicas")
+
+Both formats render each of the causes separately. If all causes are nil,
+PropagateAll returns nil. With a single non-nil Cause, it is equivalent to
+Propagate.
+*/
+func PropagateAll(causes []error, msg string, vals ...interface{}) error {
+	cause := joinCauses(causes)
+	if cause == nil {
+		return nil
+	}
+	return create(cause, NoCode, msg, vals...)
+}
+
+// joinCauses combines the non-nil causes with errors.Join, except that a
+// single Cause is returned as is and no causes at all result in nil.
+func joinCauses(causes []error) error {
+	var nonNil []error
+	for _, cause := range causes {
+		if cause != nil {
+			nonNil = append(nonNil, cause)
+		}
+	}
+	switch len(nonNil) {
+	case 0:
+		return nil
+	case 1:
+		return nonNil[0]
+	}
+	return errors.Join(nonNil...)
+}
+
 /*
 ErrorCode is a Code that can be attached to an error as it is passed/propagated
 up the stack.
@@ -148,10 +217,20 @@ itself even where stack traces with Line numbers are not warranted.
 	}
 */
 func NewMessageWithCode(code ErrorCode, msg string, vals ...interface{}) error {
-	return &Stacktrace{
-		Message: fmt.Sprintf(msg, vals...),
-		Code:    code,
+	message, args, argsDropped := render(msg, vals)
+	err := &Stacktrace{
+		Message:     message,
+		Code:        code,
+		ID:          stampID(nil),
+		Time:        timestamp(),
+		Process:     processInfo(),
+		Build:       buildInfo(),
+		format:      msg,
+		args:        args,
+		argsDropped: argsDropped,
 	}
+	runHooks(err)
+	return err
 }
 
 /*
@@ -168,24 +247,82 @@ GetCode extracts the error Code from an error.
 
 GetCode returns the special value Stacktrace.NoCode if err is nil or if there is
 no error Code attached to err.
+
+GetCode looks through other wrappers of a Stacktrace error, such as
+fmt.Errorf("context: %w", err), to the nearest error with a Code. If err has
+several causes, such as an error from errors.Join, PropagateAll,
+go.uber.org/multierr or github.com/hashicorp/go-multierror, GetCode returns the
+Code of the first of them that has one.
 */
 func GetCode(err error) ErrorCode {
-	if err, ok := err.(*Stacktrace); ok {
+	if err, ok := err.(*Stacktrace); ok && err != nil {
 		return err.Code
 	}
+	if causes, ok := branchesOf(err); ok {
+		for _, cause := range causes {
+			if code := GetCode(cause); code != NoCode {
+				return code
+			}
+		}
+		return NoCode
+	}
+	if cause := errors.Unwrap(err); cause != nil {
+		return GetCode(cause)
+	}
 	return NoCode
 }
 
+/*
+Codes returns every distinct error Code in the cause chain of err, outermost
+first. Where GetCode only returns the Code of the outermost level, Codes also
+shows the codes that were reclassified along the way:
+
+	err := Stacktrace.PropagateWithCode(timeoutErr, EcodeUnavailable, "")
+	Stacktrace.Codes(err) // [EcodeUnavailable EcodeTimeout]
+
+Codes looks through the same wrappers and multiple causes as GetCode. It returns
+nil if there are no codes in the chain.
+*/
+func Codes(err error) []ErrorCode {
+	var codes []ErrorCode
+	found := make(map[ErrorCode]bool)
+	Walk(err, func(err error) bool {
+		if st, ok := err.(*Stacktrace); ok && st.Code != NoCode && !found[st.Code] {
+			found[st.Code] = true
+			codes = append(codes, st.Code)
+		}
+		return true
+	})
+	return codes
+}
+
+/*
+GetCause returns the error wrapped by err, one level down:
+
+  - for a Stacktrace error, its Cause, which is nil for NewError and the like
+  - for an error with an Unwrap() error method, such as one from fmt.Errorf with
+    %w, the result of errors.Unwrap
+  - for any other error, including errors with several causes, err itself
+
+GetCause returns nil if err is nil.
+*/
 func GetCause(err error) error {
-	if err, ok := err.(*Stacktrace); ok {
+	if err, ok := err.(*Stacktrace); ok && err != nil {
 		return err.Cause
 	}
+	if cause := errors.Unwrap(err); cause != nil {
+		return cause
+	}
 	return err
 }
 
+/*
+GetMessage returns an error with just the Message of err, without its Cause or
+location, if err is a Stacktrace error. Otherwise it returns err itself.
+*/
 func GetMessage(err error) error {
-	if err, ok := err.(*Stacktrace); ok {
-		return fmt.Errorf(err.Message)
+	if err, ok := err.(*Stacktrace); ok && err != nil {
+		return errors.New(err.message())
 	}
 	return err
 }
@@ -197,47 +334,134 @@ type Stacktrace struct {
 	File     string
 	Function string
 	Line     int
+	// Stack holds the callers of the call site above, innermost first. It is
+	// only populated when CaptureStack is enabled.
+	Stack []Frame
+	// OmittedFrames is the number of callers left out of Stack because of
+	// MaxFrames.
+	OmittedFrames int
+	// Suppressed holds secondary errors attached by AddSuppressed.
+	Suppressed []error
+	// StringCode is the string Code attached by NewErrorWithStringCode or
+	// PropagateWithStringCode, or inherited from the Cause.
+	StringCode string
+	// ID is the unique ID assigned to the chain when AssignIDs is enabled.
+	ID string
+	// TraceID and SpanID identify the distributed trace the error occurred
+	// in. See PropagateCtx.
+	TraceID string
+	SpanID  string
+	// Time is when the error was created, if CaptureTimestamps is enabled.
+	Time time.Time
+	// Process is where the error was created, if CaptureProcessInfo is
+	// enabled.
+	Process *ProcessInfo
+	// Build is the binary the error was created in, if CaptureBuildInfo is
+	// enabled.
+	Build *BuildInfo
+	// Retryable is the mark attached by WithRetryable or MarkRetryable, or
+	// inherited from the Cause. It is nil if the error is not marked.
+	Retryable *bool
+	// RetryAt is the time after which to retry, attached by WithRetryAfter or
+	// WithRetryAt, or inherited from the Cause.
+	RetryAt time.Time
+	// Severity is the Severity attached by NewErrorWithSeverity or
+	// PropagateWithSeverity, or inherited from the Cause.
+	Severity Severity
+
+	// format and args are the msg and vals arguments that Message was
+	// rendered from, after SanitizeArgs. If there were vals but CaptureArgs
+	// was disabled, args is nil and argsDropped is set.
+	format      string
+	args        []interface{}
+	argsDropped bool
+	// lazy produces the Message of errors from NewErrorLazy and PropagateLazy.
+	lazy *lazyText
+	// exitStatus is the exit status of the command that failed, see WrapExec.
+	exitStatus int
+	// remoteCause marks a Cause that was created in another process, see
+	// Graft.
+	remoteCause bool
+	// preferred is the Format that overrides DefaultFormat for st, see
+	// WithFormat.
+	preferred *Format
+	// goroutines is the dump of all goroutines attached by WithGoroutineDump.
+	goroutines string
 }
 
 func create(cause error, code ErrorCode, msg string, vals ...interface{}) error {
+	return newStacktrace(0, cause, code, nil, msg, vals...)
+}
+
+// createWith is like create, but calls apply to set additional fields before
+// the hooks see the new error.
+func createWith(cause error, code ErrorCode, apply func(*Stacktrace), msg string, vals ...interface{}) error {
+	return newStacktrace(0, cause, code, apply, msg, vals...)
+}
+
+// createSkip is like createWith, but the location is the one skip frames
+// above the caller of the exported function, see PropagateSkip.
+func createSkip(skip int, cause error, code ErrorCode, apply func(*Stacktrace), msg string, vals ...interface{}) error {
+	return newStacktrace(skip, cause, code, apply, msg, vals...)
+}
+
+func newStacktrace(skip int, cause error, code ErrorCode, apply func(*Stacktrace), msg string, vals ...interface{}) error {
 	// If no error Code specified, inherit error Code from the Cause.
 	if code == NoCode {
 		code = GetCode(cause)
 	}
 
+	message, args, argsDropped := render(msg, vals)
 	err := &Stacktrace{
-		Message: fmt.Sprintf(msg, vals...),
-		Cause:   cause,
-		Code:    code,
+		Message:     message,
+		Cause:       cause,
+		Code:        code,
+		StringCode:  GetStringCode(cause),
+		Retryable:   retryableOf(cause),
+		RetryAt:     retryAtOf(cause),
+		Severity:    GetSeverity(cause),
+		ID:          stampID(cause),
+		Time:        timestamp(),
+		Process:     processInfo(),
+		Build:       buildInfo(),
+		format:      msg,
+		args:        args,
+		argsDropped: argsDropped,
+		preferred:   preferredFormatOf(cause),
 	}
 
-	// Caller of create is NewError or Propagate, so user's Code is 2 up.
-	pc, file, line, ok := runtime.Caller(2)
-	if !ok {
-		return err
-	}
-	if CleanPath != nil {
-		file = CleanPath(file)
-	}
-	err.File, err.Line = file, line
+	// The frames above newStacktrace are create, createWith or createSkip,
+	// then the exported function that was called, such as NewError or
+	// Propagate, then the user's code, which is 3 up, plus the frames that
+	// createSkip was asked to skip.
+	if pc, file, line, ok := runtime.Caller(3 + skip); ok {
+		if CleanPath != nil {
+			file = CleanPath(file)
+		}
+		err.File, err.Line = file, line
+
+		if CaptureStack {
+			err.Stack, err.OmittedFrames = captureStack(4 + skip)
+		}
 
-	f := runtime.FuncForPC(pc)
-	if f == nil {
-		return err
+		if f := runtime.FuncForPC(pc); f != nil {
+			err.Function = shortFuncName(f.Name())
+		}
 	}
-	err.Function = shortFuncName(f)
 
+	if apply != nil {
+		apply(err)
+	}
+	runHooks(err)
 	return err
 }
 
 /* "FuncName" or "Receiver.MethodName" */
-func shortFuncName(f *runtime.Func) string {
-	// f.Name() is like one of these:
+func shortFuncName(longName string) string {
+	// longName is like one of these:
 	// - "github.com/palantir/shield/package.FuncName"
 	// - "github.com/palantir/shield/package.Receiver.MethodName"
 	// - "github.com/palantir/shield/package.(*PtrReceiver).MethodName"
-	longName := f.Name()
-
 	withoutPath := longName[strings.LastIndex(longName, "/")+1:]
 	withoutPackage := withoutPath[strings.Index(withoutPath, ".")+1:]
 
@@ -253,11 +477,20 @@ func (st *Stacktrace) Error() string {
 	return fmt.Sprint(st)
 }
 
+// Unwrap returns the Cause of st, so that errors.Unwrap, errors.Is and errors.As
+// look through Stacktrace errors. The causes passed to PropagateAll are joined
+// by errors.Join, which errors.Is and errors.As look through in turn.
+func (st *Stacktrace) Unwrap() error {
+	if st == nil {
+		return nil
+	}
+	return st.Cause
+}
+
 // ExitCode returns the exit Code associated with the Stacktrace error based on its error Code. If the error Code is
-// NoCode, return 1 (default); otherwise, returns the value of the error Code.
+// NoCode, return the exit status of the failed command from WrapExec, if any, or 1 (default); otherwise, returns the
+// exit status registered for the error Code with RegisterExitCode, or the value of the error Code clamped to the range
+// of valid exit statuses.
 func (st *Stacktrace) ExitCode() int {
-	if st.Code == NoCode {
-		return 1
-	}
-	return int(st.Code)
+	return ExitCode(st)
 }
@@ -79,6 +79,12 @@ func TestGetCode(t *testing.T) {
 		err = stacktrace.Propagate(err, "")
 		assert.Equal(t, test.originalCode, stacktrace.GetCode(err))
 
+		err = fmt.Errorf("wrapped: %w", err)
+		assert.Equal(t, test.originalCode, stacktrace.GetCode(err))
+
+		err = stacktrace.Propagate(err, "")
+		assert.Equal(t, test.originalCode, stacktrace.GetCode(err))
+
 		err = stacktrace.PropagateWithCode(err, EcodeNotFastEnough, "")
 		assert.Equal(t, EcodeNotFastEnough, stacktrace.GetCode(err))
 
@@ -87,6 +93,53 @@ func TestGetCode(t *testing.T) {
 	}
 }
 
+func TestCodes(t *testing.T) {
+	timeout := stacktrace.NewErrorWithCode(EcodeTimeIsIllusion, "timeout")
+	unavailable := stacktrace.PropagateWithCode(stacktrace.Propagate(timeout, ""), EcodeNotFastEnough, "")
+	wrapped := fmt.Errorf("wrapped: %w", stacktrace.PropagateWithCode(unavailable, EcodeTimeIsIllusion, ""))
+	joined := stacktrace.PropagateAll([]error{unavailable, stacktrace.NewErrorWithCode(EcodeInvalidVillain, "")}, "")
+
+	assert.Nil(t, stacktrace.Codes(nil))
+	assert.Nil(t, stacktrace.Codes(errors.New("plain")))
+	assert.Nil(t, stacktrace.Codes(stacktrace.NewError("msg")))
+	assert.Equal(t, []stacktrace.ErrorCode{EcodeTimeIsIllusion}, stacktrace.Codes(timeout))
+	assert.Equal(t, []stacktrace.ErrorCode{EcodeNotFastEnough, EcodeTimeIsIllusion}, stacktrace.Codes(unavailable))
+	assert.Equal(t, []stacktrace.ErrorCode{EcodeTimeIsIllusion, EcodeNotFastEnough}, stacktrace.Codes(wrapped))
+	assert.Equal(t, []stacktrace.ErrorCode{EcodeNotFastEnough, EcodeTimeIsIllusion, EcodeInvalidVillain}, stacktrace.Codes(joined))
+}
+
+func TestGetCause(t *testing.T) {
+	plain := errors.New("plain")
+	propagated := stacktrace.Propagate(plain, "")
+	joined := errors.Join(plain, propagated)
+
+	assert.Nil(t, stacktrace.GetCause(nil))
+	assert.Nil(t, stacktrace.GetCause(stacktrace.NewError("msg")))
+	assert.Equal(t, plain, stacktrace.GetCause(plain))
+	assert.Equal(t, plain, stacktrace.GetCause(propagated))
+	assert.Equal(t, propagated, stacktrace.GetCause(fmt.Errorf("wrapped: %w", propagated)))
+	assert.Equal(t, joined, stacktrace.GetCause(joined))
+}
+
+func TestGetMessage(t *testing.T) {
+	plain := errors.New("plain")
+	assert.Equal(t, errors.New("100% done"), stacktrace.GetMessage(stacktrace.Propagate(plain, "100%% done")))
+	assert.Equal(t, plain, stacktrace.GetMessage(plain))
+	assert.Nil(t, stacktrace.GetMessage(nil))
+}
+
+func TestLiteral(t *testing.T) {
+	err := stacktrace.NewErrorLiteral("100% failed: %s").(*stacktrace.Stacktrace)
+	assert.Equal(t, "100% failed: %s", err.Message)
+	assert.Equal(t, "TestLiteral", err.Function)
+
+	propagated := stacktrace.PropagateLiteral(err, "50% done").(*stacktrace.Stacktrace)
+	assert.Equal(t, "50% done: 100% failed: %s", fmt.Sprintf("%#s", propagated))
+	assert.Equal(t, "TestLiteral", propagated.Function)
+
+	assert.Nil(t, stacktrace.PropagateLiteral(nil, "msg"))
+}
+
 func TestPropagateNil(t *testing.T) {
 	var err error
 
@@ -98,3 +151,49 @@ func TestPropagateNil(t *testing.T) {
 
 	assert.Equal(t, stacktrace.NoCode, stacktrace.GetCode(err))
 }
+
+func TestPropagateAll(t *testing.T) {
+	assert.Nil(t, stacktrace.PropagateAll(nil, "msg"))
+	assert.Nil(t, stacktrace.PropagateAll([]error{nil, nil}, "msg"))
+
+	plain := errors.New("plain")
+	single := stacktrace.PropagateAll([]error{nil, plain}, "msg")
+	assert.Equal(t, plain, single.(*stacktrace.Stacktrace).Cause)
+
+	first := stacktrace.NewErrorWithCode(EcodeNoSuchPseudo, "first")
+	err := stacktrace.PropagateAll([]error{first, nil, plain}, "failed %d times", 2)
+	assert.Equal(t, "failed 2 times: [first; plain]", fmt.Sprintf("%#s", err))
+	assert.True(t, errors.Is(err, plain))
+	assert.True(t, errors.Is(err, first))
+	var st *stacktrace.Stacktrace
+	assert.True(t, errors.As(err.(*stacktrace.Stacktrace).Cause, &st))
+	assert.Equal(t, first, st)
+}
+
+func TestPropagateSkip(t *testing.T) {
+	defer func(capture bool) { stacktrace.CaptureStack = capture }(stacktrace.CaptureStack)
+	stacktrace.CaptureStack = true
+	propagate := func(cause error) error { return stacktrace.PropagateSkip(1, cause, "skipped") }
+	plain := errors.New("plain")
+	var hooked []string
+	defer stacktrace.RegisterHook(func(st *stacktrace.Stacktrace) { hooked = append(hooked, st.Function) })()
+
+	err := propagate(plain).(*stacktrace.Stacktrace)
+	assert.Equal(t, "TestPropagateSkip", err.Function)
+	// the stack starts above the location, like for Propagate
+	assert.Equal(t, "tRunner", err.Stack[0].Function)
+	assert.Equal(t, []string{"TestPropagateSkip"}, hooked)
+	assert.Equal(t, "TestPropagateSkip", stacktrace.PropagateSkip(0, plain, "").(*stacktrace.Stacktrace).Function)
+	assert.Nil(t, propagate(nil))
+
+	newError := func() error { return stacktrace.NewErrorSkip(1, "skipped") }
+	assert.Equal(t, "TestPropagateSkip", newError().(*stacktrace.Stacktrace).Function)
+}
+
+func TestUnwrap(t *testing.T) {
+	plain := errors.New("plain")
+	assert.Equal(t, plain, stacktrace.Propagate(plain, "").(*stacktrace.Stacktrace).Unwrap())
+	assert.Equal(t, plain, errors.Unwrap(stacktrace.Propagate(plain, "msg")))
+	assert.Nil(t, stacktrace.NewError("msg").(*stacktrace.Stacktrace).Unwrap())
+	assert.True(t, errors.Is(stacktrace.Propagate(stacktrace.Propagate(plain, "a"), "b"), plain))
+}
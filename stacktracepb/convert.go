@@ -0,0 +1,181 @@
+/*
+Package stacktracepb converts Stacktrace errors to and from a protobuf message,
+so that errors can be embedded in gRPC status details and other protobuf
+envelopes in a language-agnostic way. The message is defined in
+stacktrace.proto:
+
+	st, _ := status.New(codes.Internal, err.Error()).WithDetails(stacktracepb.ToProto(err))
+*/
+package stacktracepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative stacktrace.proto
+
+import (
+	"errors"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/palantir/stacktrace"
+)
+
+/*
+ToProto converts err to an Error message, keeping its chain with codes, frames
+and causes. Causes that are not Stacktraces keep only their text. The Process,
+Build, OmittedFrames and exit status of each level, the marks of
+stacktrace.WithFormat and stacktrace.Graft and the dump of
+stacktrace.WithGoroutineDump are not kept; MarshalBinary of a Stacktrace keeps
+all of them. A chain that is cyclic or deeper than stacktrace.MaxChainDepth is
+cut with a cause of text stacktrace.ChainTruncated. ToProto returns nil if err
+is nil or an error with several causes that are all nil.
+*/
+func ToProto(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	return toProto(err, stacktrace.NewChain())
+}
+
+func toProto(err error, c *stacktrace.Chain) *Error {
+	levels, rest, truncated := c.Split(err)
+	pb := &Error{}
+	for _, st := range levels {
+		pb.Levels = append(pb.Levels, toLevel(st, c))
+	}
+	switch {
+	case truncated:
+		pb.Cause = &Error{Text: stacktrace.ChainTruncated}
+	case len(levels) == 0:
+		return causeProto(err, c)
+	case rest != nil:
+		pb.Cause = causeProto(rest, c)
+	}
+	if len(pb.Levels) == 0 {
+		// err itself was seen before
+		return pb.Cause
+	}
+	return pb
+}
+
+// causeProto converts an error that is not a Stacktrace.
+func causeProto(err error, c *stacktrace.Chain) *Error {
+	causes, ok := stacktrace.Branches(err)
+	if !ok {
+		return &Error{Text: err.Error()}
+	}
+	var branches []*Error
+	for _, cause := range causes {
+		if branch := toProto(cause, c.Branch()); branch != nil {
+			branches = append(branches, branch)
+		}
+	}
+	if len(branches) == 0 {
+		return nil
+	}
+	return &Error{Branches: branches}
+}
+
+func toLevel(st *stacktrace.Stacktrace, c *stacktrace.Chain) *Level {
+	level := &Level{
+		Message:    stacktrace.GetMessage(st).Error(),
+		StringCode: st.StringCode,
+		Id:         st.ID,
+		TraceId:    st.TraceID,
+		SpanId:     st.SpanID,
+		Severity:   Severity(st.Severity),
+	}
+	if st.Retryable != nil {
+		retryable := *st.Retryable
+		level.Retryable = &retryable
+	}
+	if st.Code != stacktrace.NoCode {
+		code := uint32(st.Code)
+		level.Code = &code
+	}
+	if st.File != "" || st.Function != "" {
+		level.Location = &Frame{File: st.File, Function: st.Function, Line: int32(st.Line)}
+	}
+	for _, frame := range st.Stack {
+		level.Stack = append(level.Stack, &Frame{File: frame.File, Function: frame.Function, Line: int32(frame.Line)})
+	}
+	for _, suppressed := range st.Suppressed {
+		level.Suppressed = append(level.Suppressed, toProto(suppressed, c.Branch()))
+	}
+	if !st.Time.IsZero() {
+		level.Time = timestamppb.New(st.Time)
+	}
+	if !st.RetryAt.IsZero() {
+		level.RetryAt = timestamppb.New(st.RetryAt)
+	}
+	level.Format, _ = stacktrace.Template(st)
+	return level
+}
+
+/*
+FromProto reconstructs the error converted by ToProto. Causes that were not
+Stacktraces are reconstructed as errors with just their text, and several causes
+with errors.Join. The format string of each level is not restored, so
+stacktrace.Template returns "" for the result. FromProto returns nil if pb is
+nil or empty, as an Error with an empty list of branches is, so a cause whose
+text was empty is not restored.
+*/
+func FromProto(pb *Error) error {
+	if pb == nil {
+		return nil
+	}
+	if len(pb.Levels) == 0 {
+		if len(pb.Branches) > 0 {
+			causes := make([]error, len(pb.Branches))
+			for i, branch := range pb.Branches {
+				causes[i] = FromProto(branch)
+			}
+			return errors.Join(causes...)
+		}
+		if pb.Text == "" {
+			// An error with several causes that are all nil
+			return nil
+		}
+		return errors.New(pb.Text)
+	}
+
+	cause := FromProto(pb.Cause)
+	for i := len(pb.Levels) - 1; i >= 0; i-- {
+		cause = fromLevel(pb.Levels[i], cause)
+	}
+	return cause
+}
+
+func fromLevel(level *Level, cause error) *stacktrace.Stacktrace {
+	st := &stacktrace.Stacktrace{
+		Message:    level.Message,
+		Cause:      cause,
+		Code:       stacktrace.NoCode,
+		File:       level.GetLocation().GetFile(),
+		Function:   level.GetLocation().GetFunction(),
+		Line:       int(level.GetLocation().GetLine()),
+		StringCode: level.StringCode,
+		ID:         level.Id,
+		TraceID:    level.TraceId,
+		SpanID:     level.SpanId,
+		Severity:   stacktrace.Severity(level.Severity),
+	}
+	if level.Retryable != nil {
+		retryable := *level.Retryable
+		st.Retryable = &retryable
+	}
+	if level.Code != nil {
+		st.Code = stacktrace.ErrorCode(*level.Code)
+	}
+	for _, frame := range level.Stack {
+		st.Stack = append(st.Stack, stacktrace.Frame{File: frame.File, Function: frame.Function, Line: int(frame.Line)})
+	}
+	for _, suppressed := range level.Suppressed {
+		st.Suppressed = append(st.Suppressed, FromProto(suppressed))
+	}
+	if level.Time != nil {
+		st.Time = level.Time.AsTime()
+	}
+	if level.RetryAt != nil {
+		st.RetryAt = level.RetryAt.AsTime()
+	}
+	return st
+}
@@ -0,0 +1,112 @@
+package stacktracepb_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/palantir/stacktrace"
+	"github.com/palantir/stacktrace/stacktracepb"
+)
+
+func TestRoundTrip(t *testing.T) {
+	defer func(capture bool) { stacktrace.CaptureStack = capture }(stacktrace.CaptureStack)
+	stacktrace.CaptureStack = true
+
+	err := stacktrace.NewErrorWithSeverity(stacktrace.SeverityWarning, "inner %d", 1)
+	err = stacktrace.AddSuppressed(err, stacktrace.NewErrorWithStringCode("CLEANUP", "cleanup failed"))
+	err = stacktrace.PropagateAll([]error{err, errors.New("plain")}, "middle")
+	err = stacktrace.MarkRetryable(err)
+	err = stacktrace.WithRetryAfter(err, time.Minute)
+	err = stacktrace.PropagateWithCode(err, 7, "outer")
+
+	pb := stacktracepb.ToProto(err)
+	assert.Equal(t, "outer", pb.Levels[0].Format)
+	assert.Equal(t, uint32(7), pb.Levels[0].GetCode())
+	assert.Equal(t, "TestRoundTrip", pb.Levels[0].GetLocation().GetFunction())
+
+	// through the wire, embedded in an Any as in gRPC status details
+	packed, perr := anypb.New(pb)
+	require.NoError(t, perr)
+	data, merr := proto.Marshal(packed)
+	require.NoError(t, merr)
+	var unpacked anypb.Any
+	require.NoError(t, proto.Unmarshal(data, &unpacked))
+	var decodedPB stacktracepb.Error
+	require.NoError(t, unpacked.UnmarshalTo(&decodedPB))
+
+	decoded := stacktracepb.FromProto(&decodedPB)
+	assert.Equal(t, fmt.Sprintf("%+s", err), fmt.Sprintf("%+s", decoded))
+	assert.Equal(t, fmt.Sprintf("%#s", err), fmt.Sprintf("%#s", decoded))
+	assert.Equal(t, stacktrace.ErrorCode(7), stacktrace.GetCode(decoded))
+	assert.Equal(t, stacktrace.SeverityWarning, stacktrace.GetSeverity(decoded))
+	assert.True(t, stacktrace.IsRetryable(decoded))
+	_, ok := stacktrace.RetryAfter(decoded)
+	assert.True(t, ok)
+	assert.Equal(t, err.(*stacktrace.Stacktrace).Stack, decoded.(*stacktrace.Stacktrace).Stack)
+}
+
+func TestNil(t *testing.T) {
+	assert.Nil(t, stacktracepb.ToProto(nil))
+	assert.Nil(t, stacktracepb.FromProto(nil))
+}
+
+func TestPlain(t *testing.T) {
+	pb := stacktracepb.ToProto(errors.New("plain"))
+	assert.Equal(t, "plain", pb.Text)
+	assert.EqualError(t, stacktracepb.FromProto(pb), "plain")
+}
+
+func TestCycle(t *testing.T) {
+	inner := stacktrace.NewError("inner").(*stacktrace.Stacktrace)
+	outer := stacktrace.Propagate(inner, "outer").(*stacktrace.Stacktrace)
+	inner.Cause = outer
+
+	decoded := stacktracepb.FromProto(stacktracepb.ToProto(outer))
+	assert.Equal(t, "outer: inner: ... truncated", fmt.Sprintf("%#s", decoded))
+}
+
+// wrapped is like a *multierror.Error from github.com/hashicorp/go-multierror.
+type wrapped []error
+
+func (w wrapped) Error() string          { return "several errors" }
+func (w wrapped) WrappedErrors() []error { return w }
+
+// listed is like an error from go.uber.org/multierr.
+type listed []error
+
+func (l listed) Error() string   { return "several errors" }
+func (l listed) Errors() []error { return l }
+
+func TestMultiErrors(t *testing.T) {
+	for _, multi := range []error{wrapped{errors.New("a"), nil, errors.New("b")}, listed{errors.New("a"), errors.New("b")}} {
+		pb := stacktracepb.ToProto(stacktrace.Propagate(multi, "outer"))
+		require.Len(t, pb.Cause.GetBranches(), 2)
+		assert.Equal(t, "b", pb.Cause.Branches[1].Text)
+		assert.Equal(t, "outer: [a; b]", fmt.Sprintf("%#s", stacktracepb.FromProto(pb)))
+	}
+}
+
+func TestEmptyBranches(t *testing.T) {
+	assert.Nil(t, stacktracepb.ToProto(errors.Join(listed{}, nil)))
+	assert.Nil(t, stacktracepb.FromProto(stacktracepb.ToProto(listed{})))
+
+	pb := stacktracepb.ToProto(stacktrace.Propagate(wrapped{nil}, "outer"))
+	assert.Nil(t, pb.Cause)
+	assert.Nil(t, stacktracepb.FromProto(&stacktracepb.Error{Branches: []*stacktracepb.Error{}}))
+}
+
+func TestMaxChainDepth(t *testing.T) {
+	defer func(depth int) { stacktrace.MaxChainDepth = depth }(stacktrace.MaxChainDepth)
+	stacktrace.MaxChainDepth = 2
+
+	err := stacktrace.Propagate(stacktrace.Propagate(stacktrace.NewError("inner"), "middle"), "outer")
+	decoded := stacktracepb.FromProto(stacktracepb.ToProto(err))
+	assert.Equal(t, "outer: middle: "+stacktrace.ChainTruncated, fmt.Sprintf("%#s", decoded))
+}
@@ -0,0 +1,482 @@
+// Wire format for the error chains of github.com/palantir/stacktrace, for
+// embedding errors in gRPC status details and other protobuf envelopes.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: stacktrace.proto
+
+package stacktracepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Severity is how serious an error is.
+type Severity int32
+
+const (
+	Severity_SEVERITY_UNSET    Severity = 0
+	Severity_SEVERITY_DEBUG    Severity = 1
+	Severity_SEVERITY_INFO     Severity = 2
+	Severity_SEVERITY_WARNING  Severity = 3
+	Severity_SEVERITY_ERROR    Severity = 4
+	Severity_SEVERITY_CRITICAL Severity = 5
+)
+
+// Enum value maps for Severity.
+var (
+	Severity_name = map[int32]string{
+		0: "SEVERITY_UNSET",
+		1: "SEVERITY_DEBUG",
+		2: "SEVERITY_INFO",
+		3: "SEVERITY_WARNING",
+		4: "SEVERITY_ERROR",
+		5: "SEVERITY_CRITICAL",
+	}
+	Severity_value = map[string]int32{
+		"SEVERITY_UNSET":    0,
+		"SEVERITY_DEBUG":    1,
+		"SEVERITY_INFO":     2,
+		"SEVERITY_WARNING":  3,
+		"SEVERITY_ERROR":    4,
+		"SEVERITY_CRITICAL": 5,
+	}
+)
+
+func (x Severity) Enum() *Severity {
+	p := new(Severity)
+	*p = x
+	return p
+}
+
+func (x Severity) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Severity) Descriptor() protoreflect.EnumDescriptor {
+	return file_stacktrace_proto_enumTypes[0].Descriptor()
+}
+
+func (Severity) Type() protoreflect.EnumType {
+	return &file_stacktrace_proto_enumTypes[0]
+}
+
+func (x Severity) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Severity.Descriptor instead.
+func (Severity) EnumDescriptor() ([]byte, []int) {
+	return file_stacktrace_proto_rawDescGZIP(), []int{0}
+}
+
+// Error is an error in a chain: a run of Stacktrace levels followed by their
+// cause, several causes at once, or the text of any other error.
+type Error struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The Stacktrace levels, outermost first.
+	Levels []*Level `protobuf:"bytes,1,rep,name=levels,proto3" json:"levels,omitempty"`
+	// The cause of the last level, if any.
+	Cause *Error `protobuf:"bytes,2,opt,name=cause,proto3" json:"cause,omitempty"`
+	// The causes of an error with several causes, such as errors.Join.
+	Branches []*Error `protobuf:"bytes,3,rep,name=branches,proto3" json:"branches,omitempty"`
+	// The text of an error that is neither a Stacktrace nor has several causes.
+	Text          string `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_stacktrace_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_stacktrace_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_stacktrace_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Error) GetLevels() []*Level {
+	if x != nil {
+		return x.Levels
+	}
+	return nil
+}
+
+func (x *Error) GetCause() *Error {
+	if x != nil {
+		return x.Cause
+	}
+	return nil
+}
+
+func (x *Error) GetBranches() []*Error {
+	if x != nil {
+		return x.Branches
+	}
+	return nil
+}
+
+func (x *Error) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+// Level is a single level of a Stacktrace chain.
+type Level struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Message string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// The error code, absent for NoCode.
+	Code       *uint32 `protobuf:"varint,2,opt,name=code,proto3,oneof" json:"code,omitempty"`
+	StringCode string  `protobuf:"bytes,3,opt,name=string_code,json=stringCode,proto3" json:"string_code,omitempty"`
+	// Where the level was created.
+	Location *Frame `protobuf:"bytes,4,opt,name=location,proto3" json:"location,omitempty"`
+	// The callers of the location, innermost first, if captured.
+	Stack      []*Frame               `protobuf:"bytes,5,rep,name=stack,proto3" json:"stack,omitempty"`
+	Suppressed []*Error               `protobuf:"bytes,6,rep,name=suppressed,proto3" json:"suppressed,omitempty"`
+	Id         string                 `protobuf:"bytes,7,opt,name=id,proto3" json:"id,omitempty"`
+	TraceId    string                 `protobuf:"bytes,8,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	SpanId     string                 `protobuf:"bytes,9,opt,name=span_id,json=spanId,proto3" json:"span_id,omitempty"`
+	Time       *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=time,proto3" json:"time,omitempty"`
+	Severity   Severity               `protobuf:"varint,11,opt,name=severity,proto3,enum=palantir.stacktrace.v1.Severity" json:"severity,omitempty"`
+	// Whether the error may be retried, absent if it is not marked.
+	Retryable *bool                  `protobuf:"varint,12,opt,name=retryable,proto3,oneof" json:"retryable,omitempty"`
+	RetryAt   *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=retry_at,json=retryAt,proto3" json:"retry_at,omitempty"`
+	// The format string the message was rendered from.
+	Format        string `protobuf:"bytes,14,opt,name=format,proto3" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Level) Reset() {
+	*x = Level{}
+	mi := &file_stacktrace_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Level) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Level) ProtoMessage() {}
+
+func (x *Level) ProtoReflect() protoreflect.Message {
+	mi := &file_stacktrace_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Level.ProtoReflect.Descriptor instead.
+func (*Level) Descriptor() ([]byte, []int) {
+	return file_stacktrace_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Level) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Level) GetCode() uint32 {
+	if x != nil && x.Code != nil {
+		return *x.Code
+	}
+	return 0
+}
+
+func (x *Level) GetStringCode() string {
+	if x != nil {
+		return x.StringCode
+	}
+	return ""
+}
+
+func (x *Level) GetLocation() *Frame {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *Level) GetStack() []*Frame {
+	if x != nil {
+		return x.Stack
+	}
+	return nil
+}
+
+func (x *Level) GetSuppressed() []*Error {
+	if x != nil {
+		return x.Suppressed
+	}
+	return nil
+}
+
+func (x *Level) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Level) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *Level) GetSpanId() string {
+	if x != nil {
+		return x.SpanId
+	}
+	return ""
+}
+
+func (x *Level) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Level) GetSeverity() Severity {
+	if x != nil {
+		return x.Severity
+	}
+	return Severity_SEVERITY_UNSET
+}
+
+func (x *Level) GetRetryable() bool {
+	if x != nil && x.Retryable != nil {
+		return *x.Retryable
+	}
+	return false
+}
+
+func (x *Level) GetRetryAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RetryAt
+	}
+	return nil
+}
+
+func (x *Level) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+// Frame is a location in the source code.
+type Frame struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	File          string                 `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	Function      string                 `protobuf:"bytes,2,opt,name=function,proto3" json:"function,omitempty"`
+	Line          int32                  `protobuf:"varint,3,opt,name=line,proto3" json:"line,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Frame) Reset() {
+	*x = Frame{}
+	mi := &file_stacktrace_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Frame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Frame) ProtoMessage() {}
+
+func (x *Frame) ProtoReflect() protoreflect.Message {
+	mi := &file_stacktrace_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Frame.ProtoReflect.Descriptor instead.
+func (*Frame) Descriptor() ([]byte, []int) {
+	return file_stacktrace_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Frame) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *Frame) GetFunction() string {
+	if x != nil {
+		return x.Function
+	}
+	return ""
+}
+
+func (x *Frame) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+var File_stacktrace_proto protoreflect.FileDescriptor
+
+const file_stacktrace_proto_rawDesc = "" +
+	"\n" +
+	"\x10stacktrace.proto\x12\x16palantir.stacktrace.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc2\x01\n" +
+	"\x05Error\x125\n" +
+	"\x06levels\x18\x01 \x03(\v2\x1d.palantir.stacktrace.v1.LevelR\x06levels\x123\n" +
+	"\x05cause\x18\x02 \x01(\v2\x1d.palantir.stacktrace.v1.ErrorR\x05cause\x129\n" +
+	"\bbranches\x18\x03 \x03(\v2\x1d.palantir.stacktrace.v1.ErrorR\bbranches\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04text\"\xc5\x04\n" +
+	"\x05Level\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x17\n" +
+	"\x04code\x18\x02 \x01(\rH\x00R\x04code\x88\x01\x01\x12\x1f\n" +
+	"\vstring_code\x18\x03 \x01(\tR\n" +
+	"stringCode\x129\n" +
+	"\blocation\x18\x04 \x01(\v2\x1d.palantir.stacktrace.v1.FrameR\blocation\x123\n" +
+	"\x05stack\x18\x05 \x03(\v2\x1d.palantir.stacktrace.v1.FrameR\x05stack\x12=\n" +
+	"\n" +
+	"suppressed\x18\x06 \x03(\v2\x1d.palantir.stacktrace.v1.ErrorR\n" +
+	"suppressed\x12\x0e\n" +
+	"\x02id\x18\a \x01(\tR\x02id\x12\x19\n" +
+	"\btrace_id\x18\b \x01(\tR\atraceId\x12\x17\n" +
+	"\aspan_id\x18\t \x01(\tR\x06spanId\x12.\n" +
+	"\x04time\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12<\n" +
+	"\bseverity\x18\v \x01(\x0e2 .palantir.stacktrace.v1.SeverityR\bseverity\x12!\n" +
+	"\tretryable\x18\f \x01(\bH\x01R\tretryable\x88\x01\x01\x125\n" +
+	"\bretry_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\aretryAt\x12\x16\n" +
+	"\x06format\x18\x0e \x01(\tR\x06formatB\a\n" +
+	"\x05_codeB\f\n" +
+	"\n" +
+	"_retryable\"K\n" +
+	"\x05Frame\x12\x12\n" +
+	"\x04file\x18\x01 \x01(\tR\x04file\x12\x1a\n" +
+	"\bfunction\x18\x02 \x01(\tR\bfunction\x12\x12\n" +
+	"\x04line\x18\x03 \x01(\x05R\x04line*\x86\x01\n" +
+	"\bSeverity\x12\x12\n" +
+	"\x0eSEVERITY_UNSET\x10\x00\x12\x12\n" +
+	"\x0eSEVERITY_DEBUG\x10\x01\x12\x11\n" +
+	"\rSEVERITY_INFO\x10\x02\x12\x14\n" +
+	"\x10SEVERITY_WARNING\x10\x03\x12\x12\n" +
+	"\x0eSEVERITY_ERROR\x10\x04\x12\x15\n" +
+	"\x11SEVERITY_CRITICAL\x10\x05B-Z+github.com/palantir/stacktrace/stacktracepbb\x06proto3"
+
+var (
+	file_stacktrace_proto_rawDescOnce sync.Once
+	file_stacktrace_proto_rawDescData []byte
+)
+
+func file_stacktrace_proto_rawDescGZIP() []byte {
+	file_stacktrace_proto_rawDescOnce.Do(func() {
+		file_stacktrace_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_stacktrace_proto_rawDesc), len(file_stacktrace_proto_rawDesc)))
+	})
+	return file_stacktrace_proto_rawDescData
+}
+
+var file_stacktrace_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_stacktrace_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_stacktrace_proto_goTypes = []any{
+	(Severity)(0),                 // 0: palantir.stacktrace.v1.Severity
+	(*Error)(nil),                 // 1: palantir.stacktrace.v1.Error
+	(*Level)(nil),                 // 2: palantir.stacktrace.v1.Level
+	(*Frame)(nil),                 // 3: palantir.stacktrace.v1.Frame
+	(*timestamppb.Timestamp)(nil), // 4: google.protobuf.Timestamp
+}
+var file_stacktrace_proto_depIdxs = []int32{
+	2, // 0: palantir.stacktrace.v1.Error.levels:type_name -> palantir.stacktrace.v1.Level
+	1, // 1: palantir.stacktrace.v1.Error.cause:type_name -> palantir.stacktrace.v1.Error
+	1, // 2: palantir.stacktrace.v1.Error.branches:type_name -> palantir.stacktrace.v1.Error
+	3, // 3: palantir.stacktrace.v1.Level.location:type_name -> palantir.stacktrace.v1.Frame
+	3, // 4: palantir.stacktrace.v1.Level.stack:type_name -> palantir.stacktrace.v1.Frame
+	1, // 5: palantir.stacktrace.v1.Level.suppressed:type_name -> palantir.stacktrace.v1.Error
+	4, // 6: palantir.stacktrace.v1.Level.time:type_name -> google.protobuf.Timestamp
+	0, // 7: palantir.stacktrace.v1.Level.severity:type_name -> palantir.stacktrace.v1.Severity
+	4, // 8: palantir.stacktrace.v1.Level.retry_at:type_name -> google.protobuf.Timestamp
+	9, // [9:9] is the sub-list for method output_type
+	9, // [9:9] is the sub-list for method input_type
+	9, // [9:9] is the sub-list for extension type_name
+	9, // [9:9] is the sub-list for extension extendee
+	0, // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_stacktrace_proto_init() }
+func file_stacktrace_proto_init() {
+	if File_stacktrace_proto != nil {
+		return
+	}
+	file_stacktrace_proto_msgTypes[1].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_stacktrace_proto_rawDesc), len(file_stacktrace_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_stacktrace_proto_goTypes,
+		DependencyIndexes: file_stacktrace_proto_depIdxs,
+		EnumInfos:         file_stacktrace_proto_enumTypes,
+		MessageInfos:      file_stacktrace_proto_msgTypes,
+	}.Build()
+	File_stacktrace_proto = out.File
+	file_stacktrace_proto_goTypes = nil
+	file_stacktrace_proto_depIdxs = nil
+}
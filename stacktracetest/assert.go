@@ -0,0 +1,88 @@
+/*
+Package stacktracetest provides assertions on errors created with the
+stacktrace package, for use in tests:
+
+	func TestLoad(t *testing.T) {
+		_, err := Load("missing.yaml")
+		stacktracetest.AssertCode(t, err, EcodeManifestNotFound)
+		stacktracetest.AssertCauseIs(t, err, os.ErrNotExist)
+		stacktracetest.AssertPropagatedFrom(t, err, "manifest.Load")
+	}
+
+Like the assertions of github.com/stretchr/testify, each of them reports a
+failure with t.Errorf and returns whether it passed.
+*/
+package stacktracetest
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/palantir/stacktrace"
+)
+
+// AssertCode asserts that the error code of err, as returned by
+// stacktrace.GetCode, is code.
+func AssertCode(t testing.TB, err error, code stacktrace.ErrorCode) bool {
+	t.Helper()
+	if actual := stacktrace.GetCode(err); actual != code {
+		t.Errorf("expected error code %s, got %s in error:\n%v", stacktrace.CodeName(code), stacktrace.CodeName(actual), err)
+		return false
+	}
+	return true
+}
+
+// AssertMessageContains asserts that err.Error(), which includes the messages
+// of all its causes, contains substr.
+func AssertMessageContains(t testing.TB, err error, substr string) bool {
+	t.Helper()
+	if err == nil {
+		t.Errorf("expected an error containing %q, got nil", substr)
+		return false
+	}
+	if !strings.Contains(err.Error(), substr) {
+		t.Errorf("expected an error containing %q, got:\n%v", substr, err)
+		return false
+	}
+	return true
+}
+
+// AssertCauseIs asserts that errors.Is(err, target) is true.
+func AssertCauseIs(t testing.TB, err, target error) bool {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("expected an error caused by %q, got:\n%v", target, err)
+		return false
+	}
+	return true
+}
+
+/*
+AssertPropagatedFrom asserts that some level of err was created or propagated in
+the given function. The name is either the Function of a Stacktrace level, like
+"Load" or "Manifest.Load", or that name qualified by the last element of the
+directory of its File, which is usually the package name:
+
+	stacktracetest.AssertPropagatedFrom(t, err, "manifest.Load")
+*/
+func AssertPropagatedFrom(t testing.TB, err error, function string) bool {
+	t.Helper()
+	var found []string
+	matched := false
+	stacktrace.Walk(err, func(err error) bool {
+		st, ok := err.(*stacktrace.Stacktrace)
+		if !ok || st.File == "" {
+			return true
+		}
+		qualified := path.Base(path.Dir(st.File)) + "." + st.Function
+		matched = function == st.Function || function == qualified
+		found = append(found, qualified)
+		return !matched
+	})
+	if !matched {
+		t.Errorf("expected an error propagated from %s, got one from [%s]:\n%v", function, strings.Join(found, ", "), err)
+	}
+	return matched
+}
@@ -0,0 +1,62 @@
+package stacktracetest_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+	"github.com/palantir/stacktrace/stacktracetest"
+)
+
+const (
+	EcodeTimeout = stacktrace.ErrorCode(iota)
+	EcodeUnavailable
+)
+
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func load() error {
+	return stacktrace.PropagateWithCode(io.EOF, EcodeTimeout, "Failed to load")
+}
+
+func TestAssertions(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", stacktrace.Propagate(load(), "Failed to start"))
+
+	r := &recorder{TB: t}
+	assert.True(t, stacktracetest.AssertCode(r, err, EcodeTimeout))
+	assert.True(t, stacktracetest.AssertMessageContains(r, err, "Failed to load"))
+	assert.True(t, stacktracetest.AssertCauseIs(r, err, io.EOF))
+	assert.True(t, stacktracetest.AssertPropagatedFrom(r, err, "load"))
+	assert.True(t, stacktracetest.AssertPropagatedFrom(r, err, "stacktracetest.TestAssertions"))
+	assert.Empty(t, r.failures)
+
+	assert.False(t, stacktracetest.AssertCode(r, err, EcodeUnavailable))
+	assert.False(t, stacktracetest.AssertMessageContains(r, err, "Failed to stop"))
+	assert.False(t, stacktracetest.AssertMessageContains(r, nil, "Failed"))
+	assert.False(t, stacktracetest.AssertCauseIs(r, err, io.ErrUnexpectedEOF))
+	assert.False(t, stacktracetest.AssertPropagatedFrom(r, err, "other.load"))
+	if assert.Len(t, r.failures, 5) {
+		for i, prefix := range []string{
+			"expected error code 1, got 0 in error:\nwrapped: Failed to start\n",
+			"expected an error containing \"Failed to stop\", got:\nwrapped: Failed to start\n",
+			"expected an error containing \"Failed\", got nil",
+			"expected an error caused by \"unexpected EOF\", got:\nwrapped: Failed to start\n",
+			"expected an error propagated from other.load, got one from [stacktracetest.TestAssertions, stacktracetest.load]:\nwrapped: Failed to start\n",
+		} {
+			assert.True(t, strings.HasPrefix(r.failures[i], prefix), r.failures[i])
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package stacktracetest
+
+import (
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/palantir/stacktrace"
+)
+
+/*
+EquateStacktraces returns a cmp.Option for github.com/google/go-cmp that compares
+Stacktrace errors with stacktrace.EqualIgnoringFrames, so that cmp.Diff only
+reports differences in their messages, codes and cause structure:
+
+	expected := &Result{Err: stacktrace.NewErrorWithCode(EcodeBadInput, "Missing ttl")}
+	if diff := cmp.Diff(expected, actual, stacktracetest.EquateStacktraces()); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+
+The option applies to pairs of errors of which at least one is a Stacktrace.
+*/
+func EquateStacktraces() cmp.Option {
+	return cmp.FilterValues(areStacktraces, cmp.Comparer(equateStacktraces))
+}
+
+func areStacktraces(x, y interface{}) bool {
+	_, okX := x.(error)
+	_, okY := y.(error)
+	_, stX := x.(*stacktrace.Stacktrace)
+	_, stY := y.(*stacktrace.Stacktrace)
+	return okX && okY && (stX || stY)
+}
+
+func equateStacktraces(x, y interface{}) bool {
+	return stacktrace.EqualIgnoringFrames(x.(error), y.(error))
+}
@@ -0,0 +1,38 @@
+package stacktracetest_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+	"github.com/palantir/stacktrace/stacktracetest"
+)
+
+type result struct {
+	Value int
+	Err   error
+}
+
+func TestEquateStacktraces(t *testing.T) {
+	expected := result{Value: 1, Err: stacktrace.PropagateWithCode(io.EOF, EcodeTimeout, "Failed to load")}
+
+	assert.Equal(t, "", cmp.Diff(expected, result{Value: 1, Err: load()}, stacktracetest.EquateStacktraces()))
+	assert.Equal(t, "", cmp.Diff(
+		[]*stacktrace.Stacktrace{expected.Err.(*stacktrace.Stacktrace)},
+		[]*stacktrace.Stacktrace{load().(*stacktrace.Stacktrace)},
+		stacktracetest.EquateStacktraces()))
+
+	for _, actual := range []result{
+		{Value: 2, Err: load()},
+		{Value: 1, Err: stacktrace.Propagate(load(), "")},
+		{Value: 1, Err: stacktrace.PropagateWithCode(io.EOF, EcodeUnavailable, "Failed to load")},
+		{Value: 1, Err: errors.New("Failed to load")},
+		{Value: 1},
+	} {
+		assert.NotEqual(t, "", cmp.Diff(expected, actual, stacktracetest.EquateStacktraces()))
+	}
+}
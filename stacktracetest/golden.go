@@ -0,0 +1,24 @@
+package stacktracetest
+
+import "regexp"
+
+var (
+	goLines    = regexp.MustCompile(`(\.go:)\d+`)
+	fieldLines = regexp.MustCompile(`\b(line(?:=|: ))\d+`)
+)
+
+/*
+StripLocations replaces the Line numbers in formatted errors with "#", so that
+golden files of rendered errors do not need updating whenever unrelated code
+moves:
+
+	Failed to load manifest
+	 --- at github.com/palantir/shield/manifest.go:# (Load) ---
+
+It handles the "File.go:Line" locations of the full, brief and tree formats as
+well as the "line=" and "line:" fields of the logfmt and YAML output.
+*/
+func StripLocations(s string) string {
+	s = goLines.ReplaceAllString(s, "${1}#")
+	return fieldLines.ReplaceAllString(s, "${1}#")
+}
@@ -0,0 +1,35 @@
+package stacktracetest_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace/stacktracetest"
+)
+
+func TestStripLocations(t *testing.T) {
+	for _, test := range []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "msg\n --- at github.com/palantir/shield/manifest.go:51 (Load) ---\n     at main.go:7",
+			expected: "msg\n --- at github.com/palantir/shield/manifest.go:# (Load) ---\n     at main.go:#",
+		},
+		{
+			input:    "msg=failed file=app/config.go line=44",
+			expected: "msg=failed file=app/config.go line=#",
+		},
+		{
+			input:    "message: failed\nline: 44\nfunction: Load",
+			expected: "message: failed\nline: #\nfunction: Load",
+		},
+		{
+			input:    "deadline=30 port:8080 timeline: 5",
+			expected: "deadline=30 port:8080 timeline: 5",
+		},
+	} {
+		assert.Equal(t, test.expected, stacktracetest.StripLocations(test.input))
+	}
+}
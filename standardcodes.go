@@ -0,0 +1,59 @@
+package stacktrace
+
+import "sync"
+
+var standardCodesOnce sync.Once
+
+/*
+UseStandardCodes enables the error codes that this package attaches on its own:
+EcodeDeadline and EcodeCanceled for PropagateCtx, the codes of PropagateSQL and
+those of PropagateSyscall. Until it is called they are NoCode, so that the
+package takes no codes away from an application and the corresponding errors
+are left uncoded. The codes that are still NoCode are allocated from the
+CodeSpace "stacktrace" and named like "stacktrace.Deadline", while those already
+set to an application's own codes are kept:
+
+	func main() {
+		Stacktrace.EcodeNoRows = EcodeNotFound
+		Stacktrace.UseStandardCodes()
+		...
+	}
+
+UseStandardCodes is meant to be called once at startup, before errors are
+created, and does nothing when called again. It panics like NewCodeSpace if the
+application already has a CodeSpace named "stacktrace".
+*/
+func UseStandardCodes() {
+	standardCodesOnce.Do(func() {
+		space := NewCodeSpace("stacktrace")
+		for _, standard := range standardCodes() {
+			if *standard.code == NoCode {
+				*standard.code = space.Code(standard.name, standard.description)
+			}
+		}
+	})
+}
+
+type standardCode struct {
+	code              *ErrorCode
+	name, description string
+}
+
+// standardCodes lists the codes enabled by UseStandardCodes, in the order they
+// are allocated.
+func standardCodes() []standardCode {
+	return []standardCode{
+		{&EcodeDeadline, "Deadline", "The context deadline was exceeded"},
+		{&EcodeCanceled, "Canceled", "The context was canceled"},
+		{&EcodeNoRows, "NoRows", "The query returned no rows"},
+		{&EcodeUniqueViolation, "UniqueViolation", "A unique constraint was violated"},
+		{&EcodeForeignKeyViolation, "ForeignKeyViolation", "A foreign key constraint was violated"},
+		{&EcodeNotNullViolation, "NotNullViolation", "A not-null constraint was violated"},
+		{&EcodeCheckViolation, "CheckViolation", "A check constraint was violated"},
+		{&EcodeSerializationFailure, "SerializationFailure", "The transaction could not be serialized"},
+		{&EcodeDeadlock, "Deadlock", "The transaction was aborted to resolve a deadlock"},
+		{&EcodePermission, "Permission", "The operation is not permitted"},
+		{&EcodeNotFound, "NotFound", "The file or directory does not exist"},
+		{&EcodeNoSpace, "NoSpace", "There is no space left on the device"},
+	}
+}
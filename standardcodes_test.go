@@ -0,0 +1,22 @@
+package stacktrace_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestUseStandardCodes(t *testing.T) {
+	stacktrace.UseStandardCodes()
+	deadline := stacktrace.EcodeDeadline
+	assert.NotEqual(t, stacktrace.NoCode, deadline)
+	assert.Equal(t, "stacktrace.Deadline", stacktrace.CodeName(deadline))
+	assert.Equal(t, "stacktrace.NoSpace", stacktrace.CodeName(stacktrace.EcodeNoSpace))
+	assert.NotEqual(t, stacktrace.EcodeNotFound, stacktrace.EcodeNoRows)
+
+	// the codes are only allocated once
+	stacktrace.UseStandardCodes()
+	assert.Equal(t, deadline, stacktrace.EcodeDeadline)
+}
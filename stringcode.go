@@ -0,0 +1,53 @@
+package stacktrace
+
+import "errors"
+
+/*
+NewErrorWithStringCode is similar to NewErrorWithCode but attaches a string Code
+such as "RATE_LIMITED", for errors whose codes are part of an API:
+
+	if !limiter.Allow() {
+		return Stacktrace.NewErrorWithStringCode("RATE_LIMITED", "Too many requests from %v", client)
+	}
+
+String codes are independent of numeric ones; an error can have both.
+*/
+func NewErrorWithStringCode(code string, msg string, vals ...interface{}) error {
+	return createWith(nil, NoCode, func(st *Stacktrace) { st.StringCode = code }, msg, vals...)
+}
+
+/*
+PropagateWithStringCode is similar to PropagateWithCode but attaches a string
+Code. An ordinary Stacktrace.Propagate call preserves the string Code of an
+error.
+*/
+func PropagateWithStringCode(cause error, code string, msg string, vals ...interface{}) error {
+	if cause == nil {
+		// Allow calling PropagateWithStringCode without checking whether there is error
+		return nil
+	}
+	return createWith(cause, NoCode, func(st *Stacktrace) { st.StringCode = code }, msg, vals...)
+}
+
+/*
+GetStringCode extracts the string Code from an error, looking through wrappers
+and several causes like GetCode does for numeric codes. It returns "" if err is
+nil or if there is no string Code attached to err.
+*/
+func GetStringCode(err error) string {
+	if err, ok := err.(*Stacktrace); ok && err != nil {
+		return err.StringCode
+	}
+	if causes, ok := branchesOf(err); ok {
+		for _, cause := range causes {
+			if code := GetStringCode(cause); code != "" {
+				return code
+			}
+		}
+		return ""
+	}
+	if cause := errors.Unwrap(err); cause != nil {
+		return GetStringCode(cause)
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestStringCode(t *testing.T) {
+	root := stacktrace.NewErrorWithStringCode("RATE_LIMITED", "slow down")
+	assert.Equal(t, "RATE_LIMITED", stacktrace.GetStringCode(root))
+	assert.Equal(t, stacktrace.NoCode, stacktrace.GetCode(root))
+
+	propagated := stacktrace.PropagateWithCode(root, EcodeTimeIsIllusion, "")
+	assert.Equal(t, "RATE_LIMITED", stacktrace.GetStringCode(propagated))
+	assert.Equal(t, EcodeTimeIsIllusion, stacktrace.GetCode(propagated))
+
+	changed := stacktrace.PropagateWithStringCode(propagated, "UNAVAILABLE", "")
+	assert.Equal(t, "UNAVAILABLE", stacktrace.GetStringCode(changed))
+	assert.Equal(t, EcodeTimeIsIllusion, stacktrace.GetCode(changed))
+
+	assert.Nil(t, stacktrace.PropagateWithStringCode(nil, "UNAVAILABLE", ""))
+	assert.Equal(t, "", stacktrace.GetStringCode(nil))
+	assert.Equal(t, "", stacktrace.GetStringCode(errors.New("plain")))
+
+	assert.Equal(t, "RATE_LIMITED", stacktrace.GetStringCode(fmt.Errorf("wrapped: %w", root)))
+
+	joined := stacktrace.PropagateAll([]error{errors.New("plain"), root}, "")
+	assert.Equal(t, "RATE_LIMITED", stacktrace.GetStringCode(joined))
+}
@@ -0,0 +1,29 @@
+package stacktrace
+
+/*
+AddSuppressed attaches a secondary error to err without replacing it, for
+failures that happen while handling err, such as a failed rollback:
+
+	defer func() {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			err = Stacktrace.AddSuppressed(err, rbErr)
+		}
+	}()
+
+The result wraps err with Line number information for the call to
+AddSuppressed, so the original error is left untouched. The full format shows
+suppressed errors in indented "Suppressed:" sections; the brief format, GetCode,
+RootCause and errors.Is only consider the primary error.
+
+If suppressed is nil, AddSuppressed returns err. If err is nil, there is no
+primary error and AddSuppressed returns suppressed.
+*/
+func AddSuppressed(err, suppressed error) error {
+	if suppressed == nil {
+		return err
+	}
+	if err == nil {
+		return suppressed
+	}
+	return createWith(err, NoCode, func(st *Stacktrace) { st.Suppressed = []error{suppressed} }, "")
+}
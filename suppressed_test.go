@@ -0,0 +1,39 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestAddSuppressed(t *testing.T) {
+	primary := stacktrace.NewErrorWithCode(EcodeTimeIsIllusion, "Failed to insert row")
+	rollback := stacktrace.Propagate(errors.New("connection reset"), "Failed to roll back")
+
+	assert.Equal(t, primary, stacktrace.AddSuppressed(primary, nil))
+	assert.Equal(t, rollback, stacktrace.AddSuppressed(nil, rollback))
+	assert.Nil(t, stacktrace.AddSuppressed(nil, nil))
+
+	err := stacktrace.Propagate(stacktrace.AddSuppressed(primary, rollback), "Failed to save")
+	assert.Equal(t, strings.Join([]string{
+		"Failed to save",
+		" --- at github.com/palantir/Stacktrace/suppressed_test.go:# (TestAddSuppressed) ---",
+		" --- at github.com/palantir/Stacktrace/suppressed_test.go:# (TestAddSuppressed) ---",
+		"    Suppressed: Failed to roll back",
+		"         --- at github.com/palantir/Stacktrace/suppressed_test.go:# (TestAddSuppressed) ---",
+		"        Caused by: connection reset",
+		"Caused by: Failed to insert row",
+		" --- at github.com/palantir/Stacktrace/suppressed_test.go:# (TestAddSuppressed) ---",
+	}, "\n"), normalizeLines(fmt.Sprintf("%+s", err)))
+
+	assert.Equal(t, "Failed to save: Failed to insert row", fmt.Sprintf("%#s", err))
+	assert.Equal(t, EcodeTimeIsIllusion, stacktrace.GetCode(err))
+	assert.Equal(t, errors.New("Failed to insert row"), stacktrace.RootCause(err))
+	assert.True(t, errors.Is(err, primary))
+	assert.False(t, errors.Is(err, rollback))
+}
@@ -0,0 +1,94 @@
+package stacktrace
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+CategorySpace allocates the error codes of one category of a two-level taxonomy
+of errors, such as "Storage/NotFound", so that large programs can keep code
+allocation sane while still matching on the broad category with Category:
+
+	var storage = Stacktrace.NewCategorySpace("Storage", 3000, 3999)
+
+	var (
+		EcodeNotFound  = storage.Code("NotFound", "The object does not exist")
+		EcodeQuotaFull = storage.Code("QuotaFull", "The bucket quota is exhausted")
+	)
+
+	if Stacktrace.Category(err) == "Storage" { ... }
+
+Codes are allocated in order from the start of the range and registered under
+the name "category/subcode", which is how they are shown in formatted output
+when ShowCodes is enabled.
+*/
+type CategorySpace struct {
+	category    string
+	first, last ErrorCode
+	next        ErrorCode
+}
+
+// NewCategorySpace registers the codes from first to last inclusive as a
+// category with RegisterCategory and returns a CategorySpace to allocate them.
+// It panics if RegisterCategory fails, since two categories sharing codes
+// would collide.
+func NewCategorySpace(category string, first, last ErrorCode) *CategorySpace {
+	if category == "" || strings.Contains(category, "/") {
+		panic(fmt.Sprintf("stacktrace: invalid category name %q", category))
+	}
+	// The allocation in Code relies on RegisterCategory rejecting NoCode, so
+	// that next cannot overflow.
+	if err := RegisterCategory(category, first, last); err != nil {
+		panic(err.Error())
+	}
+	return &CategorySpace{category: category, first: first, last: last, next: first}
+}
+
+// Name returns the category of the CategorySpace.
+func (s *CategorySpace) Name() string {
+	return s.category
+}
+
+/*
+Code allocates the next error Code of the category and registers it as
+"category/subcode" with the given description (see RegisterCode). It panics if
+the name is already in use or if the range of the category is exhausted, so it
+is meant for package-level variable declarations.
+*/
+func (s *CategorySpace) Code(subcode, description string) ErrorCode {
+	qualified := s.category + "/" + subcode
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := codesByName[qualified]; ok {
+		panic(fmt.Sprintf("stacktrace: code %q already exists", qualified))
+	}
+	for ; s.next <= s.last; s.next++ {
+		if _, ok := codeInfos[s.next]; !ok {
+			break
+		}
+	}
+	if s.next > s.last {
+		panic(fmt.Sprintf("stacktrace: no codes left in category %q to allocate %q", s.category, qualified))
+	}
+	code := s.next
+	s.next++
+	codeInfos[code] = codeInfo{name: qualified, description: description}
+	codesByName[qualified] = code
+	return code
+}
+
+// CodeSubcode returns the subcode of an error Code allocated by
+// CategorySpace.Code, such as "NotFound" for "Storage/NotFound", or "" if code
+// was not allocated that way.
+func CodeSubcode(code ErrorCode) string {
+	category := CodeCategory(code)
+	if category == "" {
+		return ""
+	}
+	subcode, ok := strings.CutPrefix(CodeName(code), category+"/")
+	if !ok {
+		return ""
+	}
+	return subcode
+}
@@ -0,0 +1,40 @@
+package stacktrace_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestCategorySpace(t *testing.T) {
+	defer func(show bool) { stacktrace.ShowCodes = show }(stacktrace.ShowCodes)
+	stacktrace.ShowCodes = true
+
+	storage := stacktrace.NewCategorySpace("Storage", 40000, 40002)
+	assert.Equal(t, "Storage", storage.Name())
+	// a code in the range that is already registered is skipped
+	assert.NoError(t, stacktrace.RegisterCode(40001, "Taken", "Registered directly"))
+
+	notFound := storage.Code("NotFound", "The object does not exist")
+	quotaFull := storage.Code("QuotaFull", "The bucket quota is exhausted")
+	assert.Equal(t, stacktrace.ErrorCode(40000), notFound)
+	assert.Equal(t, stacktrace.ErrorCode(40002), quotaFull)
+	assert.Equal(t, "Storage/NotFound", stacktrace.CodeName(notFound))
+	assert.Equal(t, "The bucket quota is exhausted", stacktrace.CodeDescription(quotaFull))
+	assert.Equal(t, "NotFound", stacktrace.CodeSubcode(notFound))
+	assert.Equal(t, "", stacktrace.CodeSubcode(40001))
+	assert.Equal(t, "", stacktrace.CodeSubcode(EcodeNotFastEnough))
+
+	err := stacktrace.PropagateWithCode(fmt.Errorf("no such key"), notFound, "Failed to load")
+	assert.Equal(t, "Storage", stacktrace.Category(err))
+	assert.Equal(t, "Failed to load [code=Storage/NotFound]: no such key", fmt.Sprintf("%#s", err))
+
+	assert.PanicsWithValue(t, `stacktrace: code "Storage/NotFound" already exists`, func() { storage.Code("NotFound", "") })
+	assert.PanicsWithValue(t, `stacktrace: no codes left in category "Storage" to allocate "Storage/Other"`, func() { storage.Code("Other", "") })
+	assert.Panics(t, func() { stacktrace.NewCategorySpace("Overlapping", 40002, 40010) })
+	assert.Panics(t, func() { stacktrace.NewCategorySpace("", 41000, 41010) })
+	assert.Panics(t, func() { stacktrace.NewCategorySpace("a/b", 41000, 41010) })
+}
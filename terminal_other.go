@@ -0,0 +1,11 @@
+//go:build !(aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos || windows)
+
+package stacktrace
+
+import "os"
+
+// isTerminal reports false, as there is no way to tell terminals apart on this
+// platform.
+func isTerminal(f *os.File) bool {
+	return false
+}
@@ -0,0 +1,91 @@
+package stacktrace
+
+import "errors"
+
+/*
+Timeout reports whether the Cause of st, or anything it wraps, is a timeout, as
+determined by the first error in the chain with a method
+
+	Timeout() bool
+
+like the errors of package net and os. This way code checking for timeouts the
+net way keeps working after the error is passed through Propagate. Errors
+created by PropagateCtx and NewErrorCtx with the Code EcodeDeadline are
+timeouts too.
+*/
+func (st *Stacktrace) Timeout() bool {
+	if st == nil {
+		return false
+	}
+	return classify(st, func(err error) (bool, bool) {
+		if st, ok := err.(*Stacktrace); ok {
+			deadline := st.Code == EcodeDeadline && EcodeDeadline != NoCode
+			return deadline, deadline
+		}
+		if t, ok := err.(interface{ Timeout() bool }); ok {
+			return t.Timeout(), true
+		}
+		return false, false
+	})
+}
+
+/*
+Temporary reports whether the Cause of st, or anything it wraps, is temporary,
+as determined by the first error in the chain with a method
+
+	Temporary() bool
+
+like Timeout does for timeouts.
+*/
+func (st *Stacktrace) Temporary() bool {
+	if st == nil {
+		return false
+	}
+	return classify(st, func(err error) (bool, bool) {
+		if _, ok := err.(*Stacktrace); ok {
+			return false, false
+		}
+		if t, ok := err.(interface{ Temporary() bool }); ok {
+			return t.Temporary(), true
+		}
+		return false, false
+	})
+}
+
+// classify returns the verdict of the first error in the chain of err that
+// check has one for, looking through wrappers and several causes. It returns
+// false if there is none. check is called with the Stacktrace levels too, so
+// that their own Timeout and Temporary methods, which would walk the rest of
+// the chain again, are not used. Like Walk, it visits each Stacktrace at most
+// once, so that cyclic chains end.
+func classify(err error, check func(error) (verdict, ok bool)) bool {
+	return classifyFrom(err, check, make(map[*Stacktrace]bool))
+}
+
+func classifyFrom(err error, check func(error) (verdict, ok bool), seen map[*Stacktrace]bool) bool {
+	for err != nil {
+		if st, ok := err.(*Stacktrace); ok {
+			if st == nil || seen[st] {
+				return false
+			}
+			seen[st] = true
+		}
+		if verdict, ok := check(err); ok {
+			return verdict
+		}
+		if st, ok := err.(*Stacktrace); ok {
+			err = st.Cause
+			continue
+		}
+		if causes, ok := branchesOf(err); ok {
+			for _, cause := range causes {
+				if classifyFrom(cause, check, seen) {
+					return true
+				}
+			}
+			return false
+		}
+		err = errors.Unwrap(err)
+	}
+	return false
+}
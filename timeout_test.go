@@ -0,0 +1,60 @@
+package stacktrace_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+type netError struct{ timeout, temporary bool }
+
+func (e netError) Error() string   { return "net error" }
+func (e netError) Timeout() bool   { return e.timeout }
+func (e netError) Temporary() bool { return e.temporary }
+
+func TestTimeout(t *testing.T) {
+	stacktrace.UseStandardCodes()
+	err := stacktrace.Propagate(netError{timeout: true}, "inner")
+	err = stacktrace.Propagate(err, "outer")
+	var netErr net.Error
+	if assert.True(t, errors.As(err, &netErr)) {
+		assert.True(t, netErr.Timeout())
+	}
+	assert.True(t, err.(interface{ Timeout() bool }).Timeout())
+	assert.False(t, err.(interface{ Temporary() bool }).Temporary())
+
+	err = stacktrace.Propagate(netError{temporary: true}, "msg")
+	assert.False(t, err.(interface{ Timeout() bool }).Timeout())
+	assert.True(t, err.(interface{ Temporary() bool }).Temporary())
+
+	err = stacktrace.Propagate(&os.PathError{Op: "read", Path: "file", Err: os.ErrDeadlineExceeded}, "msg")
+	assert.True(t, err.(interface{ Timeout() bool }).Timeout())
+	assert.True(t, os.IsTimeout(err))
+
+	err = stacktrace.PropagateAll([]error{errors.New("plain"), netError{timeout: true}}, "msg")
+	assert.True(t, err.(interface{ Timeout() bool }).Timeout())
+
+	err = stacktrace.Propagate(errors.New("plain"), "msg")
+	assert.False(t, err.(interface{ Timeout() bool }).Timeout())
+	assert.False(t, err.(interface{ Temporary() bool }).Temporary())
+
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	err = stacktrace.NewErrorCtx(ctx, "msg")
+	assert.True(t, err.(interface{ Timeout() bool }).Timeout())
+	err = stacktrace.Propagate(fmt.Errorf("wrapped: %w", err), "outer")
+	assert.True(t, err.(interface{ Timeout() bool }).Timeout())
+
+	cyclic := stacktrace.NewError("msg1").(*stacktrace.Stacktrace)
+	cyclic.Cause = fmt.Errorf("wrapped: %w", stacktrace.Propagate(cyclic, "msg2"))
+	assert.False(t, cyclic.Timeout())
+	assert.False(t, cyclic.Temporary())
+}
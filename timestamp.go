@@ -0,0 +1,31 @@
+package stacktrace
+
+import "time"
+
+/*
+CaptureTimestamps controls whether errors record the time they were created, so
+that errors which are kept around, such as the last error of a queued job or of
+a retried operation, show when the failure actually happened. The time is shown
+by Timestamp and included in the structured outputs: Log, the logfmt format and
+MarshalYAML.
+*/
+var CaptureTimestamps = false
+
+// Timestamp returns the time the innermost Stacktrace error in the chain of err
+// that recorded one was created, or the zero time if there is none. See
+// CaptureTimestamps.
+func Timestamp(err error) time.Time {
+	st := deepest(err, func(st *Stacktrace) bool { return !st.Time.IsZero() })
+	if st == nil {
+		return time.Time{}
+	}
+	return st.Time
+}
+
+// timestamp returns the creation time of a new error.
+func timestamp() time.Time {
+	if !CaptureTimestamps {
+		return time.Time{}
+	}
+	return time.Now()
+}
@@ -0,0 +1,32 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestTimestamp(t *testing.T) {
+	assert.True(t, stacktrace.Timestamp(stacktrace.NewError("msg")).IsZero())
+
+	defer func(capture bool) { stacktrace.CaptureTimestamps = capture }(stacktrace.CaptureTimestamps)
+	stacktrace.CaptureTimestamps = true
+
+	before := time.Now()
+	inner := stacktrace.Propagate(errors.New("plain"), "inner")
+	after := time.Now()
+	created := stacktrace.Timestamp(inner)
+	assert.False(t, created.Before(before))
+	assert.False(t, created.After(after))
+
+	time.Sleep(time.Millisecond)
+	outer := fmt.Errorf("wrapped: %w", stacktrace.Propagate(inner, "outer"))
+	assert.Equal(t, created, stacktrace.Timestamp(outer))
+	assert.True(t, stacktrace.Timestamp(errors.New("plain")).IsZero())
+	assert.False(t, stacktrace.Timestamp(stacktrace.NewMessageWithCode(EcodeNoSuchPseudo, "msg")).IsZero())
+}
@@ -0,0 +1,89 @@
+package stacktrace
+
+import (
+	"maps"
+	"strings"
+)
+
+// formatTree renders st like the full format, except that errors with several
+// causes branch into a tree drawn with box-drawing characters:
+//
+//	Failed to sync replicas
+//	 --- at github.com/palantir/shield/sync.go:40 (Sync) ---
+//	├─ Failed to reach replica 1
+//	│   --- at github.com/palantir/shield/sync.go:22 (syncOne) ---
+//	│  Caused by: connection refused
+//	└─ Failed to write to replica 2
+//	    --- at github.com/palantir/shield/sync.go:25 (syncOne) ---
+func formatTree(st *Stacktrace) string {
+	t := treeWalker{seen: make(map[*Stacktrace]bool)}
+	return strings.Join(t.lines(st, false), "\n")
+}
+
+type treeWalker struct {
+	seen  map[*Stacktrace]bool
+	depth int
+}
+
+// lines renders err and its causes, one entry per line. If causedBy is true,
+// the first message is prefixed with "Caused by: ".
+func (t *treeWalker) lines(err error, causedBy bool) []string {
+	var lines []string
+	addMessage := func(msg string) {
+		if causedBy {
+			msg = "Caused by: " + msg
+			causedBy = false
+		}
+		lines = append(lines, strings.Split(msg, "\n")...)
+	}
+
+	for err != nil {
+		st, ok := err.(*Stacktrace)
+		if !ok {
+			if causes, ok := branchesOf(err); ok {
+				return append(lines, t.branches(causes)...)
+			}
+			addMessage(err.Error())
+			return lines
+		}
+		if t.seen[st] || (MaxChainDepth > 0 && t.depth >= MaxChainDepth) {
+			return append(lines, truncatedMarker)
+		}
+		t.seen[st] = true
+		t.depth++
+
+		if msg := st.message(); msg != "" {
+			addMessage(msg)
+		}
+		if st.File != "" {
+			var b strings.Builder
+			writeFrame(&b, Frame{File: st.File, Function: st.Function, Line: st.Line}, " --- at ", " ---")
+			lines = append(lines, b.String())
+		}
+		if _, ok := branchesOf(st.Cause); !ok {
+			causedBy = len(lines) > 0
+		}
+		err = st.Cause
+	}
+	return lines
+}
+
+// branches renders each of causes as a branch of the tree.
+func (t *treeWalker) branches(causes []error) []string {
+	var lines []string
+	for i, cause := range causes {
+		first, rest := "├─ ", "│  "
+		if i == len(causes)-1 {
+			first, rest = "└─ ", "   "
+		}
+		branch := treeWalker{seen: maps.Clone(t.seen), depth: t.depth}
+		for j, line := range branch.lines(cause, false) {
+			if j == 0 {
+				lines = append(lines, first+line)
+			} else {
+				lines = append(lines, rest+line)
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,39 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestFormatTree(t *testing.T) {
+	defer func(format stacktrace.Format) { stacktrace.DefaultFormat = format }(stacktrace.DefaultFormat)
+	stacktrace.DefaultFormat = stacktrace.FormatTree
+
+	first := stacktrace.Propagate(errors.New("connection refused"), "Failed to reach replica 1")
+	second := stacktrace.NewError("Failed to write to replica 2")
+	nested := errors.Join(errors.New("disk full"), errors.New("quota exceeded"))
+	err := stacktrace.Propagate(errors.Join(first, nil, second, nested), "Failed to sync replicas")
+
+	expected := strings.Join([]string{
+		"Failed to sync replicas",
+		" --- at github.com/palantir/Stacktrace/tree_test.go:# (TestFormatTree) ---",
+		"├─ Failed to reach replica 1",
+		"│   --- at github.com/palantir/Stacktrace/tree_test.go:# (TestFormatTree) ---",
+		"│  Caused by: connection refused",
+		"├─ Failed to write to replica 2",
+		"│   --- at github.com/palantir/Stacktrace/tree_test.go:# (TestFormatTree) ---",
+		"└─ ├─ disk full",
+		"   └─ quota exceeded",
+	}, "\n")
+	assert.Equal(t, expected, normalizeLines(err.Error()))
+
+	// without branches, same as the full format
+	err = stacktrace.Propagate(stacktrace.Propagate(errors.New("plain"), ""), "decorated")
+	assert.Equal(t, fmt.Sprintf("%+s", err), err.Error())
+}
@@ -0,0 +1,44 @@
+package stacktrace
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+/*
+MaxMessageLength limits the length in bytes of the Message of new errors. A
+longer Message, such as one with a whole request body formatted into it, is cut
+short and ends with a marker like "... (1048576 bytes truncated)". A value of
+zero or less means no limit.
+*/
+var MaxMessageLength = 0
+
+/*
+MaxFormattedLength limits the length in bytes of the text produced by formatting
+a Stacktrace, with err.Error() or any of the formatting specifiers, in the same
+way as MaxMessageLength. A value of zero or less means no limit.
+*/
+var MaxFormattedLength = 0
+
+const truncatedBytes = "... (%d bytes truncated)"
+
+// truncate cuts s to at most max bytes, including the marker saying how much
+// was cut, without splitting a UTF-8 sequence. It returns s if max <= 0.
+func truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	cut := max - len(fmt.Sprintf(truncatedBytes, len(s)))
+	if cut < 0 {
+		cut = 0
+	}
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	marker := fmt.Sprintf(truncatedBytes, len(s)-cut)
+	// The marker alone does not fit, so keep what fits of it
+	if cut == 0 {
+		return marker[:min(max, len(marker))]
+	}
+	return s[:cut] + marker
+}
@@ -0,0 +1,44 @@
+package stacktrace_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestMaxMessageLength(t *testing.T) {
+	defer func(max int) { stacktrace.MaxMessageLength = max }(stacktrace.MaxMessageLength)
+	stacktrace.MaxMessageLength = 40
+
+	err := stacktrace.NewError("body: %s", strings.Repeat("x", 100)).(*stacktrace.Stacktrace)
+	assert.Equal(t, "body: xxxxxxxxx... (91 bytes truncated)", err.Message)
+	assert.True(t, len(err.Message) <= 40)
+
+	err = stacktrace.NewError("%s", strings.Repeat("é", 30)).(*stacktrace.Stacktrace)
+	assert.Equal(t, "éééééééé... (44 bytes truncated)", err.Message)
+
+	assert.Equal(t, "short", stacktrace.NewError("short").(*stacktrace.Stacktrace).Message)
+
+	stacktrace.MaxMessageLength = 25
+	assert.Equal(t, "... (100 bytes truncated)", stacktrace.NewError(strings.Repeat("x", 100)).(*stacktrace.Stacktrace).Message)
+	// the marker is cut too when it does not fit
+	stacktrace.MaxMessageLength = 5
+	assert.Equal(t, "... (", stacktrace.NewError(strings.Repeat("x", 100)).(*stacktrace.Stacktrace).Message)
+}
+
+func TestMaxFormattedLength(t *testing.T) {
+	defer func(max int) { stacktrace.MaxFormattedLength = max }(stacktrace.MaxFormattedLength)
+	stacktrace.MaxFormattedLength = 50
+
+	err := stacktrace.Propagate(stacktrace.NewError(strings.Repeat("inner ", 20)), "outer")
+	brief := fmt.Sprintf("%#s", err)
+	assert.True(t, len(brief) <= 50)
+	assert.True(t, strings.HasPrefix(brief, "outer: inner "))
+	assert.True(t, strings.HasSuffix(brief, " bytes truncated)"))
+	assert.True(t, len(err.Error()) <= 50)
+	assert.Equal(t, "short", fmt.Sprintf("%#s", stacktrace.NewError("short")))
+}
@@ -0,0 +1,118 @@
+package stacktrace
+
+import (
+	"errors"
+	"maps"
+)
+
+/*
+Walk calls fn for each error in the cause chain of err, outermost first,
+stopping early if fn returns false. It looks through Stacktrace causes, standard
+wrappers with an Unwrap() error method, and each of the causes of an error with
+several causes, in order:
+
+	var paths []string
+	Stacktrace.Walk(err, func(err error) bool {
+		if perr, ok := err.(*os.PathError); ok {
+			paths = append(paths, perr.Path)
+		}
+		return true
+	})
+
+Errors attached with AddSuppressed are not part of the chain. A Stacktrace
+error that was already visited, as in a cyclic chain, is not visited again.
+*/
+func Walk(err error, fn func(err error) bool) {
+	walk(err, fn, make(map[*Stacktrace]bool))
+}
+
+// walk returns false if fn stopped the walk.
+func walk(err error, fn func(err error) bool, seen map[*Stacktrace]bool) bool {
+	for err != nil {
+		if st, ok := err.(*Stacktrace); ok {
+			if st == nil || seen[st] {
+				return true
+			}
+			seen[st] = true
+		}
+		if !fn(err) {
+			return false
+		}
+		if st, ok := err.(*Stacktrace); ok {
+			err = st.Cause
+			continue
+		}
+		if causes, ok := branchesOf(err); ok {
+			for _, cause := range causes {
+				if !walk(cause, fn, seen) {
+					return false
+				}
+			}
+			return true
+		}
+		err = errors.Unwrap(err)
+	}
+	return true
+}
+
+// ChainTruncated is the text that stands for the rest of a chain that was cut
+// short because it is cyclic or deeper than MaxChainDepth.
+const ChainTruncated = truncatedMarker
+
+/*
+Branches returns the causes of err if it is an error with several causes, such
+as an error from errors.Join, PropagateAll, github.com/hashicorp/go-multierror
+or go.uber.org/multierr, leaving out nil causes. It returns false for Stacktrace
+errors and errors with a single cause.
+*/
+func Branches(err error) ([]error, bool) {
+	return branchesOf(err)
+}
+
+/*
+Chain walks the cause chain of an error the way the formatters do, for
+encoders that convert errors to another representation:
+
+	c := Stacktrace.NewChain()
+	levels, rest, truncated := c.Split(err)
+	if causes, ok := Stacktrace.Branches(rest); ok {
+		for _, cause := range causes {
+			encode(cause, c.Branch())
+		}
+	}
+
+A Chain remembers the levels it walked, so that a cyclic chain is cut where it
+repeats, and cuts chains deeper than MaxChainDepth.
+*/
+type Chain struct {
+	seen map[*Stacktrace]bool
+}
+
+// NewChain returns a Chain that has not walked any level yet.
+func NewChain() *Chain {
+	return &Chain{seen: make(map[*Stacktrace]bool)}
+}
+
+/*
+Split returns the consecutive Stacktrace levels at the start of err, outermost
+first, and rest, the error the last of them wraps. If err is not a Stacktrace
+error, levels is empty and rest is err. If the chain was cut, truncated is true
+and rest is nil; encoders show the cut with ChainTruncated.
+*/
+func (c *Chain) Split(err error) (levels []*Stacktrace, rest error, truncated bool) {
+	st, ok := err.(*Stacktrace)
+	if !ok || st == nil {
+		return nil, err, false
+	}
+	levels, truncated = chainFrom(st, c.seen)
+	if truncated {
+		return levels, nil, true
+	}
+	return levels, levels[len(levels)-1].Cause, false
+}
+
+// Branch returns a Chain to walk one of several causes or a suppressed error
+// with, so that a cause shared by several branches is not mistaken for a cycle.
+func (c *Chain) Branch() *Chain {
+	return &Chain{seen: maps.Clone(c.seen)}
+}
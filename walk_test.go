@@ -0,0 +1,81 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestWalk(t *testing.T) {
+	root := errors.New("root")
+	wrapped := fmt.Errorf("wrapped: %w", root)
+	inner := stacktrace.Propagate(wrapped, "inner")
+	other := stacktrace.NewError("other")
+	joined := errors.Join(inner, other)
+	outer := stacktrace.Propagate(joined, "outer")
+
+	var visited []error
+	stacktrace.Walk(outer, func(err error) bool {
+		visited = append(visited, err)
+		return true
+	})
+	assert.Equal(t, []error{outer, joined, inner, wrapped, root, other}, visited)
+
+	visited = nil
+	stacktrace.Walk(outer, func(err error) bool {
+		visited = append(visited, err)
+		return err != wrapped
+	})
+	assert.Equal(t, []error{outer, joined, inner, wrapped}, visited)
+
+	cyclic := stacktrace.NewError("msg1").(*stacktrace.Stacktrace)
+	cyclic.Cause = stacktrace.Propagate(cyclic, "msg2")
+	count := 0
+	stacktrace.Walk(cyclic, func(err error) bool {
+		count++
+		return true
+	})
+	assert.Equal(t, 2, count)
+
+	stacktrace.Walk(nil, func(err error) bool {
+		t.Fatal("unexpected call for nil error")
+		return true
+	})
+}
+
+func TestChain(t *testing.T) {
+	root := errors.New("root")
+	inner := stacktrace.Propagate(root, "inner")
+	outer := stacktrace.Propagate(inner, "outer")
+
+	c := stacktrace.NewChain()
+	branch := c.Branch()
+	levels, rest, truncated := c.Split(outer)
+	assert.Equal(t, []*stacktrace.Stacktrace{outer.(*stacktrace.Stacktrace), inner.(*stacktrace.Stacktrace)}, levels)
+	assert.Equal(t, root, rest)
+	assert.False(t, truncated)
+
+	// a branch may walk the same levels again, the Chain itself may not
+	levels, _, truncated = branch.Split(inner)
+	assert.Len(t, levels, 1)
+	assert.False(t, truncated)
+	levels, rest, truncated = c.Split(inner)
+	assert.Empty(t, levels)
+	assert.Nil(t, rest)
+	assert.True(t, truncated)
+
+	levels, rest, truncated = stacktrace.NewChain().Split(root)
+	assert.Empty(t, levels)
+	assert.Equal(t, root, rest)
+	assert.False(t, truncated)
+
+	causes, ok := stacktrace.Branches(errors.Join(root, nil, inner))
+	assert.True(t, ok)
+	assert.Equal(t, []error{root, inner}, causes)
+	_, ok = stacktrace.Branches(outer)
+	assert.False(t, ok)
+}
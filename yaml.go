@@ -0,0 +1,117 @@
+package stacktrace
+
+import (
+	"maps"
+	"time"
+)
+
+// yamlStacktrace is the document produced by MarshalYAML for each level of a
+// Stacktrace chain.
+type yamlStacktrace struct {
+	Message    string        `yaml:"message,omitempty"`
+	Code       *ErrorCode    `yaml:"code,omitempty"`
+	StringCode string        `yaml:"string_code,omitempty"`
+	Severity   string        `yaml:"severity,omitempty"`
+	File       string        `yaml:"file,omitempty"`
+	Line       int           `yaml:"line,omitempty"`
+	Function   string        `yaml:"function,omitempty"`
+	TraceID    string        `yaml:"trace_id,omitempty"`
+	SpanID     string        `yaml:"span_id,omitempty"`
+	Time       *time.Time    `yaml:"time,omitempty"`
+	Process    *ProcessInfo  `yaml:"process,omitempty"`
+	Build      *BuildInfo    `yaml:"build,omitempty"`
+	Stack      []Frame       `yaml:"stack,omitempty"`
+	Suppressed []interface{} `yaml:"suppressed,omitempty"`
+	Cause      interface{}   `yaml:"cause,omitempty"`
+}
+
+/*
+MarshalYAML implements the Marshaler interface of gopkg.in/yaml.v2 and
+gopkg.in/yaml.v3, rendering the chain as nested documents instead of a
+multi-Line string:
+
+	message: Failed to load S.H.I.E.L.D. config
+	code: 3
+	file: github.com/palantir/shield/connector/config.go
+	line: 44
+	function: withShieldConfig
+	cause:
+	    message: There isn't enough time
+	    ...
+
+A Cause that is not a Stacktrace is rendered as its Error() string, and the
+causes passed to PropagateAll as a list. A nil Stacktrace is rendered as null.
+*/
+func (st *Stacktrace) MarshalYAML() (interface{}, error) {
+	if st == nil {
+		return nil, nil
+	}
+	return yamlDoc(st, make(map[*Stacktrace]bool)), nil
+}
+
+func yamlDoc(st *Stacktrace, seen map[*Stacktrace]bool) interface{} {
+	levels, truncated := chainFrom(st, seen)
+	if len(levels) == 0 {
+		return truncatedMarker
+	}
+
+	var cause interface{}
+	if last := levels[len(levels)-1]; truncated {
+		cause = truncatedMarker
+	} else if last.Cause != nil {
+		cause = yamlCause(last.Cause, seen)
+	}
+
+	for i := len(levels) - 1; i >= 0; i-- {
+		curr := levels[i]
+		doc := &yamlStacktrace{
+			Message:    curr.message(),
+			StringCode: curr.StringCode,
+			File:       curr.File,
+			Line:       curr.Line,
+			Function:   curr.Function,
+			TraceID:    curr.TraceID,
+			SpanID:     curr.SpanID,
+			Process:    curr.Process,
+			Build:      curr.Build,
+			Stack:      curr.Stack,
+			Cause:      cause,
+		}
+		if !curr.Time.IsZero() {
+			t := curr.Time
+			doc.Time = &t
+		}
+		if curr.Severity != SeverityUnset {
+			doc.Severity = curr.Severity.String()
+		}
+		if curr.Code != NoCode {
+			code := curr.Code
+			doc.Code = &code
+		}
+		for _, suppressed := range curr.Suppressed {
+			doc.Suppressed = append(doc.Suppressed, yamlCause(suppressed, maps.Clone(seen)))
+		}
+		cause = doc
+	}
+	return cause
+}
+
+// yamlCause renders a non-Stacktrace cause as its Error() string, or as a list
+// if it has several causes.
+func yamlCause(cause error, seen map[*Stacktrace]bool) interface{} {
+	if st, ok := cause.(*Stacktrace); ok {
+		if st == nil {
+			return "<nil>"
+		}
+		return yamlDoc(st, seen)
+	}
+	causes, ok := branchesOf(cause)
+	if !ok {
+		return cause.Error()
+	}
+	list := make([]interface{}, len(causes))
+	for i, cause := range causes {
+		list[i] = yamlCause(cause, maps.Clone(seen))
+	}
+	return list
+}
@@ -0,0 +1,48 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/palantir/stacktrace"
+)
+
+func TestMarshalYAML(t *testing.T) {
+	err := stacktrace.PropagateWithCode(stacktrace.Propagate(errors.New("plain"), "inner"), EcodeInvalidVillain, "outer")
+
+	out, yerr := yaml.Marshal(err)
+	assert.NoError(t, yerr)
+	expected := fmt.Sprintf(`message: outer
+code: %d
+file: github.com/palantir/Stacktrace/yaml_test.go
+line: #
+function: TestMarshalYAML
+cause:
+    message: inner
+    file: github.com/palantir/Stacktrace/yaml_test.go
+    line: #
+    function: TestMarshalYAML
+    cause: plain
+`, EcodeInvalidVillain)
+	assert.Equal(t, expected, yamlLines.ReplaceAllString(string(out), "line: #"))
+
+	// nested in another document
+	out, yerr = yaml.Marshal(map[string]error{"error": stacktrace.NewMessageWithCode(0, "msg")})
+	assert.NoError(t, yerr)
+	assert.Equal(t, "error:\n    message: msg\n    code: 0\n", string(out))
+
+	var nilStacktrace *stacktrace.Stacktrace
+	doc, yerr := nilStacktrace.MarshalYAML()
+	assert.NoError(t, yerr)
+	assert.Nil(t, doc)
+	out, yerr = yaml.Marshal(stacktrace.Propagate(nilStacktrace, "msg"))
+	assert.NoError(t, yerr)
+	assert.Contains(t, string(out), "\ncause: <nil>\n")
+}
+
+var yamlLines = regexp.MustCompile(`line: \d+`)